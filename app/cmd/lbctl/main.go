@@ -0,0 +1,471 @@
+// lbctl은 leaderboard 운영용 CLI 입니다.
+// 기본으로 REDIS_ADDR의 redis에 직접 접근하고, -addr를 주면 HTTP API를 사용합니다.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/client"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/pkg/errors"
+)
+
+const usage = `usage: lbctl [-addr URL] [-key API_KEY] [-game GAME -secret SECRET] [-o table|json] <command> [args]
+
+commands:
+  boards                         board 목록과 user 수
+  get <name>                     user의 score와 rank
+  set <name> <score> [attr=value...]
+                                 user 추가 또는 수정
+  delete <name>                  user 삭제
+  top [n]                        상위 n명 (기본 10)
+  export                         전체 user를 JSON으로 출력
+  import <file|->                JSON user 목록을 추가 또는 수정
+  reset -yes                     전체 board 초기화 (archive는 유지)
+  archive <name>                 전체 board를 archive:<name>으로 복사
+  keys                           API key 목록
+  key-add <id> <scope,...> [board,...]
+                                 API key 생성 (scope: read, submit, admin)
+  key-delete <id>                API key 삭제
+`
+
+const defaultTop = 10
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "lbctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("lbctl", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	flags.Usage = func() { fmt.Fprint(stdout, usage) }
+	addr := flags.String("addr", "", "HTTP API 주소 (예: http://localhost:6025). 없으면 REDIS_ADDR 사용")
+	apiKey := flags.String("key", os.Getenv("LB_API_KEY"), "-addr 사용 시 보낼 API key. 기본값은 LB_API_KEY")
+	game := flags.String("game", "", "-addr 사용 시 set, import 요청을 서명할 game")
+	secret := flags.String("secret", os.Getenv("LB_SUBMISSION_SECRET"), "-game의 서명 secret. API key 없이 점수를 기록할 때 필요. 기본값은 LB_SUBMISSION_SECRET")
+	output := flags.String("o", "table", "출력 형식: table, json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *output != "table" && *output != "json" {
+		return errors.New("invalid output format: " + *output)
+	}
+	if (*game == "") != (*secret == "") {
+		return errors.New("-game and -secret must be set together")
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("command is required")
+	}
+
+	var lb leaderboard.Interface
+	var keys keyManager
+	if *addr != "" {
+		options := []client.Option{client.WithAPIKey(*apiKey)}
+		if *secret != "" {
+			options = append(options, client.WithSubmissionSecret(*game, *secret))
+		}
+		lb = client.New(*addr, options...)
+	} else {
+		db, err := redisstorage.New()
+		if err != nil {
+			return errors.Wrap(err, "redisstorage.New")
+		}
+		if lb, err = leaderboard.NewWithStorage(db); err != nil {
+			return errors.Wrap(err, "leaderboard.NewWithStorage")
+		}
+		keys = auth.NewRedisKeyStore(db)
+	}
+	cli := &cli{
+		lb:     lb,
+		keys:   keys,
+		stdin:  stdin,
+		stdout: stdout,
+		json:   *output == "json",
+	}
+	return cli.run(ctx, flags.Arg(0), flags.Args()[1:])
+}
+
+// redis에 저장한 API key 관리
+type keyManager interface {
+	Keys(ctx context.Context) ([]auth.Key, error)
+	AddKey(ctx context.Context, key auth.Key) (string, error)
+	DeleteKey(ctx context.Context, id string) (bool, error)
+}
+
+type cli struct {
+	lb     leaderboard.Interface
+	keys   keyManager
+	stdin  io.Reader
+	stdout io.Writer
+	json   bool
+}
+
+func (c *cli) run(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "boards":
+		return c.boards(ctx)
+	case "get":
+		if len(args) != 1 {
+			return errors.New("usage: get <name>")
+		}
+		return c.get(ctx, args[0])
+	case "set":
+		if len(args) < 2 {
+			return errors.New("usage: set <name> <score> [attr=value...]")
+		}
+		return c.set(ctx, args[0], args[1], args[2:])
+	case "delete":
+		if len(args) != 1 {
+			return errors.New("usage: delete <name>")
+		}
+		return c.delete(ctx, args[0])
+	case "top":
+		n := int64(defaultTop)
+		if len(args) > 0 {
+			parsed, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || parsed <= 0 {
+				return errors.New("invalid n: " + args[0])
+			}
+			n = parsed
+		}
+		return c.top(ctx, n)
+	case "export":
+		return c.export(ctx)
+	case "import":
+		if len(args) != 1 {
+			return errors.New("usage: import <file|->")
+		}
+		return c.importUsers(ctx, args[0])
+	case "reset":
+		if len(args) != 1 || args[0] != "-yes" {
+			return errors.New("reset deletes every user. run with -yes to confirm")
+		}
+		return c.reset(ctx)
+	case "archive":
+		if len(args) != 1 {
+			return errors.New("usage: archive <name>")
+		}
+		return c.archive(ctx, args[0])
+	case "keys":
+		return c.listKeys(ctx)
+	case "key-add":
+		if len(args) < 2 || len(args) > 3 {
+			return errors.New("usage: key-add <id> <scope,...> [board,...]")
+		}
+		return c.addKey(ctx, args[0], args[1], args[2:])
+	case "key-delete":
+		if len(args) != 1 {
+			return errors.New("usage: key-delete <id>")
+		}
+		return c.deleteKey(ctx, args[0])
+	}
+	return errors.New("unknown command: " + command)
+}
+
+func (c *cli) admin() (leaderboard.AdminInterface, error) {
+	admin, ok := c.lb.(leaderboard.AdminInterface)
+	if !ok {
+		return nil, errors.New("admin commands are not supported over HTTP")
+	}
+	return admin, nil
+}
+
+func (c *cli) keyManager() (keyManager, error) {
+	if c.keys == nil {
+		return nil, errors.New("key commands are not supported over HTTP")
+	}
+	return c.keys, nil
+}
+
+// json 모드면 data를, table 모드면 header와 rows를 출력합니다.
+func (c *cli) print(data interface{}, header []string, rows [][]string) error {
+	if c.json {
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		return errors.Wrap(encoder.Encode(data), "encoder.Encode")
+	}
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return errors.Wrap(w.Flush(), "w.Flush")
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+func (c *cli) printUserRanks(userRanks []leaderboard.UserRank) error {
+	rows := make([][]string, 0, len(userRanks))
+	for _, userRank := range userRanks {
+		rows = append(rows, []string{strconv.FormatInt(userRank.Rank, 10), userRank.Name, formatScore(userRank.Score)})
+	}
+	return c.print(userRanks, []string{"RANK", "NAME", "SCORE"}, rows)
+}
+
+func (c *cli) boards(ctx context.Context) error {
+	admin, err := c.admin()
+	if err != nil {
+		return err
+	}
+	boards, err := admin.Boards(ctx)
+	if err != nil {
+		return errors.Wrap(err, "admin.Boards")
+	}
+	rows := make([][]string, 0, len(boards))
+	for _, board := range boards {
+		rows = append(rows, []string{board.Name, strconv.FormatInt(board.Count, 10)})
+	}
+	return c.print(boards, []string{"NAME", "COUNT"}, rows)
+}
+
+func (c *cli) get(ctx context.Context, name string) error {
+	userRank, err := c.lb.GetUser(ctx, name)
+	if err != nil {
+		return errors.Wrap(err, "c.lb.GetUser")
+	}
+	if c.json {
+		return c.print(userRank, nil, nil)
+	}
+	return c.printUserRanks([]leaderboard.UserRank{*userRank})
+}
+
+// 없는 user면 추가합니다.
+func (c *cli) setUser(ctx context.Context, user leaderboard.User) error {
+	err := c.lb.UpdateUser(ctx, user)
+	if leaderboard.StatusCode(err) == http.StatusNotFound {
+		return errors.Wrap(c.lb.AddUser(ctx, user), "c.lb.AddUser")
+	}
+	return errors.Wrap(err, "c.lb.UpdateUser")
+}
+
+func (c *cli) set(ctx context.Context, name string, scoreArg string, segmentArgs []string) error {
+	score, err := strconv.ParseFloat(scoreArg, 64)
+	if err != nil {
+		return errors.New("invalid score: " + scoreArg)
+	}
+	user := leaderboard.User{Name: name, Score: score}
+	for _, arg := range segmentArgs {
+		attr, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return errors.New("invalid segment: " + arg)
+		}
+		if user.Segments == nil {
+			user.Segments = map[string]string{}
+		}
+		user.Segments[attr] = value
+	}
+	if err := c.setUser(ctx, user); err != nil {
+		return err
+	}
+	return c.get(ctx, name)
+}
+
+func (c *cli) delete(ctx context.Context, name string) error {
+	ok, err := c.lb.DeleteUser(ctx, name)
+	if err != nil {
+		return errors.Wrap(err, "c.lb.DeleteUser")
+	}
+	data := struct {
+		Name      string `json:"name"`
+		IsDeleted bool   `json:"is_deleted"`
+	}{name, ok}
+	return c.print(data, []string{"NAME", "DELETED"}, [][]string{{name, strconv.FormatBool(ok)}})
+}
+
+// 순위 순서대로 최대 n명을 MaxPageSize씩 나눠 읽어서 fn에 넘깁니다. n이 0보다 작으면 전체
+func (c *cli) eachUser(ctx context.Context, n int64, fn func(leaderboard.UserRank) error) error {
+	for start := int64(0); n < 0 || start < n; start += leaderboard.MaxPageSize {
+		stop := start + leaderboard.MaxPageSize - 1
+		if n >= 0 && stop >= n {
+			stop = n - 1
+		}
+		userList, err := c.lb.GetUserList(ctx, start, stop)
+		if err != nil {
+			return errors.Wrap(err, "c.lb.GetUserList")
+		}
+		for i, user := range userList {
+			if err := fn(leaderboard.UserRank{User: user, Rank: start + int64(i)}); err != nil {
+				return err
+			}
+		}
+		if int64(len(userList)) < stop-start+1 {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (c *cli) top(ctx context.Context, n int64) error {
+	userRanks := make([]leaderboard.UserRank, 0, n)
+	err := c.eachUser(ctx, n, func(userRank leaderboard.UserRank) error {
+		userRanks = append(userRanks, userRank)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.printUserRanks(userRanks)
+}
+
+// 전체를 메모리에 올리지 않도록 한 명씩 출력합니다.
+func (c *cli) export(ctx context.Context) error {
+	if _, err := fmt.Fprint(c.stdout, "["); err != nil {
+		return errors.Wrap(err, "fmt.Fprint")
+	}
+	first := true
+	err := c.eachUser(ctx, -1, func(userRank leaderboard.UserRank) error {
+		data, err := json.Marshal(userRank.User)
+		if err != nil {
+			return errors.Wrap(err, "json.Marshal")
+		}
+		separator := ",\n  "
+		if first {
+			separator = "\n  "
+			first = false
+		}
+		_, err = fmt.Fprint(c.stdout, separator, string(data))
+		return errors.Wrap(err, "fmt.Fprint")
+	})
+	if err != nil {
+		return err
+	}
+	end := "\n]"
+	if first {
+		end = "]"
+	}
+	_, err = fmt.Fprintln(c.stdout, end)
+	return errors.Wrap(err, "fmt.Fprintln")
+}
+
+func (c *cli) importUsers(ctx context.Context, path string) error {
+	input := c.stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return errors.Wrap(err, "os.Open")
+		}
+		defer file.Close()
+		input = file
+	}
+	users := []leaderboard.User{}
+	if err := json.NewDecoder(input).Decode(&users); err != nil {
+		return errors.Wrap(err, "json.Decode")
+	}
+	for _, user := range users {
+		if err := c.setUser(ctx, user); err != nil {
+			return errors.Wrap(err, "user "+user.Name)
+		}
+	}
+	_, err := fmt.Fprintf(c.stdout, "imported %d users\n", len(users))
+	return errors.Wrap(err, "fmt.Fprintf")
+}
+
+func (c *cli) reset(ctx context.Context) error {
+	admin, err := c.admin()
+	if err != nil {
+		return err
+	}
+	if err := admin.ResetBoard(ctx); err != nil {
+		return errors.Wrap(err, "admin.ResetBoard")
+	}
+	_, err = fmt.Fprintln(c.stdout, "reset")
+	return errors.Wrap(err, "fmt.Fprintln")
+}
+
+func (c *cli) archive(ctx context.Context, name string) error {
+	admin, err := c.admin()
+	if err != nil {
+		return err
+	}
+	count, err := admin.ArchiveBoard(ctx, name)
+	if err != nil {
+		return errors.Wrap(err, "admin.ArchiveBoard")
+	}
+	_, err = fmt.Fprintf(c.stdout, "archived %d users to archive:%s\n", count, name)
+	return errors.Wrap(err, "fmt.Fprintf")
+}
+
+func (c *cli) listKeys(ctx context.Context) error {
+	keys, err := c.keyManager()
+	if err != nil {
+		return err
+	}
+	keyList, err := keys.Keys(ctx)
+	if err != nil {
+		return errors.Wrap(err, "keys.Keys")
+	}
+	rows := make([][]string, 0, len(keyList))
+	for _, key := range keyList {
+		scopes := make([]string, 0, len(key.Scopes))
+		for _, scope := range key.Scopes {
+			scopes = append(scopes, string(scope))
+		}
+		boards := strings.Join(key.Boards, ",")
+		if boards == "" {
+			boards = "*"
+		}
+		rows = append(rows, []string{key.ID, strings.Join(scopes, ","), boards})
+	}
+	return c.print(keyList, []string{"ID", "SCOPES", "BOARDS"}, rows)
+}
+
+func (c *cli) addKey(ctx context.Context, id string, scopeArg string, boardArgs []string) error {
+	keys, err := c.keyManager()
+	if err != nil {
+		return err
+	}
+	key := auth.Key{ID: id}
+	for _, value := range strings.Split(scopeArg, ",") {
+		scope, err := auth.ParseScope(value)
+		if err != nil {
+			return err
+		}
+		key.Scopes = append(key.Scopes, scope)
+	}
+	if len(boardArgs) > 0 {
+		key.Boards = strings.Split(boardArgs[0], ",")
+	}
+	secret, err := keys.AddKey(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, "keys.AddKey")
+	}
+	// key는 저장하지 않으므로 지금만 볼 수 있음
+	data := struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}{id, secret}
+	return c.print(data, []string{"ID", "KEY"}, [][]string{{id, secret}})
+}
+
+func (c *cli) deleteKey(ctx context.Context, id string) error {
+	keys, err := c.keyManager()
+	if err != nil {
+		return err
+	}
+	ok, err := keys.DeleteKey(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "keys.DeleteKey")
+	}
+	data := struct {
+		ID        string `json:"id"`
+		IsDeleted bool   `json:"is_deleted"`
+	}{id, ok}
+	return c.print(data, []string{"ID", "DELETED"}, [][]string{{id, strconv.FormatBool(ok)}})
+}
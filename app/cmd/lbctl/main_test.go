@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/handler"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+type FakeLeaderBoard struct {
+	UserSet  *sortedset.SortedSet
+	Archives map[string]int64
+}
+
+func (lb *FakeLeaderBoard) UserCount(_ context.Context) (int64, error) {
+	return int64(lb.UserSet.GetCount()), nil
+}
+
+func (lb *FakeLeaderBoard) AddUser(_ context.Context, user leaderboard.User) error {
+	if lb.UserSet.GetByKey(user.Name) != nil {
+		return leaderboard.ErrorWithStatusCode(errors.New("already exists name: "+user.Name), http.StatusBadRequest)
+	}
+	lb.UserSet.AddOrUpdate(user.Name, sortedset.SCORE(user.Score), nil)
+	return nil
+}
+
+func (lb *FakeLeaderBoard) GetUser(_ context.Context, name string) (*leaderboard.UserRank, error) {
+	node := lb.UserSet.GetByKey(name)
+	if node == nil {
+		err := leaderboard.ErrorWithStatusCode(errors.New(name), http.StatusNotFound)
+		return nil, errors.Wrap(err, "not exists user")
+	}
+	return &leaderboard.UserRank{
+		User: leaderboard.User{Name: name, Score: float64(node.Score())},
+		Rank: int64(lb.UserSet.GetCount() - lb.UserSet.FindRank(name)),
+	}, nil
+}
+
+func (lb *FakeLeaderBoard) DeleteUser(_ context.Context, name string) (bool, error) {
+	return lb.UserSet.Remove(name) != nil, nil
+}
+
+func (lb *FakeLeaderBoard) UpdateUser(_ context.Context, user leaderboard.User) error {
+	if lb.UserSet.GetByKey(user.Name) == nil {
+		err := leaderboard.ErrorWithStatusCode(errors.New(user.Name), http.StatusNotFound)
+		return errors.Wrap(err, "not exists user")
+	}
+	lb.UserSet.AddOrUpdate(user.Name, sortedset.SCORE(user.Score), nil)
+	return nil
+}
+
+// 높은 점수부터 반환
+func (lb *FakeLeaderBoard) GetUserList(_ context.Context, start int64, stop int64) ([]leaderboard.User, error) {
+	nodes := lb.UserSet.GetByRankRange(int(-start-1), int(-stop-1), false)
+	result := make([]leaderboard.User, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, leaderboard.User{Name: node.Key(), Score: float64(node.Score())})
+	}
+	return result, nil
+}
+
+func (lb *FakeLeaderBoard) Boards(_ context.Context) ([]leaderboard.Board, error) {
+	boards := []leaderboard.Board{{Name: "users", Count: int64(lb.UserSet.GetCount())}}
+	for name, count := range lb.Archives {
+		boards = append(boards, leaderboard.Board{Name: "archive:" + name, Count: count})
+	}
+	return boards, nil
+}
+
+func (lb *FakeLeaderBoard) ResetBoard(_ context.Context) error {
+	lb.UserSet = sortedset.New()
+	return nil
+}
+
+func (lb *FakeLeaderBoard) ArchiveBoard(_ context.Context, name string) (int64, error) {
+	if _, ok := lb.Archives[name]; ok {
+		return 0, leaderboard.ErrorWithStatusCode(errors.New("already exists archive: "+name), http.StatusBadRequest)
+	}
+	lb.Archives[name] = int64(lb.UserSet.GetCount())
+	return lb.Archives[name], nil
+}
+
+func TestCLI(t *testing.T) {
+	ctx := context.Background()
+	lb := &FakeLeaderBoard{
+		UserSet:  sortedset.New(),
+		Archives: map[string]int64{},
+	}
+	stdout := &bytes.Buffer{}
+	c := &cli{lb: lb, stdin: strings.NewReader(""), stdout: stdout}
+	expect := func(expected string, command string, args ...string) {
+		t.Helper()
+		stdout.Reset()
+		if assert.NoError(t, c.run(ctx, command, args)) {
+			assert.Equal(t, expected, stdout.String())
+		}
+	}
+
+	// set은 없으면 추가, 있으면 수정
+	expect("RANK  NAME    SCORE\n0     Minsik  100\n", "set", "Minsik", "100")
+	expect("RANK  NAME  SCORE\n1     Yumi  50\n", "set", "Yumi", "50")
+	expect("RANK  NAME  SCORE\n0     Yumi  500\n", "set", "Yumi", "500")
+
+	expect("RANK  NAME    SCORE\n1     Minsik  100\n", "get", "Minsik")
+	expect("RANK  NAME    SCORE\n0     Yumi    500\n1     Minsik  100\n", "top")
+	expect("RANK  NAME  SCORE\n0     Yumi  500\n", "top", "1")
+	expect("[\n  {\"name\":\"Yumi\",\"score\":500},\n  {\"name\":\"Minsik\",\"score\":100}\n]\n", "export")
+	expect("NAME    DELETED\nMinsik  true\n", "delete", "Minsik")
+
+	// import
+	c.stdin = strings.NewReader(`[{"name": "Yumi", "score": 10}, {"name": "Foo", "score": 20}]`)
+	expect("imported 2 users\n", "import", "-")
+	expect("RANK  NAME  SCORE\n0     Foo   20\n1     Yumi  10\n", "top")
+
+	// archive, reset
+	expect("archived 2 users to archive:2022\n", "archive", "2022")
+	expect("reset\n", "reset", "-yes")
+	expect("NAME          COUNT\nusers         0\narchive:2022  2\n", "boards")
+	expect("[]\n", "export")
+
+	// json 출력
+	c.json = true
+	expect("[\n  {\n    \"name\": \"users\",\n    \"count\": 0\n  },\n  {\n    \"name\": \"archive:2022\",\n    \"count\": 2\n  }\n]\n", "boards")
+}
+
+func TestCLIError(t *testing.T) {
+	ctx := context.Background()
+	lb := &FakeLeaderBoard{
+		UserSet:  sortedset.New(),
+		Archives: map[string]int64{"2022": 0},
+	}
+	c := &cli{lb: lb, stdout: &bytes.Buffer{}}
+
+	assert.EqualError(t, c.run(ctx, "foo", nil), "unknown command: foo")
+	assert.EqualError(t, c.run(ctx, "get", nil), "usage: get <name>")
+	assert.EqualError(t, c.run(ctx, "set", []string{"Minsik", "abc"}), "invalid score: abc")
+	assert.EqualError(t, c.run(ctx, "top", []string{"0"}), "invalid n: 0")
+	assert.Error(t, c.run(ctx, "reset", nil))
+	assert.EqualError(t, c.run(ctx, "archive", []string{"2022"}), "admin.ArchiveBoard: already exists archive: 2022")
+	err := c.run(ctx, "get", []string{"Minsik"})
+	assert.Equal(t, http.StatusNotFound, leaderboard.StatusCode(err))
+
+	// HTTP로는 운영 기능을 사용할 수 없음
+	type onlyInterface struct{ leaderboard.Interface }
+	c.lb = onlyInterface{lb}
+	assert.EqualError(t, c.run(ctx, "boards", nil), "admin commands are not supported over HTTP")
+}
+
+type FakeKeyManager struct {
+	keys []auth.Key
+}
+
+func (m *FakeKeyManager) Keys(_ context.Context) ([]auth.Key, error) {
+	return m.keys, nil
+}
+
+func (m *FakeKeyManager) AddKey(_ context.Context, key auth.Key) (string, error) {
+	m.keys = append(m.keys, key)
+	return "secret-" + key.ID, nil
+}
+
+func (m *FakeKeyManager) DeleteKey(_ context.Context, id string) (bool, error) {
+	for i, key := range m.keys {
+		if key.ID == id {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestKeys(t *testing.T) {
+	ctx := context.Background()
+	stdout := &bytes.Buffer{}
+	c := &cli{keys: &FakeKeyManager{}, stdout: stdout}
+	expect := func(expected string, command string, args ...string) {
+		t.Helper()
+		stdout.Reset()
+		if assert.NoError(t, c.run(ctx, command, args)) {
+			assert.Equal(t, expected, stdout.String())
+		}
+	}
+
+	expect("ID           KEY\ngame-server  secret-game-server\n", "key-add", "game-server", "read,submit")
+	expect("ID  KEY\nkr  secret-kr\n", "key-add", "kr", "read", "segment:country:KR")
+	expect("ID           SCOPES       BOARDS\ngame-server  read,submit  *\nkr           read         segment:country:KR\n", "keys")
+	expect("ID  DELETED\nkr  true\n", "key-delete", "kr")
+
+	assert.Error(t, c.run(ctx, "key-add", []string{"ops", "root"}))
+	c.keys = nil
+	assert.EqualError(t, c.run(ctx, "keys", nil), "key commands are not supported over HTTP")
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	stdout := &bytes.Buffer{}
+
+	assert.EqualError(t, run(ctx, nil, nil, stdout), "command is required")
+	assert.Contains(t, stdout.String(), "usage: lbctl")
+	assert.EqualError(t, run(ctx, []string{"-o", "xml", "top"}, nil, stdout), "invalid output format: xml")
+	require.Error(t, run(ctx, []string{"-addr", "http://127.0.0.1:0", "top"}, nil, stdout))
+	assert.EqualError(t, run(ctx, []string{"-game", "game-1", "top"}, nil, stdout), "-game and -secret must be set together")
+}
+
+type fakeNonceStore struct {
+	nonces map[string]bool
+}
+
+func (s *fakeNonceStore) UseNonce(_ context.Context, game string, nonce string, _ time.Duration) (bool, error) {
+	if s.nonces[game+":"+nonce] {
+		return false, nil
+	}
+	s.nonces[game+":"+nonce] = true
+	return true, nil
+}
+
+func TestRunSigned(t *testing.T) {
+	ctx := context.Background()
+	verifier, err := auth.NewSubmissionVerifier(map[string]string{"game-1": "secret"}, &fakeNonceStore{nonces: map[string]bool{}}, 0)
+	require.NoError(t, err)
+	authn := &handler.Auth{Submissions: verifier}
+	e := echo.New()
+	h := handler.Handler{Leaderboard: &FakeLeaderBoard{UserSet: sortedset.New(), Archives: map[string]int64{}}}
+	e.GET("/users", h.GetUser)
+	e.POST("/users", h.AddUser, authn.RequireSignature())
+	e.PATCH("/users", h.UpdateUser, authn.RequireSignature())
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	// 서명하지 않으면 점수를 기록할 수 없음
+	stdout := &bytes.Buffer{}
+	err = run(ctx, []string{"-addr", server.URL, "-key", "", "-secret", "", "set", "Minsik", "100"}, nil, stdout)
+	assert.Equal(t, http.StatusUnauthorized, leaderboard.StatusCode(err))
+
+	stdout.Reset()
+	err = run(ctx, []string{"-addr", server.URL, "-key", "", "-game", "game-1", "-secret", "secret", "set", "Minsik", "100"}, nil, stdout)
+	if assert.NoError(t, err) {
+		assert.Contains(t, stdout.String(), "Minsik")
+	}
+	stdout.Reset()
+	err = run(ctx, []string{"-addr", server.URL, "-key", "", "-game", "game-1", "-secret", "secret", "import", "-"}, strings.NewReader(`[{"name": "Minsik", "score": 200}, {"name": "Jimin", "score": 150}]`), stdout)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "imported 2 users\n", stdout.String())
+	}
+}
@@ -36,52 +36,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/teapot": {
+        "/admin/bans": {
             "get": {
-                "description": "테스트용",
-                "tags": [
-                    "test"
-                ],
-                "responses": {
-                    "418": {
-                        "description": "I'm a teapot",
-                        "schema": {
-                            "type": "string"
-                        }
+                "security": [
+                    {
+                        "ApiKeyAuth": []
                     }
-                }
-            }
-        },
-        "/users": {
-            "get": {
-                "description": "name으로 User의 score와 rank를 얻습니다.",
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
-                ],
-                "summary": "Show a user info",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "User name",
-                        "name": "name",
-                        "in": "query",
-                        "required": true
-                    }
+                    "Admin"
                 ],
+                "summary": "Get banned users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/leaderboard.UserRank"
-                        }
-                    },
-                    "400": {
-                        "description": "name query param 확인 필요",
-                        "schema": {
-                            "$ref": "#/definitions/handler.messageData"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/leaderboard.Ban"
+                            }
                         }
                     },
                     "500": {
@@ -93,7 +69,12 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "신규 user를 추가합니다.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "user를 순위, 목록, user 수에서 제외합니다. 점수는 지우지 않습니다.\nshadow가 true면 계속 점수를 기록할 수 있고 자기 player token으로 name을 조회하면 자기 순위를 볼 수 있습니다 (다른 요청에는 404). false면 점수 기록도 막습니다.",
                 "consumes": [
                     "application/json"
                 ],
@@ -101,17 +82,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Add a user",
+                "summary": "Ban a user",
                 "parameters": [
                     {
-                        "description": "New User",
-                        "name": "user",
+                        "description": "Ban",
+                        "name": "ban",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/leaderboard.User"
+                            "$ref": "#/definitions/handler.banData"
                         }
                     }
                 ],
@@ -119,7 +100,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/leaderboard.UserRank"
+                            "$ref": "#/definitions/leaderboard.Ban"
                         }
                     },
                     "400": {
@@ -137,14 +118,19 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "기존 user를 삭제합니다.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "차단을 풀고 user를 순위에 되돌립니다.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Delete a user",
+                "summary": "Unban a user",
                 "parameters": [
                     {
                         "type": "string",
@@ -158,7 +144,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.deleteData"
+                            "$ref": "#/definitions/handler.unbanData"
                         }
                     },
                     "400": {
@@ -174,39 +160,184 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "기존 user를 수정합니다.",
-                "consumes": [
+            }
+        },
+        "/admin/reviews": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "점수 기록 규칙(SCORE_RULES_FILE)을 어겨서 검토 대기 중인 기록을 오래된 순서로 반환합니다.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get review queue",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/leaderboard.Review"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reviews/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "검토 대기 중인 기록을 기록하지 않고 지웁니다.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Update a user",
+                "summary": "Discard a review",
                 "parameters": [
                     {
-                        "description": "Updated User",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Review ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/leaderboard.User"
+                            "$ref": "#/definitions/handler.discardReviewData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reviews/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "검토 대기 중인 기록을 규칙 확인 없이 board에 기록합니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Approve a review",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Review ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "404": {
+                        "description": "없는 review",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
                         }
                     }
+                }
+            }
+        },
+        "/admin/snapshots": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "최신 snapshot부터 반환합니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get snapshot list",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/leaderboard.UserRank"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/leaderboard.Snapshot"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "전체 board를 복사해 snapshot을 만듭니다. SNAPSHOT_RETENTION(기본 24)개를 넘는 오래된 snapshot은 지웁니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create a snapshot",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/leaderboard.Snapshot"
                         }
                     },
                     "400": {
-                        "description": "request body 확인 필요",
+                        "description": "같은 초에 만든 snapshot 있음",
                         "schema": {
                             "$ref": "#/definitions/handler.messageData"
                         }
@@ -220,21 +351,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/count": {
-            "get": {
-                "description": "전체 유저 수",
+        "/admin/snapshots/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
+                ],
+                "summary": "Delete a snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get user count",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.userCountData"
+                            "$ref": "#/definitions/handler.deleteSnapshotData"
                         }
                     },
                     "500": {
@@ -246,44 +390,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{start}/to/{stop}": {
+        "/admin/snapshots/{id}/movers": {
             "get": {
-                "description": "user list 를 받아옵니다.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "snapshot 이후 순위가 많이 오른 user, 많이 내려간 user, 새로 추가된 user, 삭제된 user를 반환합니다.\n두 board를 모두 읽어서 비교하므로 user 수가 많으면 느릴 수 있습니다.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Get user list",
+                "summary": "Get rank movers since a snapshot",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "start index",
-                        "name": "start",
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "stop index",
-                        "name": "stop",
-                        "in": "path",
-                        "required": true
+                        "description": "목록별 최대 user 수 (기본 10, 최대 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/leaderboard.UserRank"
-                            }
+                            "$ref": "#/definitions/leaderboard.Movers"
                         }
                     },
                     "400": {
-                        "description": "param 확인 필요",
+                        "description": "limit 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "404": {
+                        "description": "없는 snapshot",
                         "schema": {
                             "$ref": "#/definitions/handler.messageData"
                         }
@@ -296,44 +447,1418 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handler.deleteData": {
-            "type": "object",
-            "properties": {
-                "is_deleted": {
-                    "type": "boolean"
-                },
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "handler.messageData": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "type": "string"
-                }
-            }
-        },
-        "handler.userCountData": {
-            "type": "object",
-            "properties": {
-                "count": {
-                    "type": "integer"
-                }
-            }
         },
-        "leaderboard.User": {
-            "type": "object",
-            "properties": {
+        "/admin/snapshots/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "전체 board를 snapshot 시점의 점수로 되돌립니다. group 소속과 segment 속성은 현재 상태를 유지합니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Restore a snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "404": {
+                        "description": "없는 snapshot",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{group}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "그룹(클랜)의 score, rank, 멤버 수를 얻습니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Show a group info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/leaderboard.GroupRank"
+                        }
+                    },
+                    "404": {
+                        "description": "없는 그룹",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{group}/members": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "그룹(클랜)의 멤버 목록과 각 멤버의 score를 얻습니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Get group members",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/leaderboard.User"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "user를 그룹(클랜)에 가입시킵니다. 다른 그룹에 속해 있었다면 이동합니다.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Join a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.memberData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/leaderboard.GroupRank"
+                        }
+                    },
+                    "400": {
+                        "description": "request body 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "403": {
+                        "description": "다른 user의 player token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "user를 그룹(클랜)에서 탈퇴시킵니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Leave a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.leaveData"
+                        }
+                    },
+                    "400": {
+                        "description": "name 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "403": {
+                        "description": "다른 user의 player token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{start}/to/{stop}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "그룹(클랜) 순위 list 를 받아옵니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Get group list",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "start index",
+                        "name": "start",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "stop index",
+                        "name": "stop",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/leaderboard.Group"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "param 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/teapot": {
+            "get": {
+                "description": "테스트용",
+                "tags": [
+                    "test"
+                ],
+                "responses": {
+                    "418": {
+                        "description": "I'm a teapot",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "name으로 User의 score와 rank를 얻습니다. name 없이 limit이나 cursor를 보내면 GET /users/list 와 같이 user page를 반환합니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Show a user info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Segment (예: country:KR)",
+                        "name": "segment",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/leaderboard.UserRank"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "PATCH, DELETE의 If-Match에 사용"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "name, segment query param 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "신규 user를 추가합니다. segments가 있으면 해당 segment board에도 함께 기록합니다.\n서명 모드(SUBMISSION_SECRETS_FILE)에서 API key 없이 보내면 body에 game, nonce, timestamp, signature가 필요합니다.\nsignature는 board, name, score, timestamp, nonce, segments(attr 순으로 정렬한 query string)를 서명한 값입니다 (auth.SignSubmission).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Add a user",
+                "parameters": [
+                    {
+                        "description": "New User",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/leaderboard.User"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "같은 key로 다시 보내면 첫 응답을 다시 보냄",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/leaderboard.UserRank"
+                        }
+                    },
+                    "202": {
+                        "description": "점수 기록 규칙 위반으로 검토 대기",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "400": {
+                        "description": "request body 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "401": {
+                        "description": "서명 확인 실패",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "403": {
+                        "description": "다른 user의 player token, 차단된 user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "409": {
+                        "description": "이미 사용한 nonce, 같은 Idempotency-Key 요청 처리 중",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "422": {
+                        "description": "다른 요청에 사용한 Idempotency-Key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "429": {
+                        "description": "분당 기록 횟수 초과",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "기존 user를 삭제합니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "같은 key로 다시 보내면 첫 응답을 다시 보냄",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "GET /users의 ETag. 다르면 삭제하지 않음",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.deleteData"
+                        }
+                    },
+                    "400": {
+                        "description": "name 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "409": {
+                        "description": "같은 Idempotency-Key 요청 처리 중",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "412": {
+                        "description": "ETag 불일치",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "422": {
+                        "description": "다른 요청에 사용한 Idempotency-Key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "기존 user를 수정합니다. 바뀐 segments는 segment board에도 반영됩니다.\n서명 모드(SUBMISSION_SECRETS_FILE)에서 API key 없이 보내면 body에 game, nonce, timestamp, signature가 필요합니다.\nsignature는 board, name, score, timestamp, nonce, segments(attr 순으로 정렬한 query string)를 서명한 값입니다 (auth.SignSubmission).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update a user",
+                "parameters": [
+                    {
+                        "description": "Updated User",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/leaderboard.User"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "같은 key로 다시 보내면 첫 응답을 다시 보냄",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "GET /users의 ETag. 다르면 수정하지 않음",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/leaderboard.UserRank"
+                        }
+                    },
+                    "202": {
+                        "description": "점수 기록 규칙 위반으로 검토 대기",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "400": {
+                        "description": "request body 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "401": {
+                        "description": "서명 확인 실패",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "403": {
+                        "description": "다른 user의 player token, 차단된 user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "409": {
+                        "description": "이미 사용한 nonce, 같은 Idempotency-Key 요청 처리 중",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "412": {
+                        "description": "ETag 불일치",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "422": {
+                        "description": "다른 요청에 사용한 Idempotency-Key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "429": {
+                        "description": "분당 기록 횟수 초과",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/count": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "전체 유저 수",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get user count",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Segment (예: country:KR)",
+                        "name": "segment",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.userCountData"
+                        }
+                    },
+                    "400": {
+                        "description": "segment 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "전체 user를 순위 순서대로 내려받습니다. 서버는 500명씩 나눠 읽어 바로 보냅니다.\ncsv는 name, score와 SEGMENT_ATTRIBUTES의 segment column을 가집니다.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Export users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv, jsonl, json (기본 json)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/leaderboard.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "format 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/group": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "user가 속한 그룹(클랜)의 score, rank를 얻습니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Show a user's group info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/leaderboard.GroupRank"
+                        }
+                    },
+                    "400": {
+                        "description": "name query param 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "404": {
+                        "description": "그룹 없음",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "csv, jsonl, json 파일의 user를 500명씩 나눠 추가 또는 수정합니다.\n잘못된 record, 차단된 user, 점수 규칙을 어긴 record는 건너뛰고 결과에 기록합니다(최대 100개). dry_run이면 검사만 합니다.\ncsv는 name, score column이 필요하며 나머지 column은 segment 속성으로 읽습니다.\n파일을 읽다가 중간에 실패하면 그 전까지 저장한 결과를 result에 담아 반환합니다.",
+                "consumes": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Import users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv, jsonl, json (기본 json)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "true면 저장하지 않고 검사만 함",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "description": "user 파일",
+                        "name": "users",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/leaderboard.ImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "format, 파일 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.importErrorData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.importErrorData"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "user list 를 cursor 기반으로 받아옵니다. 다음 page는 응답의 next_cursor로 요청합니다.\nlimit은 최대 100까지 허용됩니다. cursor는 (score, name) 기준이라 점수가 바뀌지 않은 user는 중복되지 않지만,\n읽는 도중 점수가 cursor를 넘어 바뀐 user는 누락되거나 두 번 나올 수 있습니다.\nname 없이 GET /users?limit=\u0026cursor= 로 요청해도 같습니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get user page",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size (기본 20, 최대 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "이전 응답의 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Segment (예: country:KR)",
+                        "name": "segment",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/leaderboard.UserPage"
+                        }
+                    },
+                    "400": {
+                        "description": "param 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Server-Sent Events로 top N과 watch 대상 user의 순위 변화를 받아옵니다.\n연결 직후 현재 상태를 보내고, 이후에는 바뀐 부분만 보냅니다.\ntop N은 GET /users/{start}/to/{stop}과 같이 0위부터 N-1위(점수가 낮은 순)입니다.\nevent: top (data: user list), rank (data: user rank, 없는 user는 rank -1), error",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Stream rank changes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "top N (기본 10, 최대 100, 0이면 top을 보내지 않음)",
+                        "name": "top",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "순위를 받을 user name 목록 (쉼표로 구분)",
+                        "name": "watch",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "param 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{start}/to/{stop}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "user list 를 받아옵니다. 한 번에 최대 100명까지 받을 수 있습니다.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get user list",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "start index",
+                        "name": "start",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "stop index",
+                        "name": "stop",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Segment (예: country:KR)",
+                        "name": "segment",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/leaderboard.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "param 확인 필요",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "WebSocket으로 top N, 특정 user의 순위, user 주변 순위(around)를 구독합니다.\n구독 직후 현재 상태를 보내고, 이후에는 바뀐 부분만 보냅니다.\ntop N은 GET /users/{start}/to/{stop}과 같이 0위부터 N-1위(점수가 낮은 순)입니다.\n요청: {\"action\": \"subscribe\"|\"unsubscribe\", \"channel\": \"top\"|\"rank\"|\"around\", \"name\": \"user name\", \"size\": N}\n응답: {\"type\": \"top\"|\"rank\"|\"around\"|\"error\", \"users\": [...], \"user\": {...}, \"name\": \"...\", \"message\": \"...\"}\n느린 client에게는 중간 변경을 건너뛰고 최신 상태만 보냅니다.",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Subscribe rank changes",
+                "responses": {
+                    "101": {
+                        "description": "switching protocols",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "서버에러",
+                        "schema": {
+                            "$ref": "#/definitions/handler.messageData"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.banData": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "shadow": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handler.deleteData": {
+            "type": "object",
+            "properties": {
+                "is_deleted": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.deleteSnapshotData": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "is_deleted": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handler.discardReviewData": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "is_discarded": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handler.importErrorData": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "error 응답에만 포함. X-Request-ID header와 같음",
+                    "type": "string"
+                },
+                "result": {
+                    "$ref": "#/definitions/leaderboard.ImportResult"
+                }
+            }
+        },
+        "handler.leaveData": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "is_left": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.memberData": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.messageData": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "error 응답에만 포함. X-Request-ID header와 같음",
+                    "type": "string"
+                }
+            }
+        },
+        "handler.unbanData": {
+            "type": "object",
+            "properties": {
+                "is_unbanned": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.userCountData": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "leaderboard.Ban": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "shadow": {
+                    "description": "true면 계속 점수를 기록할 수 있고 자기 player token으로 name을 조회하면 자기 순위를 볼 수 있지만, 목록과 user 수에는 나오지 않음\nfalse면 점수 기록도 403",
+                    "type": "boolean"
+                }
+            }
+        },
+        "leaderboard.Group": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "leaderboard.GroupRank": {
+            "type": "object",
+            "properties": {
+                "member_count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "leaderboard.ImportError": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "record": {
+                    "type": "integer"
+                }
+            }
+        },
+        "leaderboard.ImportResult": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/leaderboard.ImportError"
+                    }
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "leaderboard.Movers": {
+            "type": "object",
+            "properties": {
+                "climbers": {
+                    "description": "순위가 가장 많이 오른 user부터",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/leaderboard.UserMove"
+                    }
+                },
+                "dropped_user_count": {
+                    "type": "integer"
+                },
+                "dropped_users": {
+                    "description": "snapshot 이후 삭제된 user. snapshot 순위 순서",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/leaderboard.UserRank"
+                    }
+                },
+                "fallers": {
+                    "description": "순위가 가장 많이 내려간 user부터",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/leaderboard.UserMove"
+                    }
+                },
+                "new_user_count": {
+                    "type": "integer"
+                },
+                "new_users": {
+                    "description": "snapshot 이후 추가된 user. 현재 순위 순서",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/leaderboard.UserRank"
+                    }
+                },
+                "snapshot": {
+                    "$ref": "#/definitions/leaderboard.Snapshot"
+                }
+            }
+        },
+        "leaderboard.Review": {
+            "type": "object",
+            "properties": {
+                "board": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "update": {
+                    "description": "기존 user 수정이면 true",
+                    "type": "boolean"
+                },
+                "user": {
+                    "$ref": "#/definitions/leaderboard.User"
+                }
+            }
+        },
+        "leaderboard.Snapshot": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "leaderboard.User": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "segments": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "leaderboard.UserMove": {
+            "type": "object",
+            "properties": {
                 "name": {
                     "type": "string"
                 },
+                "prev_rank": {
+                    "type": "integer"
+                },
+                "prev_score": {
+                    "type": "number"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "rank_delta": {
+                    "type": "integer"
+                },
                 "score": {
                     "type": "number"
+                },
+                "score_delta": {
+                    "type": "number"
+                }
+            }
+        },
+        "leaderboard.UserPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/leaderboard.User"
+                    }
                 }
             }
         },
@@ -348,9 +1873,27 @@ const docTemplate = `{
                 },
                 "score": {
                     "type": "number"
+                },
+                "segments": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
@@ -1,15 +1,36 @@
 package main
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
 	_ "github.com/JeongMinSik/go-leaderboard/docs"
 
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/grpcserver"
 	"github.com/JeongMinSik/go-leaderboard/pkg/handler"
 	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
 	"github.com/JeongMinSik/go-leaderboard/pkg/logger"
+	"github.com/JeongMinSik/go-leaderboard/pkg/metrics"
+	"github.com/JeongMinSik/go-leaderboard/pkg/ratelimit"
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/JeongMinSik/go-leaderboard/pkg/tracing"
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"google.golang.org/grpc"
 )
 
+// 종료할 때 처리 중인 요청을 기다리는 시간
+const shutdownTimeout = 10 * time.Second
+
 // @title       Leaderboard API
 // @version     1.0
 // @description go언어로 만든 리더보드 api 토이프로젝트입니다.
@@ -19,36 +40,347 @@ import (
 // @contact.email jms6025a@naver.com
 
 // @host localhost:6025
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in                         header
+// @name                       X-API-Key
+
+// @securityDefinitions.apikey BearerAuth
+// @in                         header
+// @name                       Authorization
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	e := echo.New()
-	setupLogger(e)
-	lb, err := leaderboard.New()
+	if err := setupIPExtractor(e, os.Getenv("TRUSTED_PROXIES")); err != nil {
+		e.Logger.Fatal(err)
+	}
+	log, err := setupLogger(e, os.Getenv("LOG_SINK"), os.Getenv("LOG_FILE"), os.Getenv("LOG_FILE_MAX_SIZE"))
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	shutdownTracing, err := setupTracing(os.Getenv("OTEL_TRACES_EXPORTER"))
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	// LeaderBoard와 key, nonce, rate limit bucket, idempotency 응답이 같은 redis client를 사용
+	db, err := redisstorage.New()
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	lb, err := leaderboard.NewWithStorage(db)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	keys, err := setupKeyStore(os.Getenv("API_KEYS_FILE"), os.Getenv("API_KEYS_REDIS"), db)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	players, err := setupPlayers(os.Getenv("JWT_SECRET"), os.Getenv("JWT_JWKS_FILE"))
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	submissions, err := setupSubmissions(os.Getenv("SUBMISSION_SECRETS_FILE"), os.Getenv("SUBMISSION_MAX_AGE"), db)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	limiter, err := setupRateLimits(os.Getenv("RATE_LIMITS_FILE"), db)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	idempotency, err := setupIdempotency(os.Getenv("IDEMPOTENCY_TTL"), db)
 	if err != nil {
 		e.Logger.Fatal(err)
 	}
-	setupHandler(e, lb)
-	e.Logger.Fatal(e.Start(":6025"))
+	if err := setupMetrics(lb); err != nil {
+		e.Logger.Fatal(err)
+	}
+	setupHandler(e, lb, &handler.Auth{Keys: keys, Players: players, Submissions: submissions}, limiter, idempotency)
+	if err := setupSnapshots(ctx, e, lb, os.Getenv("SNAPSHOT_INTERVAL")); err != nil {
+		e.Logger.Fatal(err)
+	}
+	// echo server와 같은 LeaderBoard를 사용하는 gRPC server
+	grpcServer := grpcserver.New(lb, &grpcserver.Auth{Keys: keys, Players: players, Submissions: submissions}, limiter)
+	go func() {
+		if err := serveGRPC(":6026", grpcServer); err != nil {
+			e.Logger.Error(err)
+			stop()
+		}
+	}()
+	go func() {
+		if err := e.Start(":6025"); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Error(err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	shutdown(e, grpcServer, shutdownTracing, log)
+}
+
+func serveGRPC(addr string, grpcServer *grpc.Server) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "net.Listen")
+	}
+	return errors.Wrap(grpcServer.Serve(listener), "grpcServer.Serve")
+}
+
+// 새 요청을 받지 않고 처리 중인 요청을 shutdownTimeout까지 기다린 뒤, 남은 span과 log를 보냅니다.
+// 구독(stream)처럼 끝나지 않는 요청은 shutdownTimeout이 지나면 끊음
+func shutdown(e *echo.Echo, grpcServer *grpc.Server, shutdownTracing func(context.Context) error, log *logger.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := e.Shutdown(ctx); err != nil {
+		e.Logger.Error(errors.Wrap(err, "e.Shutdown"))
+		if err := e.Close(); err != nil {
+			e.Logger.Error(errors.Wrap(err, "e.Close"))
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+
+	// 처리 중이던 요청의 span까지 보내도록 server를 멈춘 뒤에 종료
+	if err := shutdownTracing(context.Background()); err != nil {
+		e.Logger.Error(err)
+	}
+	log.Close()
+}
+
+// interval(예: 1h)마다 snapshot을 만듭니다. 비어 있으면 만들지 않음
+func setupSnapshots(ctx context.Context, e *echo.Echo, lb leaderboard.Interface, interval string) error {
+	if interval == "" {
+		return nil
+	}
+	duration, err := time.ParseDuration(interval)
+	if err != nil || duration <= 0 {
+		return errors.New("invalid snapshot interval: " + interval)
+	}
+	snapshots, ok := lb.(leaderboard.SnapshotInterface)
+	if !ok {
+		return errors.New("snapshots are not supported")
+	}
+	go leaderboard.ScheduleSnapshots(ctx, snapshots, duration, func(err error) {
+		e.Logger.Error(errors.Wrap(err, "snapshots.CreateSnapshot"))
+	})
+	return nil
+}
+
+// 요청 수 제한, idempotency key에서 사용하는 client IP를 정합니다.
+// trustedProxies(쉼표로 구분한 CIDR)가 비어 있으면 header를 믿지 않고 연결한 주소를 사용하고,
+// 있으면 그 범위의 proxy가 붙인 X-Forwarded-For만 믿습니다.
+func setupIPExtractor(e *echo.Echo, trustedProxies string) error {
+	if trustedProxies == "" {
+		e.IPExtractor = echo.ExtractIPDirect()
+		return nil
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range strings.Split(trustedProxies, ",") {
+		_, ipRange, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return errors.New("invalid trusted proxy: " + cidr)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	e.IPExtractor = echo.ExtractIPFromXFFHeader(options...)
+	return nil
+}
+
+// sink(stdout, file, elasticsearch, none)로 요청 log를 보냅니다. maxSize는 file sink가 새 file로 바꾸는 크기(MB)
+func setupLogger(e *echo.Echo, sink string, file string, maxSize string) (*logger.Logger, error) {
+	cfg := logger.Config{
+		Sink:             sink,
+		Name:             "api-log",
+		File:             file,
+		ElasticsearchURL: os.Getenv("ELASTICSEARCH_URL"),
+		SpoolFile:        os.Getenv("LOG_SPOOL_FILE"),
+	}
+	if maxSize != "" {
+		size, err := strconv.Atoi(maxSize)
+		if err != nil || size <= 0 {
+			return nil, errors.New("invalid log file max size: " + maxSize)
+		}
+		cfg.FileMaxSize = size
+	}
+	log, err := logger.New(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "logger.New")
+	}
+	// handler, LeaderBoard에서 요청 ID와 함께 남기는 log도 같은 sink로 보냄
+	logger.SetDefault(log)
+	if log.Sink() != logger.SinkNone {
+		e.Use(log.RequestLogger())
+	}
+	if shipper := log.Shipper(); shipper != nil {
+		err := metrics.Default.RegisterLogShipper(func() metrics.LogShipperStats {
+			stats := shipper.Stats()
+			return metrics.LogShipperStats{
+				Queued:  stats.Queued,
+				Spooled: stats.Spooled,
+				Dropped: stats.Dropped,
+				Shipped: stats.Shipped,
+			}
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "metrics.Default.RegisterLogShipper")
+		}
+	}
+	return log, nil
+}
+
+// API_KEYS_FILE이 있으면 파일, API_KEYS_REDIS가 true면 redis의 key를 사용합니다. 둘 다 없으면 인증하지 않음
+func setupKeyStore(file string, useRedis string, db *redisstorage.RedisStorage) (auth.KeyStore, error) {
+	if file != "" {
+		keys, err := auth.LoadFileKeyStore(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "auth.LoadFileKeyStore")
+		}
+		return keys, nil
+	}
+	if ok, _ := strconv.ParseBool(useRedis); ok {
+		return auth.NewRedisKeyStore(db), nil
+	}
+	return nil, nil
 }
 
-func setupLogger(e *echo.Echo) {
-	log := logger.New()
-	if err := log.AddElasticHook(e, "api-log"); err != nil {
-		log.Panic(err)
+// JWT_SECRET(HS256) 또는 JWT_JWKS_FILE이 있으면 player token을 허용합니다.
+func setupPlayers(secret string, jwksFile string) (*auth.TokenVerifier, error) {
+	if secret == "" && jwksFile == "" {
+		return nil, nil
 	}
+	players, err := auth.NewTokenVerifier(secret, jwksFile)
+	return players, errors.Wrap(err, "auth.NewTokenVerifier")
 }
 
-func setupHandler(e *echo.Echo, lb leaderboard.Interface) {
+// SUBMISSION_SECRETS_FILE이 있으면 API key 없이 보내는 점수 기록 요청에 서명이 필요합니다. nonce는 redis에 저장
+func setupSubmissions(file string, maxAge string, db *redisstorage.RedisStorage) (*auth.SubmissionVerifier, error) {
+	if file == "" {
+		return nil, nil
+	}
+	duration := auth.DefaultSubmissionMaxAge
+	if maxAge != "" {
+		parsed, err := time.ParseDuration(maxAge)
+		if err != nil || parsed <= 0 {
+			return nil, errors.New("invalid submission max age: " + maxAge)
+		}
+		duration = parsed
+	}
+	submissions, err := auth.LoadSubmissionVerifier(file, db, duration)
+	return submissions, errors.Wrap(err, "auth.LoadSubmissionVerifier")
+}
+
+// RATE_LIMITS_FILE이 있으면 route별로 요청 수를 제한합니다. bucket은 redis에 저장
+func setupRateLimits(file string, db *redisstorage.RedisStorage) (*ratelimit.Limiter, error) {
+	if file == "" {
+		return nil, nil
+	}
+	limiter, err := ratelimit.LoadLimiter(file, db)
+	return limiter, errors.Wrap(err, "ratelimit.LoadLimiter")
+}
+
+// Idempotency-Key header가 있는 user 추가/수정/삭제 요청의 응답을 IDEMPOTENCY_TTL(기본 24h) 동안 redis에 저장합니다.
+func setupIdempotency(ttl string, db *redisstorage.RedisStorage) (*handler.Idempotency, error) {
+	duration := handler.DefaultIdempotencyTTL
+	if ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil || parsed <= 0 {
+			return nil, errors.New("invalid idempotency ttl: " + ttl)
+		}
+		duration = parsed
+	}
+	return &handler.Idempotency{Store: db, TTL: duration}, nil
+}
+
+// exporter(otlp, stdout, none)로 span을 보냅니다. 비어 있으면 보내지 않음
+func setupTracing(exporter string) (func(context.Context) error, error) {
+	shutdown, err := tracing.Setup(context.Background(), exporter)
+	if err != nil {
+		return nil, errors.Wrap(err, "tracing.Setup")
+	}
+	return shutdown, nil
+}
+
+// /metrics에서 board별 user 수를 scrape할 때마다 읽습니다. board 목록은 LeaderBoard가 일정 시간 동안 다시 사용
+func setupMetrics(lb leaderboard.Interface) error {
+	admin, ok := lb.(leaderboard.AdminInterface)
+	if !ok {
+		return nil
+	}
+	err := metrics.Default.RegisterBoards(func(ctx context.Context) (map[string]int64, error) {
+		boards, err := admin.Boards(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "admin.Boards")
+		}
+		sizes := make(map[string]int64, len(boards))
+		for _, board := range boards {
+			sizes[board.Name] = board.Count
+		}
+		return sizes, nil
+	})
+	return errors.Wrap(err, "metrics.Default.RegisterBoards")
+}
+
+func setupHandler(e *echo.Echo, lb leaderboard.Interface, authn *handler.Auth, limiter *ratelimit.Limiter, idempotency *handler.Idempotency) {
 	hdler := handler.Handler{
 		Leaderboard: lb,
 	}
+	read := authn.Require(auth.ScopeRead)
+	submit := authn.Require(auth.ScopeSubmit)
+	admin := authn.Require(auth.ScopeAdmin)
+	signed := authn.RequireSignature()
+	limit := handler.RateLimit(limiter)
+	idempotent := idempotency.Require()
+
+	e.Pre(handler.RequestID())
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
+	e.Use(handler.Tracing(), handler.Metrics(metrics.Default))
+	e.GET("/metrics", echo.WrapHandler(metrics.Default.Handler()))
 	e.GET("/", hdler.Hello)
 	e.GET("/teapot", hdler.Teapot)
-	e.GET("/users/count", hdler.GetUserCount)
-	e.GET("/users", hdler.GetUser)
-	e.POST("/users", hdler.AddUser)
-	e.DELETE("/users", hdler.DeleteUser)
-	e.PATCH("/users", hdler.UpdateUser)
-	e.GET("/users/:start/to/:stop", hdler.GetUserList)
+	e.GET("/users/count", hdler.GetUserCount, read, limit)
+	e.GET("/users", hdler.GetUser, read, limit)
+	e.POST("/users", hdler.AddUser, submit, limit, idempotent, signed)
+	e.DELETE("/users", hdler.DeleteUser, admin, limit, idempotent)
+	e.PATCH("/users", hdler.UpdateUser, submit, limit, idempotent, signed)
+	e.GET("/users/:start/to/:stop", hdler.GetUserList, read, limit)
+	e.GET("/users/list", hdler.GetUserPage, read, limit)
+	e.GET("/users/export", hdler.ExportUsers, admin, limit)
+	e.POST("/users/import", hdler.ImportUsers, admin, limit)
+	e.GET("/users/stream", hdler.StreamRanks, read, limit)
+	e.GET("/users/group", hdler.GetUserGroup, read, limit)
+
+	e.GET("/groups/:group", hdler.GetGroup, read, limit)
+	e.GET("/groups/:group/members", hdler.GetGroupMembers, read, limit)
+	e.POST("/groups/:group/members", hdler.JoinGroup, submit, limit)
+	e.DELETE("/groups/:group/members", hdler.LeaveGroup, submit, limit)
+	e.GET("/groups/:start/to/:stop", hdler.GetGroupList, read, limit)
+
+	e.GET("/admin/snapshots", hdler.GetSnapshots, admin, limit)
+	e.POST("/admin/snapshots", hdler.CreateSnapshot, admin, limit)
+	e.GET("/admin/snapshots/:id/movers", hdler.GetMovers, admin, limit)
+	e.POST("/admin/snapshots/:id/restore", hdler.RestoreSnapshot, admin, limit)
+	e.DELETE("/admin/snapshots/:id", hdler.DeleteSnapshot, admin, limit)
+
+	e.GET("/admin/reviews", hdler.GetReviews, admin, limit)
+	e.POST("/admin/reviews/:id/approve", hdler.ApproveReview, admin, limit)
+	e.DELETE("/admin/reviews/:id", hdler.DiscardReview, admin, limit)
+
+	e.GET("/admin/bans", hdler.GetBans, admin, limit)
+	e.POST("/admin/bans", hdler.BanUser, admin, limit)
+	e.DELETE("/admin/bans", hdler.UnbanUser, admin, limit)
+
+	e.GET("/ws", hdler.SubscribeRanks, read, limit)
 
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 }
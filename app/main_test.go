@@ -1,19 +1,186 @@
 package main
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/JeongMinSik/go-leaderboard/pkg/handler"
+	"github.com/JeongMinSik/go-leaderboard/pkg/logger"
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redis/v8"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
 )
 
+func TestSetupIPExtractor(t *testing.T) {
+	e := echo.New()
+	realIP := func(remoteAddr string, forwardedFor string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+		return e.NewContext(req, httptest.NewRecorder()).RealIP()
+	}
+
+	// 기본값은 client가 보낸 header를 믿지 않음
+	assert.NoError(t, setupIPExtractor(e, ""))
+	assert.Equal(t, "203.0.113.7", realIP("203.0.113.7:1234", "198.51.100.1"))
+
+	// 믿을 수 있는 proxy가 붙인 header만 사용
+	assert.NoError(t, setupIPExtractor(e, "10.0.0.0/8, 192.168.0.0/16"))
+	assert.Equal(t, "198.51.100.1", realIP("10.0.0.2:1234", "198.51.100.1"))
+	assert.Equal(t, "203.0.113.7", realIP("203.0.113.7:1234", "198.51.100.1"))
+
+	assert.Error(t, setupIPExtractor(e, "10.0.0.1"))
+}
+
 func TestSetupLogger(t *testing.T) {
 	e := echo.New()
-	assert.Panics(t, func() { setupLogger(e) })
+	_, err := setupLogger(e, logger.SinkNone, "", "")
+	assert.NoError(t, err)
+	_, err = setupLogger(e, logger.SinkFile, filepath.Join(t.TempDir(), "api-log.log"), "10")
+	assert.NoError(t, err)
+	_, err = setupLogger(e, logger.SinkFile, "", "10MB")
+	assert.Error(t, err)
+	_, err = setupLogger(e, "kafka", "", "")
+	assert.Error(t, err)
 }
 
 func TestSetupHandler(t *testing.T) {
 	e := echo.New()
-	setupHandler(e, nil)
+	setupHandler(e, nil, nil, nil, nil)
 	assert.Greater(t, len(e.Routes()), 0)
 }
+
+func TestSetupMetrics(t *testing.T) {
+	assert.NoError(t, setupMetrics(nil))
+}
+
+func TestSetupTracing(t *testing.T) {
+	shutdown, err := setupTracing("")
+	if assert.NoError(t, err) {
+		assert.NoError(t, shutdown(context.Background()))
+	}
+	_, err = setupTracing("invalid")
+	assert.Error(t, err)
+}
+
+func TestServeGRPC(t *testing.T) {
+	assert.Error(t, serveGRPC("invalid address", grpc.NewServer()))
+}
+
+func TestShutdown(t *testing.T) {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	grpcServer := grpc.NewServer()
+	echoStopped := make(chan error, 1)
+	grpcStopped := make(chan error, 1)
+	go func() { echoStopped <- e.Start("127.0.0.1:0") }()
+	go func() { grpcStopped <- serveGRPC("127.0.0.1:0", grpcServer) }()
+	assert.Eventually(t, func() bool { return e.ListenerAddr() != nil }, time.Second, 5*time.Millisecond)
+
+	log, err := logger.New(logger.Config{Sink: logger.SinkNone})
+	assert.NoError(t, err)
+	tracingStopped := false
+	shutdown(e, grpcServer, func(context.Context) error {
+		tracingStopped = true
+		return nil
+	}, log)
+	assert.True(t, tracingStopped)
+	select {
+	case err := <-echoStopped:
+		assert.ErrorIs(t, err, http.ErrServerClosed)
+	case <-time.After(time.Second):
+		t.Fatal("echo server did not stop")
+	}
+	select {
+	case <-grpcStopped:
+	case <-time.After(time.Second):
+		t.Fatal("gRPC server did not stop")
+	}
+}
+
+func TestSetupSnapshots(t *testing.T) {
+	e := echo.New()
+	ctx := context.Background()
+	assert.NoError(t, setupSnapshots(ctx, e, nil, ""))
+	assert.Error(t, setupSnapshots(ctx, e, nil, "hourly"))
+	assert.Error(t, setupSnapshots(ctx, e, nil, "1h"))
+}
+
+// 연결하지 않으므로 redis server가 없어도 됨
+func newTestStorage() *redisstorage.RedisStorage {
+	return redisstorage.NewMock("scores", redis.NewClient(&redis.Options{Addr: "localhost:6379"}))
+}
+
+func TestSetupKeyStore(t *testing.T) {
+	db := newTestStorage()
+	keys, err := setupKeyStore("", "", db)
+	assert.NoError(t, err)
+	assert.Nil(t, keys)
+
+	_, err = setupKeyStore("not-exists.json", "", db)
+	assert.Error(t, err)
+
+	keys, err = setupKeyStore("", "true", db)
+	assert.NoError(t, err)
+	assert.NotNil(t, keys)
+}
+
+func TestSetupPlayers(t *testing.T) {
+	players, err := setupPlayers("", "")
+	assert.NoError(t, err)
+	assert.Nil(t, players)
+
+	players, err = setupPlayers("secret", "")
+	assert.NoError(t, err)
+	assert.NotNil(t, players)
+
+	_, err = setupPlayers("", "not-exists.json")
+	assert.Error(t, err)
+}
+
+func TestSetupSubmissions(t *testing.T) {
+	db := newTestStorage()
+	submissions, err := setupSubmissions("", "", db)
+	assert.NoError(t, err)
+	assert.Nil(t, submissions)
+
+	_, err = setupSubmissions("secrets.json", "soon", db)
+	assert.Error(t, err)
+
+	_, err = setupSubmissions("not-exists.json", "1m", db)
+	assert.Error(t, err)
+}
+
+func TestSetupRateLimits(t *testing.T) {
+	db := newTestStorage()
+	limiter, err := setupRateLimits("", db)
+	assert.NoError(t, err)
+	assert.Nil(t, limiter)
+
+	_, err = setupRateLimits("not-exists.json", db)
+	assert.Error(t, err)
+}
+
+func TestSetupIdempotency(t *testing.T) {
+	db := newTestStorage()
+	_, err := setupIdempotency("forever", db)
+	assert.Error(t, err)
+
+	idempotency, err := setupIdempotency("", db)
+	if assert.NoError(t, err) {
+		assert.Equal(t, handler.DefaultIdempotencyTTL, idempotency.TTL)
+	}
+
+	idempotency, err = setupIdempotency("1h", db)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Hour, idempotency.TTL)
+	}
+}
@@ -0,0 +1,95 @@
+// auth는 API key와 key별 권한(scope, board)을 관리합니다.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/pkg/errors"
+)
+
+type Scope string
+
+const (
+	// 순위 조회
+	ScopeRead Scope = "read"
+	// 점수 기록, group 가입/탈퇴
+	ScopeSubmit Scope = "submit"
+	// user 삭제, import/export, snapshot 등 운영 기능. 다른 모든 scope를 포함
+	ScopeAdmin Scope = "admin"
+)
+
+func ParseScope(value string) (Scope, error) {
+	switch scope := Scope(value); scope {
+	case ScopeRead, ScopeSubmit, ScopeAdmin:
+		return scope, nil
+	}
+	return "", leaderboard.ErrorWithStatusCode(errors.New("invalid scope: "+value), http.StatusBadRequest)
+}
+
+type Key struct {
+	ID     string  `json:"id"`
+	Scopes []Scope `json:"scopes"`
+	// 접근할 수 있는 board (예: users, groups, segment:country:KR). 비어 있으면 모든 board
+	Boards []string `json:"boards,omitempty"`
+}
+
+func (k *Key) Allows(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *Key) AllowsBoard(board string) bool {
+	if len(k.Boards) == 0 {
+		return true
+	}
+	for _, b := range k.Boards {
+		if b == board {
+			return true
+		}
+	}
+	return false
+}
+
+type KeyStore interface {
+	// 없는 key면 nil을 반환합니다.
+	Lookup(ctx context.Context, key string) (*Key, error)
+}
+
+// 저장소에는 key 대신 hash를 저장합니다.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func GenerateKey() (string, error) {
+	data := make([]byte, 24)
+	if _, err := rand.Read(data); err != nil {
+		return "", errors.Wrap(err, "rand.Read")
+	}
+	return "lb_" + hex.EncodeToString(data), nil
+}
+
+func validateKey(key Key) error {
+	if key.ID == "" || strings.ContainsAny(key.ID, " \t\n") {
+		return leaderboard.ErrorWithStatusCode(errors.New("invalid key id: "+key.ID), http.StatusBadRequest)
+	}
+	if len(key.Scopes) == 0 {
+		return leaderboard.ErrorWithStatusCode(errors.New("scopes are required: "+key.ID), http.StatusBadRequest)
+	}
+	for _, scope := range key.Scopes {
+		if _, err := ParseScope(string(scope)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
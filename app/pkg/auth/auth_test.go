@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const apiKeysKey = "scores:api-keys"
+
+var hashPattern = regexp.MustCompile("^[0-9a-f]{64}$")
+
+func TestKey(t *testing.T) {
+	key := &Key{ID: "reader", Scopes: []Scope{ScopeRead}, Boards: []string{"segment:country:KR"}}
+	assert.True(t, key.Allows(ScopeRead))
+	assert.False(t, key.Allows(ScopeSubmit))
+	assert.True(t, key.AllowsBoard("segment:country:KR"))
+	assert.False(t, key.AllowsBoard("users"))
+
+	// admin은 모든 scope 포함, board 제한이 없으면 모든 board
+	admin := &Key{ID: "ops", Scopes: []Scope{ScopeAdmin}}
+	assert.True(t, admin.Allows(ScopeSubmit))
+	assert.True(t, admin.AllowsBoard("users"))
+
+	_, err := ParseScope("root")
+	assert.Equal(t, http.StatusBadRequest, leaderboard.StatusCode(err))
+}
+
+func TestGenerateKey(t *testing.T) {
+	key1, err := GenerateKey()
+	require.NoError(t, err)
+	key2, err := GenerateKey()
+	require.NoError(t, err)
+	assert.Regexp(t, "^lb_[0-9a-f]{48}$", key1)
+	assert.NotEqual(t, key1, key2)
+	assert.Regexp(t, hashPattern, HashKey(key1))
+}
+
+func TestFileKeyStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	data := `[
+		{"id": "game-server", "key": "secret", "scopes": ["read", "submit"]},
+		{"id": "ops", "key_hash": "` + HashKey("ops-secret") + `", "scopes": ["admin"]}
+	]`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	store, err := LoadFileKeyStore(path)
+	require.NoError(t, err)
+	key, err := store.Lookup(ctx, "secret")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Key{ID: "game-server", Scopes: []Scope{ScopeRead, ScopeSubmit}}, key)
+	}
+	key, err = store.Lookup(ctx, "ops-secret")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ops", key.ID)
+	}
+	key, err = store.Lookup(ctx, "wrong")
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+
+	// 잘못된 파일
+	for _, data := range []string{
+		`{"id": "game-server"}`,
+		`[{"id": "game-server", "scopes": ["read"]}]`,
+		`[{"id": "game-server", "key": "secret", "scopes": ["root"]}]`,
+		`[{"id": "", "key": "secret", "scopes": ["read"]}]`,
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		_, err := LoadFileKeyStore(path)
+		assert.Error(t, err, data)
+	}
+	_, err = LoadFileKeyStore(filepath.Join(t.TempDir(), "none.json"))
+	assert.Error(t, err)
+}
+
+func TestRedisKeyStore(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	store := NewRedisKeyStore(redisstorage.NewMock("scores", db))
+	reader, _ := json.Marshal(Key{ID: "reader", Scopes: []Scope{ScopeRead}})
+
+	// Lookup
+	mock.ExpectHGet(apiKeysKey, HashKey("secret")).SetVal(string(reader))
+	key, err := store.Lookup(ctx, "secret")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Key{ID: "reader", Scopes: []Scope{ScopeRead}}, key)
+	}
+	mock.ExpectHGet(apiKeysKey, HashKey("wrong")).RedisNil()
+	key, err = store.Lookup(ctx, "wrong")
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+
+	// Keys
+	ops, _ := json.Marshal(Key{ID: "ops", Scopes: []Scope{ScopeAdmin}})
+	mock.ExpectHGetAll(apiKeysKey).SetVal(map[string]string{"hash1": string(reader), "hash2": string(ops)})
+	keys, err := store.Keys(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Key{{ID: "ops", Scopes: []Scope{ScopeAdmin}}, {ID: "reader", Scopes: []Scope{ScopeRead}}}, keys)
+	}
+
+	// AddKey
+	writer, _ := json.Marshal(Key{ID: "writer", Scopes: []Scope{ScopeSubmit}, Boards: []string{"users"}})
+	addKeyMatch := func(id string, data []byte) func(expected, actual []interface{}) error {
+		return func(expected, actual []interface{}) error {
+			// 저장하는 hash는 만들어진 key에 따라 바뀌므로 형식만 확인
+			if len(actual) != 7 || actual[3] != apiKeysKey || actual[4] != id || !hashPattern.MatchString(actual[5].(string)) || actual[6] != string(data) {
+				return errors.New("unexpected evalsha args")
+			}
+			return nil
+		}
+	}
+	mock.CustomMatch(addKeyMatch("writer", writer)).ExpectEvalSha("sha", []string{apiKeysKey}, "id", "hash", "data").SetVal(int64(1))
+	secret, err := store.AddKey(ctx, Key{ID: "writer", Scopes: []Scope{ScopeSubmit}, Boards: []string{"users"}})
+	if assert.NoError(t, err) {
+		assert.Regexp(t, "^lb_", secret)
+	}
+
+	// AddKey - 같은 id는 추가할 수 없음
+	mock.CustomMatch(addKeyMatch("reader", reader)).ExpectEvalSha("sha", []string{apiKeysKey}, "id", "hash", "data").SetVal(int64(0))
+	_, err = store.AddKey(ctx, Key{ID: "reader", Scopes: []Scope{ScopeRead}})
+	assert.Equal(t, http.StatusBadRequest, leaderboard.StatusCode(err))
+	assert.EqualError(t, err, "already exists key id: reader")
+	_, err = store.AddKey(ctx, Key{ID: "writer"})
+	assert.Equal(t, http.StatusBadRequest, leaderboard.StatusCode(err))
+
+	// DeleteKey
+	mock.ExpectHGetAll(apiKeysKey).SetVal(map[string]string{"hash1": string(reader)})
+	mock.ExpectHDel(apiKeysKey, "hash1").SetVal(1)
+	ok, err := store.DeleteKey(ctx, "reader")
+	if assert.NoError(t, err) {
+		assert.True(t, ok)
+	}
+	mock.ExpectHGetAll(apiKeysKey).SetVal(map[string]string{})
+	ok, err = store.DeleteKey(ctx, "reader")
+	if assert.NoError(t, err) {
+		assert.False(t, ok)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// 설정 파일의 key. Secret 또는 SecretHash(sha256 hex) 중 하나가 필요합니다.
+type fileKey struct {
+	Key
+	Secret     string `json:"key,omitempty"`
+	SecretHash string `json:"key_hash,omitempty"`
+}
+
+// 시작할 때 JSON 파일에서 읽은 key. 파일을 바꾸면 다시 시작해야 합니다.
+type FileKeyStore struct {
+	keys map[string]Key
+}
+
+// 파일 형식: [{"id": "game-server", "key": "...", "scopes": ["read", "submit"], "boards": ["users"]}]
+func LoadFileKeyStore(path string) (*FileKeyStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile")
+	}
+	fileKeys := []fileKey{}
+	if err := json.Unmarshal(data, &fileKeys); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	store := &FileKeyStore{keys: map[string]Key{}}
+	for _, fileKey := range fileKeys {
+		if err := validateKey(fileKey.Key); err != nil {
+			return nil, err
+		}
+		hash := fileKey.SecretHash
+		if fileKey.Secret != "" {
+			hash = HashKey(fileKey.Secret)
+		}
+		if hash == "" {
+			return nil, errors.New("key or key_hash is required: " + fileKey.ID)
+		}
+		store.keys[hash] = fileKey.Key
+	}
+	return store, nil
+}
+
+func (s *FileKeyStore) Lookup(_ context.Context, key string) (*Key, error) {
+	found, ok := s.keys[HashKey(key)]
+	if !ok {
+		return nil, nil
+	}
+	return &found, nil
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+)
+
+// 게임 client가 보내는 player token(JWT)을 검증합니다. token의 sub가 user name입니다.
+// player token은 admin scope가 필요 없는 API만 사용할 수 있고, 점수 기록은 자기 자신만 할 수 있습니다.
+type TokenVerifier struct {
+	// kid별 key. HS256은 []byte, RS256은 *rsa.PublicKey. kid 없이 설정한 secret은 ""
+	keys map[string]interface{}
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// oct (HMAC)
+	K string `json:"k"`
+}
+
+// secret이 있으면 HS256, jwksPath가 있으면 JWKS 파일의 RSA(RS256), oct(HS256) key를 사용합니다.
+func NewTokenVerifier(secret string, jwksPath string) (*TokenVerifier, error) {
+	verifier := &TokenVerifier{keys: map[string]interface{}{}}
+	if secret != "" {
+		verifier.keys[""] = []byte(secret)
+	}
+	if jwksPath != "" {
+		if err := verifier.loadJWKS(jwksPath); err != nil {
+			return nil, err
+		}
+	}
+	if len(verifier.keys) == 0 {
+		return nil, errors.New("jwt secret or jwks file is required")
+	}
+	return verifier, nil
+}
+
+// 파일 형식: {"keys": [{"kty": "RSA", "kid": "game-1", "n": "...", "e": "AQAB"}, {"kty": "oct", "kid": "game-2", "k": "..."}]}
+func (v *TokenVerifier) loadJWKS(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "os.ReadFile")
+	}
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return errors.Wrap(err, "json.Unmarshal")
+	}
+	for _, key := range set.Keys {
+		switch key.Kty {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(key.N)
+			if err != nil {
+				return errors.Wrap(err, "invalid jwk n: "+key.Kid)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(key.E)
+			if err != nil {
+				return errors.Wrap(err, "invalid jwk e: "+key.Kid)
+			}
+			v.keys[key.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "oct":
+			k, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil {
+				return errors.Wrap(err, "invalid jwk k: "+key.Kid)
+			}
+			v.keys[key.Kid] = k
+		default:
+			return errors.New("unsupported jwk kty: " + key.Kty)
+		}
+	}
+	return nil
+}
+
+// kid가 없는 token은 key가 하나뿐일 때만 허용합니다.
+func (v *TokenVerifier) key(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := v.keys[kid]
+	if !ok && kid == "" && len(v.keys) == 1 {
+		for _, only := range v.keys {
+			key, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, errors.New("unknown kid: " + kid)
+	}
+	// HS256 token을 RSA public key로 검증하지 않도록 method와 key 종류를 맞춥니다.
+	switch key.(type) {
+	case []byte:
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("unexpected signing method: " + token.Method.Alg())
+		}
+	case *rsa.PublicKey:
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, errors.New("unexpected signing method: " + token.Method.Alg())
+		}
+	}
+	return key, nil
+}
+
+// 서명과 만료(exp, 필수)를 확인하고 subject를 반환합니다.
+func (v *TokenVerifier) Subject(token string) (string, error) {
+	claims := &jwt.StandardClaims{}
+	parser := &jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}}
+	if _, err := parser.ParseWithClaims(token, claims, v.key); err != nil {
+		return "", leaderboard.ErrorWithStatusCode(errors.Wrap(err, "invalid token"), http.StatusUnauthorized)
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return "", leaderboard.ErrorWithStatusCode(errors.New("invalid token: exp is required"), http.StatusUnauthorized)
+	}
+	if claims.Subject == "" {
+		return "", leaderboard.ErrorWithStatusCode(errors.New("invalid token: sub is required"), http.StatusUnauthorized)
+	}
+	return claims.Subject, nil
+}
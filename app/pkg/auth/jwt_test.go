@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.StandardClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestTokenVerifierSecret(t *testing.T) {
+	verifier, err := NewTokenVerifier("secret", "")
+	require.NoError(t, err)
+	exp := time.Now().Add(time.Hour).Unix()
+
+	subject, err := verifier.Subject(signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), jwt.StandardClaims{Subject: "Alice", ExpiresAt: exp}))
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", subject)
+
+	invalidTokens := []string{
+		"not-a-token",
+		signToken(t, jwt.SigningMethodHS256, "", []byte("wrong"), jwt.StandardClaims{Subject: "Alice", ExpiresAt: exp}),
+		signToken(t, jwt.SigningMethodHS512, "", []byte("secret"), jwt.StandardClaims{Subject: "Alice", ExpiresAt: exp}),
+		signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), jwt.StandardClaims{Subject: "Alice", ExpiresAt: time.Now().Add(-time.Minute).Unix()}),
+		signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), jwt.StandardClaims{Subject: "Alice"}),
+		signToken(t, jwt.SigningMethodHS256, "", []byte("secret"), jwt.StandardClaims{ExpiresAt: exp}),
+	}
+	for _, token := range invalidTokens {
+		_, err := verifier.Subject(token)
+		assert.Equal(t, http.StatusUnauthorized, leaderboard.StatusCode(err), token)
+	}
+
+	_, err = NewTokenVerifier("", "")
+	assert.Error(t, err)
+}
+
+func TestTokenVerifierJWKS(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	encode := base64.RawURLEncoding.EncodeToString
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	data := `{"keys": [
+		{"kty": "RSA", "kid": "game-1", "n": "` + encode(privateKey.N.Bytes()) + `", "e": "` + encode(big.NewInt(int64(privateKey.E)).Bytes()) + `"},
+		{"kty": "oct", "kid": "game-2", "k": "` + encode([]byte("game-2-secret")) + `"}
+	]}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	verifier, err := NewTokenVerifier("", path)
+	require.NoError(t, err)
+	claims := jwt.StandardClaims{Subject: "Bob", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+	subject, err := verifier.Subject(signToken(t, jwt.SigningMethodRS256, "game-1", privateKey, claims))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", subject)
+	subject, err = verifier.Subject(signToken(t, jwt.SigningMethodHS256, "game-2", []byte("game-2-secret"), claims))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", subject)
+
+	// 모르는 kid, kid 없음(key가 여러 개), RSA key로 HS256 검증 시도
+	publicKeyBytes := privateKey.PublicKey.N.Bytes()
+	invalidTokens := []string{
+		signToken(t, jwt.SigningMethodRS256, "game-3", privateKey, claims),
+		signToken(t, jwt.SigningMethodRS256, "", privateKey, claims),
+		signToken(t, jwt.SigningMethodHS256, "game-1", publicKeyBytes, claims),
+	}
+	for _, token := range invalidTokens {
+		_, err := verifier.Subject(token)
+		assert.Equal(t, http.StatusUnauthorized, leaderboard.StatusCode(err), token)
+	}
+
+	_, err = NewTokenVerifier("", "not-exists.json")
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys": [{"kty": "EC", "kid": "game-4"}]}`), 0o600))
+	_, err = NewTokenVerifier("", path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/pkg/errors"
+)
+
+// redis에 저장한 key. 서버를 다시 시작하지 않아도 추가, 삭제가 바로 반영됩니다.
+type RedisKeyStore struct {
+	storage *redisstorage.RedisStorage
+}
+
+func NewRedisKeyStore(storage *redisstorage.RedisStorage) *RedisKeyStore {
+	return &RedisKeyStore{storage: storage}
+}
+
+func (s *RedisKeyStore) Lookup(ctx context.Context, key string) (*Key, error) {
+	ok, data, err := s.storage.APIKey(ctx, HashKey(key))
+	if err != nil || !ok {
+		return nil, errors.Wrap(err, "s.storage.APIKey")
+	}
+	found := &Key{}
+	if err := json.Unmarshal([]byte(data), found); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	return found, nil
+}
+
+// ID 순서로 반환합니다.
+func (s *RedisKeyStore) Keys(ctx context.Context) ([]Key, error) {
+	hashes, err := s.keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Key, 0, len(hashes))
+	for _, key := range hashes {
+		result = append(result, key)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// hash별 key 정보
+func (s *RedisKeyStore) keys(ctx context.Context) (map[string]Key, error) {
+	stored, err := s.storage.APIKeys(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "s.storage.APIKeys")
+	}
+	result := make(map[string]Key, len(stored))
+	for hash, data := range stored {
+		key := Key{}
+		if err := json.Unmarshal([]byte(data), &key); err != nil {
+			return nil, errors.Wrap(err, "json.Unmarshal")
+		}
+		result[hash] = key
+	}
+	return result, nil
+}
+
+// 새 key를 만들어 저장하고 반환합니다. key는 저장하지 않으므로 다시 볼 수 없습니다.
+func (s *RedisKeyStore) AddKey(ctx context.Context, key Key) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	secret, err := GenerateKey()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", errors.Wrap(err, "json.Marshal")
+	}
+	added, err := s.storage.AddAPIKey(ctx, key.ID, HashKey(secret), string(data))
+	if err != nil {
+		return "", errors.Wrap(err, "s.storage.AddAPIKey")
+	}
+	if !added {
+		return "", leaderboard.ErrorWithStatusCode(errors.New("already exists key id: "+key.ID), http.StatusBadRequest)
+	}
+	return secret, nil
+}
+
+func (s *RedisKeyStore) DeleteKey(ctx context.Context, id string) (bool, error) {
+	hashes, err := s.keys(ctx)
+	if err != nil {
+		return false, err
+	}
+	for hash, key := range hashes {
+		if key.ID == id {
+			ok, err := s.storage.DeleteAPIKey(ctx, hash)
+			return ok, errors.Wrap(err, "s.storage.DeleteAPIKey")
+		}
+	}
+	return false, nil
+}
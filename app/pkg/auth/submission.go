@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/pkg/errors"
+)
+
+// 서명의 timestamp와 서버 시각의 최대 차이
+const DefaultSubmissionMaxAge = 5 * time.Minute
+
+const maxNonceLength = 128
+
+// 서명한 점수 기록 요청에 user 정보와 함께 보내는 값
+type Submission struct {
+	Game      string `json:"game"`
+	Nonce     string `json:"nonce"`
+	Timestamp int64  `json:"timestamp"`
+	// SignSubmission으로 만든 HMAC-SHA256 hex
+	Signature string `json:"signature"`
+}
+
+type NonceStore interface {
+	// 처음 사용한 nonce면 ttl 동안 저장하고 true를 반환합니다.
+	UseNonce(ctx context.Context, game string, nonce string, ttl time.Duration) (bool, error)
+}
+
+// game별 secret으로 점수 기록 요청의 서명을 확인합니다.
+type SubmissionVerifier struct {
+	secrets map[string][]byte
+	nonces  NonceStore
+	maxAge  time.Duration
+	now     func() time.Time
+}
+
+func NewSubmissionVerifier(secrets map[string]string, nonces NonceStore, maxAge time.Duration) (*SubmissionVerifier, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("submission secrets are required")
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultSubmissionMaxAge
+	}
+	verifier := &SubmissionVerifier{secrets: map[string][]byte{}, nonces: nonces, maxAge: maxAge, now: time.Now}
+	for game, secret := range secrets {
+		if game == "" || secret == "" {
+			return nil, errors.New("invalid submission secret: " + game)
+		}
+		verifier.secrets[game] = []byte(secret)
+	}
+	return verifier, nil
+}
+
+// 파일 형식: {"game-1": "secret", "game-2": "secret"}
+func LoadSubmissionVerifier(path string, nonces NonceStore, maxAge time.Duration) (*SubmissionVerifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile")
+	}
+	secrets := map[string]string{}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	return NewSubmissionVerifier(secrets, nonces, maxAge)
+}
+
+// 서명할 message: board, user name, score, timestamp, nonce, segments를 줄바꿈으로 연결
+// segments는 attr 순으로 정렬한 query string (예: country=KR&platform=ios). 없으면 빈 줄
+func SubmissionMessage(board string, name string, score float64, segments map[string]string, timestamp int64, nonce string) string {
+	values := url.Values{}
+	for attr, value := range segments {
+		values.Set(attr, value)
+	}
+	return strings.Join([]string{
+		board,
+		name,
+		strconv.FormatFloat(score, 'f', -1, 64),
+		strconv.FormatInt(timestamp, 10),
+		nonce,
+		values.Encode(),
+	}, "\n")
+}
+
+func SignSubmission(secret string, board string, name string, score float64, segments map[string]string, timestamp int64, nonce string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(SubmissionMessage(board, name, score, segments, timestamp, nonce)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// 서명, timestamp를 확인한 뒤 nonce를 사용 처리합니다. 같은 nonce는 다시 사용할 수 없습니다.
+func (v *SubmissionVerifier) Verify(ctx context.Context, board string, user leaderboard.User, submission Submission) error {
+	if submission.Game == "" || submission.Nonce == "" || submission.Timestamp == 0 || submission.Signature == "" {
+		return leaderboard.ErrorWithStatusCode(errors.New("signature is required"), http.StatusUnauthorized)
+	}
+	if len(submission.Nonce) > maxNonceLength {
+		return leaderboard.ErrorWithStatusCode(errors.New("nonce is too long"), http.StatusBadRequest)
+	}
+	secret, ok := v.secrets[submission.Game]
+	if !ok {
+		return leaderboard.ErrorWithStatusCode(errors.New("unknown game: "+submission.Game), http.StatusUnauthorized)
+	}
+	signature, err := hex.DecodeString(submission.Signature)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(SubmissionMessage(board, user.Name, user.Score, user.Segments, submission.Timestamp, submission.Nonce)))
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return leaderboard.ErrorWithStatusCode(errors.New("invalid signature"), http.StatusUnauthorized)
+	}
+	age := v.now().Sub(time.Unix(submission.Timestamp, 0))
+	if age > v.maxAge || age < -v.maxAge {
+		return leaderboard.ErrorWithStatusCode(errors.New("stale timestamp"), http.StatusUnauthorized)
+	}
+	// timestamp가 maxAge 안에 있는 동안만 nonce를 기억하면 됨
+	ok, err = v.nonces.UseNonce(ctx, submission.Game, submission.Nonce, 2*v.maxAge)
+	if err != nil {
+		return errors.Wrap(err, "v.nonces.UseNonce")
+	}
+	if !ok {
+		return leaderboard.ErrorWithStatusCode(errors.New("nonce already used: "+submission.Nonce), http.StatusConflict)
+	}
+	return nil
+}
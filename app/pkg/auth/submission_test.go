@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmissionVerifier(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	verifier, err := NewSubmissionVerifier(map[string]string{"game-1": "secret"}, redisstorage.NewMock("scores", db), time.Minute)
+	require.NoError(t, err)
+	now := time.Unix(1656676800, 0)
+	verifier.now = func() time.Time { return now }
+
+	user := leaderboard.User{Name: "Alice", Score: 1234.5, Segments: map[string]string{"country": "KR", "platform": "ios"}}
+	sign := func(nonce string, timestamp int64) Submission {
+		return Submission{
+			Game:      "game-1",
+			Nonce:     nonce,
+			Timestamp: timestamp,
+			Signature: SignSubmission("secret", "users", user.Name, user.Score, user.Segments, timestamp, nonce),
+		}
+	}
+
+	// 처음 사용한 nonce는 성공, 같은 nonce는 replay
+	mock.ExpectSetNX("scores:nonce:game-1:n1", 1, 2*time.Minute).SetVal(true)
+	assert.NoError(t, verifier.Verify(ctx, "users", user, sign("n1", now.Unix())))
+	mock.ExpectSetNX("scores:nonce:game-1:n1", 1, 2*time.Minute).SetVal(false)
+	err = verifier.Verify(ctx, "users", user, sign("n1", now.Unix()))
+	assert.Equal(t, http.StatusConflict, leaderboard.StatusCode(err))
+
+	// 서명한 값과 다른 점수, 다른 segment, segment 없음, 다른 board, 다른 game, 오래된 timestamp
+	tampered := sign("n2", now.Unix())
+	err = verifier.Verify(ctx, "users", leaderboard.User{Name: "Alice", Score: 1e15, Segments: user.Segments}, tampered)
+	assert.EqualError(t, err, "invalid signature")
+	err = verifier.Verify(ctx, "users", leaderboard.User{Name: "Alice", Score: 1234.5, Segments: map[string]string{"country": "US", "platform": "ios"}}, tampered)
+	assert.EqualError(t, err, "invalid signature")
+	err = verifier.Verify(ctx, "users", leaderboard.User{Name: "Alice", Score: 1234.5}, tampered)
+	assert.EqualError(t, err, "invalid signature")
+	err = verifier.Verify(ctx, "groups", user, tampered)
+	assert.EqualError(t, err, "invalid signature")
+	tampered.Game = "game-2"
+	err = verifier.Verify(ctx, "users", user, tampered)
+	assert.EqualError(t, err, "unknown game: game-2")
+	err = verifier.Verify(ctx, "users", user, sign("n3", now.Add(-2*time.Minute).Unix()))
+	assert.EqualError(t, err, "stale timestamp")
+	err = verifier.Verify(ctx, "users", user, sign("n4", now.Add(2*time.Minute).Unix()))
+	assert.EqualError(t, err, "stale timestamp")
+	err = verifier.Verify(ctx, "users", user, Submission{})
+	assert.Equal(t, http.StatusUnauthorized, leaderboard.StatusCode(err))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmissionMessage(t *testing.T) {
+	// segment는 attr 순으로 정렬
+	message := SubmissionMessage("users", "Alice", 100, map[string]string{"platform": "ios", "country": "KR"}, 1656676800, "n1")
+	assert.Equal(t, "users\nAlice\n100\n1656676800\nn1\ncountry=KR&platform=ios", message)
+	message = SubmissionMessage("users", "Alice", 1234.5, nil, 1656676800, "n1")
+	assert.Equal(t, "users\nAlice\n1234.5\n1656676800\nn1\n", message)
+}
+
+func TestLoadSubmissionVerifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"game-1": "secret"}`), 0o600))
+	verifier, err := LoadSubmissionVerifier(path, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSubmissionMaxAge, verifier.maxAge)
+
+	_, err = LoadSubmissionVerifier("not-exists.json", nil, 0)
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(`{}`), 0o600))
+	_, err = LoadSubmissionVerifier(path, nil, 0)
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(`{"game-1": ""}`), 0o600))
+	_, err = LoadSubmissionVerifier(path, nil, 0)
+	assert.Error(t, err)
+}
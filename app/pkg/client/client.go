@@ -0,0 +1,315 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/handler"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTimeout   = 5 * time.Second
+	defaultRetries   = 2
+	defaultRetryWait = 100 * time.Millisecond
+	// client는 segment board를 지정하지 않으므로 항상 users board로 서명
+	submissionBoard = "users"
+)
+
+// HTTP API를 사용하는 leaderboard.Interface 구현입니다.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	timeout    time.Duration
+	retries    int
+	retryWait  time.Duration
+	apiKey     string
+	game       string
+	secret     string
+}
+
+var _ leaderboard.Interface = (*Client)(nil)
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// 요청마다의 timeout. 재시도하면 다시 적용됩니다. 0이면 ctx의 deadline만 사용
+// WithHTTPClient로 넘긴 http.Client는 바꾸지 않습니다.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// 최대 retries번 재시도합니다. 재시도 간격은 wait부터 두 배씩 늘어납니다.
+func WithRetry(retries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.retryWait = wait
+	}
+}
+
+// 요청마다 X-API-Key header로 보냅니다.
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// AddUser, UpdateUser 요청을 game의 secret으로 서명합니다. API key 없이 점수를 기록할 때 사용
+func WithSubmissionSecret(game string, secret string) Option {
+	return func(c *Client) {
+		c.game = game
+		c.secret = secret
+	}
+}
+
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{},
+		timeout:    defaultTimeout,
+		retries:    defaultRetries,
+		retryWait:  defaultRetryWait,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// server가 보낸 에러. leaderboard.StatusCode로 status code를 얻을 수 있습니다.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) StatusCode() int {
+	return e.Status
+}
+
+type messageData struct {
+	Message string `json:"message"`
+}
+
+type userCountData struct {
+	Count int64 `json:"count"`
+}
+
+// 서명한 점수 기록 요청의 body
+type signedUser struct {
+	leaderboard.User
+	auth.Submission
+}
+
+type deleteData struct {
+	Name      string `json:"name"`
+	IsDeleted bool   `json:"is_deleted"`
+}
+
+func (c *Client) UserCount(ctx context.Context) (int64, error) {
+	data := userCountData{}
+	if err := c.do(ctx, http.MethodGet, "/users/count", nil, &data); err != nil {
+		return 0, err
+	}
+	return data.Count, nil
+}
+
+// 요청이 처리됐는지 알 수 없으므로 AddUser는 재시도하지 않습니다.
+func (c *Client) AddUser(ctx context.Context, user leaderboard.User) error {
+	body, err := c.submission(user)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, "/users", body, nil)
+}
+
+func (c *Client) GetUser(ctx context.Context, name string) (*leaderboard.UserRank, error) {
+	userRank := leaderboard.UserRank{}
+	if err := c.do(ctx, http.MethodGet, "/users?name="+url.QueryEscape(name), nil, &userRank); err != nil {
+		return nil, err
+	}
+	return &userRank, nil
+}
+
+func (c *Client) DeleteUser(ctx context.Context, name string) (bool, error) {
+	data := deleteData{}
+	if err := c.do(ctx, http.MethodDelete, "/users?name="+url.QueryEscape(name), nil, &data); err != nil {
+		return false, err
+	}
+	return data.IsDeleted, nil
+}
+
+func (c *Client) UpdateUser(ctx context.Context, user leaderboard.User) error {
+	body, err := c.submission(user)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPatch, "/users", body, nil)
+}
+
+// secret이 있으면 새 nonce로 서명한 body를 만듭니다.
+// 재시도는 같은 body와 Idempotency-Key를 보내므로 nonce를 다시 사용하지 않습니다.
+func (c *Client) submission(user leaderboard.User) (interface{}, error) {
+	if c.secret == "" {
+		return user, nil
+	}
+	nonce, err := randomHex()
+	if err != nil {
+		return nil, err
+	}
+	timestamp := time.Now().Unix()
+	return signedUser{
+		User: user,
+		Submission: auth.Submission{
+			Game:      c.game,
+			Nonce:     nonce,
+			Timestamp: timestamp,
+			Signature: auth.SignSubmission(c.secret, submissionBoard, user.Name, user.Score, user.Segments, timestamp, nonce),
+		},
+	}, nil
+}
+
+// server는 한 번에 MaxPageSize명까지 보내므로 나눠서 요청합니다.
+func (c *Client) GetUserList(ctx context.Context, start int64, stop int64) ([]leaderboard.User, error) {
+	if start < 0 || stop < start {
+		return nil, &Error{Status: http.StatusBadRequest, Message: "invalid index range"}
+	}
+	result := []leaderboard.User{}
+	for from := start; from <= stop; from += leaderboard.MaxPageSize {
+		to := from + leaderboard.MaxPageSize - 1
+		if to > stop {
+			to = stop
+		}
+		userList := []leaderboard.User{}
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/users/%d/to/%d", from, to), nil, &userList); err != nil {
+			return nil, err
+		}
+		result = append(result, userList...)
+		if int64(len(userList)) < to-from+1 {
+			break
+		}
+	}
+	return result, nil
+}
+
+// 연결 에러나 일시적인 server 에러는 POST를 제외하고 재시도합니다.
+// 재시도할 수 있는 PATCH, DELETE는 모든 시도에 같은 Idempotency-Key를 보내 한 번만 처리되게 합니다.
+func (c *Client) do(ctx context.Context, method string, path string, body interface{}, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return errors.Wrap(err, "json.Marshal")
+		}
+	}
+	idempotencyKey := ""
+	if method != http.MethodGet && method != http.MethodPost && c.retries > 0 {
+		var err error
+		if idempotencyKey, err = randomHex(); err != nil {
+			return err
+		}
+	}
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		err := c.request(ctx, method, path, payload, idempotencyKey, result)
+		if err == nil || method == http.MethodPost || attempt >= c.retries || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "ctx.Done")
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// Idempotency-Key, nonce로 사용하는 임의의 hex
+func randomHex() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "rand.Read")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (c *Client) request(ctx context.Context, method string, path string, payload []byte, idempotencyKey string, result interface{}) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return errors.Wrap(err, "http.NewRequestWithContext")
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set(handler.APIKeyHeader, c.apiKey)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(handler.IdempotencyKeyHeader, idempotencyKey)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "c.httpClient.Do")
+	}
+	defer resp.Body.Close()
+
+	// 검토 대기(202)는 leaderboard.LeaderBoard처럼 StatusAccepted 에러로 돌려줍니다.
+	if resp.StatusCode >= http.StatusBadRequest || resp.StatusCode == http.StatusAccepted {
+		data := messageData{}
+		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil || data.Message == "" {
+			data.Message = http.StatusText(resp.StatusCode)
+		}
+		return &Error{Status: resp.StatusCode, Message: data.Message}
+	}
+	if result == nil {
+		return nil
+	}
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(result), "json.Decode")
+}
+
+func retryable(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// 연결 에러, timeout
+		return true
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
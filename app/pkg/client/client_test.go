@@ -0,0 +1,307 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/handler"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wangjia184/sortedset"
+)
+
+type FakeLeaderBoard struct {
+	UserSet *sortedset.SortedSet
+}
+
+func (lb *FakeLeaderBoard) UserCount(_ context.Context) (int64, error) {
+	return int64(lb.UserSet.GetCount()), nil
+}
+
+func (lb *FakeLeaderBoard) AddUser(_ context.Context, user leaderboard.User) error {
+	if lb.UserSet.GetByKey(user.Name) != nil {
+		return leaderboard.ErrorWithStatusCode(errors.New("already exists name: "+user.Name), http.StatusBadRequest)
+	}
+	lb.UserSet.AddOrUpdate(user.Name, sortedset.SCORE(user.Score), nil)
+	return nil
+}
+
+func (lb *FakeLeaderBoard) GetUser(_ context.Context, name string) (*leaderboard.UserRank, error) {
+	node := lb.UserSet.GetByKey(name)
+	if node == nil {
+		err := leaderboard.ErrorWithStatusCode(errors.New(name), http.StatusNotFound)
+		return nil, errors.Wrap(err, "not exists user")
+	}
+	return &leaderboard.UserRank{
+		User: leaderboard.User{Name: name, Score: float64(node.Score())},
+		Rank: int64(lb.UserSet.GetCount() - lb.UserSet.FindRank(name)),
+	}, nil
+}
+
+func (lb *FakeLeaderBoard) DeleteUser(_ context.Context, name string) (bool, error) {
+	return lb.UserSet.Remove(name) != nil, nil
+}
+
+func (lb *FakeLeaderBoard) UpdateUser(_ context.Context, user leaderboard.User) error {
+	if lb.UserSet.GetByKey(user.Name) == nil {
+		err := leaderboard.ErrorWithStatusCode(errors.New(user.Name), http.StatusNotFound)
+		return errors.Wrap(err, "not exists user")
+	}
+	lb.UserSet.AddOrUpdate(user.Name, sortedset.SCORE(user.Score), nil)
+	return nil
+}
+
+// 높은 점수부터 반환
+func (lb *FakeLeaderBoard) GetUserList(_ context.Context, start int64, stop int64) ([]leaderboard.User, error) {
+	nodes := lb.UserSet.GetByRankRange(int(-start-1), int(-stop-1), false)
+	result := make([]leaderboard.User, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, leaderboard.User{Name: node.Key(), Score: float64(node.Score())})
+	}
+	return result, nil
+}
+
+func newServer(lb leaderboard.Interface) *httptest.Server {
+	e := echo.New()
+	h := handler.Handler{Leaderboard: lb}
+	e.GET("/users/count", h.GetUserCount)
+	e.GET("/users", h.GetUser)
+	e.POST("/users", h.AddUser)
+	e.DELETE("/users", h.DeleteUser)
+	e.PATCH("/users", h.UpdateUser)
+	e.GET("/users/:start/to/:stop", h.GetUserList)
+	return httptest.NewServer(e)
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	server := newServer(&FakeLeaderBoard{UserSet: sortedset.New()})
+	defer server.Close()
+	client := New(server.URL)
+
+	// AddUser
+	assert.NoError(t, client.AddUser(ctx, leaderboard.User{Name: "Minsik", Score: 100}))
+	assert.NoError(t, client.AddUser(ctx, leaderboard.User{Name: "Yumi", Score: 500}))
+	err := client.AddUser(ctx, leaderboard.User{Name: "Minsik", Score: 100})
+	assert.Equal(t, &Error{Status: http.StatusBadRequest, Message: "already exists name: Minsik"}, err)
+
+	// UserCount
+	count, err := client.UserCount(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), count)
+	}
+
+	// GetUser
+	userRank, err := client.GetUser(ctx, "Minsik")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &leaderboard.UserRank{User: leaderboard.User{Name: "Minsik", Score: 100}, Rank: 1}, userRank)
+	}
+	_, err = client.GetUser(ctx, "Foo")
+	assert.Equal(t, http.StatusNotFound, leaderboard.StatusCode(err))
+	assert.EqualError(t, err, "not exists user: Foo")
+
+	// UpdateUser
+	assert.NoError(t, client.UpdateUser(ctx, leaderboard.User{Name: "Minsik", Score: 1000}))
+	err = client.UpdateUser(ctx, leaderboard.User{Name: "Foo", Score: 1000})
+	assert.Equal(t, http.StatusNotFound, leaderboard.StatusCode(err))
+
+	// GetUserList
+	userList, err := client.GetUserList(ctx, 0, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []leaderboard.User{{Name: "Minsik", Score: 1000}, {Name: "Yumi", Score: 500}}, userList)
+	}
+	_, err = client.GetUserList(ctx, 1, 0)
+	assert.Equal(t, http.StatusBadRequest, leaderboard.StatusCode(err))
+
+	// DeleteUser
+	ok, err := client.DeleteUser(ctx, "Yumi")
+	if assert.NoError(t, err) {
+		assert.True(t, ok)
+	}
+	ok, err = client.DeleteUser(ctx, "Yumi")
+	if assert.NoError(t, err) {
+		assert.False(t, ok)
+	}
+}
+
+func TestGetUserListPages(t *testing.T) {
+	ctx := context.Background()
+	lb := &FakeLeaderBoard{UserSet: sortedset.New()}
+	for i := 0; i < leaderboard.MaxPageSize+10; i++ {
+		lb.UserSet.AddOrUpdate(string(rune('A'+i)), sortedset.SCORE(i), nil)
+	}
+	server := newServer(lb)
+	defer server.Close()
+
+	// server 제한보다 큰 범위는 나눠서 요청
+	userList, err := New(server.URL).GetUserList(ctx, 5, 1000)
+	if assert.NoError(t, err) && assert.Len(t, userList, leaderboard.MaxPageSize+5) {
+		assert.Equal(t, float64(leaderboard.MaxPageSize+4), userList[0].Score)
+		assert.Equal(t, float64(0), userList[len(userList)-1].Score)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"count": 3}`))
+	}))
+	defer server.Close()
+
+	// 일시적인 에러는 재시도
+	count, err := New(server.URL, WithRetry(2, time.Millisecond)).UserCount(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(3), count)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	}
+
+	// 재시도 횟수 초과
+	atomic.StoreInt32(&calls, 0)
+	_, err = New(server.URL, WithRetry(1, time.Millisecond)).UserCount(ctx)
+	assert.Equal(t, &Error{Status: http.StatusServiceUnavailable, Message: "Service Unavailable"}, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// POST는 재시도하지 않음
+	atomic.StoreInt32(&calls, 0)
+	err = New(server.URL, WithRetry(2, time.Millisecond)).AddUser(ctx, leaderboard.User{Name: "Minsik"})
+	assert.Equal(t, http.StatusServiceUnavailable, leaderboard.StatusCode(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client := New(server.URL, WithTimeout(10*time.Millisecond), WithRetry(0, 0))
+	_, err := client.UserCount(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, leaderboard.StatusCode(err))
+
+	// 넘겨준 http.Client는 바꾸지 않으며, option 순서와 관계없이 적용
+	httpClient := &http.Client{}
+	client = New(server.URL, WithTimeout(10*time.Millisecond), WithHTTPClient(httpClient), WithRetry(0, 0))
+	_, err = client.UserCount(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, time.Duration(0), httpClient.Timeout)
+}
+
+func TestAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(handler.APIKeyHeader) != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "invalid api key"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"count": 1}`))
+	}))
+	defer server.Close()
+
+	count, err := New(server.URL, WithAPIKey("secret")).UserCount(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1), count)
+	}
+	_, err = New(server.URL).UserCount(context.Background())
+	assert.Equal(t, http.StatusUnauthorized, leaderboard.StatusCode(err))
+}
+
+func TestAccepted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"message": "submission is under review: 7"}`))
+	}))
+	defer server.Close()
+
+	// 검토 대기는 기록되지 않았으므로 에러
+	client := New(server.URL)
+	err := client.AddUser(context.Background(), leaderboard.User{Name: "Minsik", Score: 100})
+	assert.Equal(t, &Error{Status: http.StatusAccepted, Message: "submission is under review: 7"}, err)
+	err = client.UpdateUser(context.Background(), leaderboard.User{Name: "Minsik", Score: 100})
+	assert.Equal(t, http.StatusAccepted, leaderboard.StatusCode(err))
+}
+
+func TestRetryIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	var calls int32
+	keys := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys <- r.Header.Get(handler.IdempotencyKeyHeader)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "Minsik", "is_deleted": true}`))
+	}))
+	defer server.Close()
+
+	// 재시도해도 같은 key
+	isDeleted, err := New(server.URL, WithRetry(2, time.Millisecond)).DeleteUser(ctx, "Minsik")
+	if assert.NoError(t, err) {
+		assert.True(t, isDeleted)
+	}
+	first, second := <-keys, <-keys
+	assert.NotEmpty(t, first)
+	assert.Equal(t, first, second)
+
+	// 요청마다 다른 key
+	_, err = New(server.URL, WithRetry(2, time.Millisecond)).DeleteUser(ctx, "Minsik")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, <-keys)
+
+	// 재시도하지 않으면 보내지 않음
+	_, err = New(server.URL, WithRetry(0, 0)).DeleteUser(ctx, "Minsik")
+	assert.NoError(t, err)
+	assert.Empty(t, <-keys)
+}
+
+type fakeNonceStore struct {
+	nonces map[string]bool
+}
+
+func (s *fakeNonceStore) UseNonce(_ context.Context, game string, nonce string, _ time.Duration) (bool, error) {
+	if s.nonces[game+":"+nonce] {
+		return false, nil
+	}
+	s.nonces[game+":"+nonce] = true
+	return true, nil
+}
+
+func TestSubmissionSecret(t *testing.T) {
+	ctx := context.Background()
+	verifier, err := auth.NewSubmissionVerifier(map[string]string{"game-1": "secret"}, &fakeNonceStore{nonces: map[string]bool{}}, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	authn := &handler.Auth{Submissions: verifier}
+	e := echo.New()
+	h := handler.Handler{Leaderboard: &FakeLeaderBoard{UserSet: sortedset.New()}}
+	e.POST("/users", h.AddUser, authn.RequireSignature())
+	e.PATCH("/users", h.UpdateUser, authn.RequireSignature())
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	client := New(server.URL, WithSubmissionSecret("game-1", "secret"))
+	assert.NoError(t, client.AddUser(ctx, leaderboard.User{Name: "Minsik", Score: 100}))
+	// 요청마다 새 nonce로 서명
+	assert.NoError(t, client.UpdateUser(ctx, leaderboard.User{Name: "Minsik", Score: 200}))
+
+	err = New(server.URL).AddUser(ctx, leaderboard.User{Name: "Jimin", Score: 100})
+	assert.Equal(t, &Error{Status: http.StatusUnauthorized, Message: "signature is required"}, err)
+	err = New(server.URL, WithSubmissionSecret("game-1", "wrong")).AddUser(ctx, leaderboard.User{Name: "Jimin", Score: 100})
+	assert.Equal(t, &Error{Status: http.StatusUnauthorized, Message: "invalid signature"}, err)
+}
@@ -0,0 +1,140 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// HTTP API의 X-API-Key header와 같은 역할
+	apiKeyMetadata = "x-api-key"
+	// "Bearer <token>" 형식의 player token
+	authorizationMetadata = "authorization"
+)
+
+// method 이름별 필요한 scope
+var methodScopes = map[string]auth.Scope{
+	"UserCount":   auth.ScopeRead,
+	"GetUser":     auth.ScopeRead,
+	"GetUserList": auth.ScopeRead,
+	"Subscribe":   auth.ScopeRead,
+	"AddUser":     auth.ScopeSubmit,
+	"UpdateUser":  auth.ScopeSubmit,
+	"DeleteUser":  auth.ScopeAdmin,
+}
+
+// HTTP API의 handler.Auth와 같은 설정. Keys와 Players가 모두 nil이면 인증하지 않습니다.
+// Submissions가 있으면 API key 없이 보내는 AddUser, UpdateUser는 서명이 필요
+type Auth struct {
+	Keys        auth.KeyStore
+	Players     *auth.TokenVerifier
+	Submissions *auth.SubmissionVerifier
+}
+
+func (a *Auth) enabled() bool {
+	return a != nil && (a.Keys != nil || a.Players != nil)
+}
+
+// 인증한 auth.Key를 context에 저장하는 key
+type keyContextKey struct{}
+
+func metadataValue(ctx context.Context, name string) string {
+	values := metadata.ValueFromIncomingContext(ctx, name)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func bearerToken(ctx context.Context) string {
+	if value := metadataValue(ctx, authorizationMetadata); len(value) > 7 && strings.EqualFold(value[:7], "bearer ") {
+		return value[7:]
+	}
+	return ""
+}
+
+// HTTP API의 Auth.Require와 같이 API key 또는 player token을 확인하고, 인증한 key나 subject를 담은 context를 반환합니다.
+// gRPC API는 전체 board만 다루므로 board는 항상 "users"
+func (a *Auth) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	scope, ok := methodScopes[fullMethod[strings.LastIndex(fullMethod, "/")+1:]]
+	if !ok {
+		scope = auth.ScopeAdmin
+	}
+	if token := bearerToken(ctx); token != "" && a.Players != nil {
+		subject, err := a.Players.Subject(token)
+		if err != nil {
+			return nil, err
+		}
+		if scope == auth.ScopeAdmin {
+			return nil, leaderboard.ErrorWithStatusCode(errors.New("player token has no scope: "+string(scope)), http.StatusForbidden)
+		}
+		return leaderboard.WithPlayer(ctx, subject), nil
+	}
+	if a.Keys == nil {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("token is required"), http.StatusUnauthorized)
+	}
+	value := metadataValue(ctx, apiKeyMetadata)
+	if value == "" {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("api key is required"), http.StatusUnauthorized)
+	}
+	key, err := a.Keys.Lookup(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("invalid api key"), http.StatusUnauthorized)
+	}
+	if !key.Allows(scope) {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("api key has no scope: "+string(scope)), http.StatusForbidden)
+	}
+	if !key.AllowsBoard("users") {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("api key is not allowed for board: users"), http.StatusForbidden)
+	}
+	return context.WithValue(ctx, keyContextKey{}, key), nil
+}
+
+// player token으로 인증한 요청은 token의 subject와 같은 user만 수정할 수 있습니다. API key는 모든 user를 수정할 수 있음
+func requirePlayer(ctx context.Context, userName string) error {
+	subject, ok := leaderboard.PlayerFromContext(ctx)
+	if ok && subject != userName {
+		return leaderboard.ErrorWithStatusCode(errors.New("token subject does not match user: "+userName), http.StatusForbidden)
+	}
+	return nil
+}
+
+func (a *Auth) unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// 인증한 context를 handler에 넘기기 위한 stream
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+func (a *Auth) stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return statusError(err)
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
@@ -0,0 +1,115 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/pb"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type FakeKeyStore map[string]auth.Key
+
+func (s FakeKeyStore) Lookup(_ context.Context, key string) (*auth.Key, error) {
+	found, ok := s[key]
+	if !ok {
+		return nil, nil
+	}
+	return &found, nil
+}
+
+func TestAuth(t *testing.T) {
+	lb := &FakeLeaderBoard{UserSet: sortedset.New()}
+	client := newAuthClient(t, lb, &Auth{Keys: FakeKeyStore{
+		"reader": {ID: "reader", Scopes: []auth.Scope{auth.ScopeRead}},
+		"kr":     {ID: "kr", Scopes: []auth.Scope{auth.ScopeRead}, Boards: []string{"segment:country:KR"}},
+		"server": {ID: "server", Scopes: []auth.Scope{auth.ScopeSubmit}},
+	}})
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, key)
+	}
+
+	_, err := client.UserCount(context.Background(), &pb.UserCountRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.UserCount(withKey("wrong"), &pb.UserCountRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.UserCount(withKey("kr"), &pb.UserCountRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.UserCount(withKey("reader"), &pb.UserCountRequest{})
+	assert.NoError(t, err)
+	_, err = client.AddUser(withKey("reader"), &pb.User{Name: "Minsik", Score: 100})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.AddUser(withKey("server"), &pb.User{Name: "Minsik", Score: 100})
+	assert.NoError(t, err)
+	_, err = client.DeleteUser(withKey("server"), &pb.DeleteUserRequest{Name: "Minsik"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// stream
+	stream, err := client.GetUserList(context.Background(), &pb.GetUserListRequest{Start: 0, Stop: 10})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	stream, err = client.GetUserList(withKey("reader"), &pb.GetUserListRequest{Start: 0, Stop: 10})
+	require.NoError(t, err)
+	user, err := stream.Recv()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Minsik", user.GetName())
+	}
+}
+
+// JWT_SECRET만 설정해도 API key나 player token 없이는 요청할 수 없음
+func TestPlayerAuth(t *testing.T) {
+	lb := &FakeLeaderBoard{UserSet: sortedset.New()}
+	players, err := auth.NewTokenVerifier("secret", "")
+	require.NoError(t, err)
+	client := newAuthClient(t, lb, &Auth{Players: players})
+	withToken := func(subject string) context.Context {
+		claims := jwt.StandardClaims{Subject: subject, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		require.NoError(t, err)
+		return metadata.AppendToOutgoingContext(context.Background(), authorizationMetadata, "Bearer "+token)
+	}
+
+	_, err = client.AddUser(context.Background(), &pb.User{Name: "Alice", Score: 10})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.AddUser(metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, "server"), &pb.User{Name: "Alice", Score: 10})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.AddUser(metadata.AppendToOutgoingContext(context.Background(), authorizationMetadata, "Bearer invalid"), &pb.User{Name: "Alice", Score: 10})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// subject와 같은 user만 수정할 수 있음
+	_, err = client.AddUser(withToken("Alice"), &pb.User{Name: "Alice", Score: 10})
+	assert.NoError(t, err)
+	_, err = client.AddUser(withToken("Alice"), &pb.User{Name: "Bob", Score: 20})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.UpdateUser(withToken("Bob"), &pb.User{Name: "Alice", Score: 30})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.UpdateUser(withToken("Alice"), &pb.User{Name: "Alice", Score: 30})
+	assert.NoError(t, err)
+
+	// player token은 admin scope가 없음
+	_, err = client.DeleteUser(withToken("Alice"), &pb.DeleteUserRequest{Name: "Alice"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, 1, lb.UserSet.GetCount())
+
+	// stream
+	stream, err := client.GetUserList(context.Background(), &pb.GetUserListRequest{Start: 0, Stop: 10})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	stream, err = client.GetUserList(withToken("Bob"), &pb.GetUserListRequest{Start: 0, Stop: 10})
+	require.NoError(t, err)
+	user, err := stream.Recv()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Alice", user.GetName())
+	}
+}
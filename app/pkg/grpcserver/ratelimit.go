@@ -0,0 +1,100 @@
+package grpcserver
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/JeongMinSik/go-leaderboard/pkg/ratelimit"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// method 이름별로 같은 기능의 HTTP route. HTTP API와 같은 규칙과 bucket을 사용하므로 gRPC로 우회할 수 없음
+var methodRoutes = map[string]string{
+	"UserCount":   "GET /users/count",
+	"GetUser":     "GET /users",
+	"GetUserList": "GET /users/:start/to/:stop",
+	"Subscribe":   "GET /users/stream",
+	"AddUser":     "POST /users",
+	"UpdateUser":  "PATCH /users",
+	"DeleteUser":  "DELETE /users",
+}
+
+// route에 규칙이 있으면 요청 수를 제한하고, 넘으면 retry-after(초) header를 보냅니다.
+func allow(ctx context.Context, limiter *ratelimit.Limiter, fullMethod string) (metadata.MD, error) {
+	route, ok := methodRoutes[fullMethod[strings.LastIndex(fullMethod, "/")+1:]]
+	if !ok {
+		return nil, nil
+	}
+	rule, ok := limiter.Rule(route)
+	if !ok {
+		return nil, nil
+	}
+	allowed, retryAfter, err := limiter.Allow(ctx, route, requestIdentity(ctx, rule.Key))
+	if err != nil {
+		return nil, errors.Wrap(err, "limiter.Allow")
+	}
+	if !allowed {
+		seconds := int(math.Max(1, math.Ceil(retryAfter.Seconds())))
+		header := metadata.Pairs("retry-after", strconv.Itoa(seconds))
+		return header, leaderboard.ErrorWithStatusCode(errors.New("rate limit exceeded"), http.StatusTooManyRequests)
+	}
+	return nil, nil
+}
+
+func rateLimitUnary(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		header, err := allow(ctx, limiter, info.FullMethod)
+		if header != nil {
+			_ = grpc.SetHeader(ctx, header)
+		}
+		if err != nil {
+			return nil, statusError(err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+func rateLimitStream(limiter *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		header, err := allow(ss.Context(), limiter, info.FullMethod)
+		if header != nil {
+			_ = ss.SetHeader(header)
+		}
+		if err != nil {
+			return statusError(err)
+		}
+		return handler(srv, ss)
+	}
+}
+
+// HTTP API와 같이 keyBy로 요청자를 구분합니다. 예: user:Alice, key:game-server, ip:127.0.0.1
+func requestIdentity(ctx context.Context, keyBy ratelimit.KeyBy) string {
+	if keyBy == ratelimit.KeyByUser {
+		if subject, ok := leaderboard.PlayerFromContext(ctx); ok {
+			return "user:" + subject
+		}
+	}
+	if keyBy == ratelimit.KeyByUser || keyBy == ratelimit.KeyByAPIKey {
+		if key, ok := ctx.Value(keyContextKey{}).(*auth.Key); ok {
+			return "key:" + key.ID
+		}
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "ip:"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return "ip:" + p.Addr.String()
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,73 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/pb"
+	"github.com/JeongMinSik/go-leaderboard/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// key별로 limit번까지 허용하고, 넘으면 1.5초 뒤에 다시 요청하라고 하는 fake
+type FakeTokenStore map[string]int64
+
+func (s FakeTokenStore) TakeToken(_ context.Context, key string, limit int64, _ time.Duration, _ time.Time) (bool, time.Duration, error) {
+	if s[key] >= limit {
+		return false, 1500 * time.Millisecond, nil
+	}
+	s[key]++
+	return true, 0, nil
+}
+
+func TestRateLimit(t *testing.T) {
+	lb := &FakeLeaderBoard{UserSet: sortedset.New()}
+	store := FakeTokenStore{}
+	limiter, err := ratelimit.NewLimiter(map[string]ratelimit.Rule{
+		"POST /users":                {Limit: 1, Window: time.Minute, Key: ratelimit.KeyByAPIKey},
+		"GET /users/:start/to/:stop": {Limit: 1, Window: time.Second, Key: ratelimit.KeyByIP},
+	}, store)
+	require.NoError(t, err)
+	client := newLimitedClient(t, lb, &Auth{Keys: FakeKeyStore{
+		"server": {ID: "server", Scopes: []auth.Scope{auth.ScopeSubmit, auth.ScopeRead}},
+		"other":  {ID: "other", Scopes: []auth.Scope{auth.ScopeSubmit, auth.ScopeRead}},
+	}}, limiter)
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, key)
+	}
+
+	// HTTP API와 같은 route 규칙, key별 bucket
+	_, err = client.AddUser(withKey("server"), &pb.User{Name: "Alice", Score: 10})
+	assert.NoError(t, err)
+	var header metadata.MD
+	_, err = client.AddUser(withKey("server"), &pb.User{Name: "Bob", Score: 20}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"2"}, header.Get("retry-after"))
+	_, err = client.AddUser(withKey("other"), &pb.User{Name: "Bob", Score: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), store["POST /users:key:server"])
+
+	// 규칙이 없는 method는 제한하지 않음
+	for i := 0; i < 3; i++ {
+		_, err = client.UserCount(withKey("server"), &pb.UserCountRequest{})
+		assert.NoError(t, err)
+	}
+
+	// stream
+	stream, err := client.GetUserList(withKey("server"), &pb.GetUserListRequest{Start: 0, Stop: 10})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.NoError(t, err)
+	stream, err = client.GetUserList(withKey("other"), &pb.GetUserListRequest{Start: 0, Stop: 10})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -0,0 +1,264 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/JeongMinSik/go-leaderboard/pkg/pb"
+	"github.com/JeongMinSik/go-leaderboard/pkg/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Server struct {
+	pb.UnimplementedLeaderboardServer
+	Leaderboard leaderboard.Interface
+	// nil이 아니면 API key 없이 보내는 점수 기록의 서명을 확인합니다.
+	Submissions *auth.SubmissionVerifier
+}
+
+// HTTP API와 같은 authn으로 인증하고 limiter로 요청 수를 제한합니다.
+// authn이 nil이거나 Keys, Players가 모두 nil이면 인증하지 않고, limiter가 nil이면 제한하지 않음
+func New(lb leaderboard.Interface, authn *Auth, limiter *ratelimit.Limiter) *grpc.Server {
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+	if authn.enabled() {
+		unary = append(unary, authn.unary())
+		stream = append(stream, authn.stream())
+	}
+	// 인증한 key나 player로 요청자를 구분하도록 인증 뒤에 둠
+	if limiter != nil {
+		unary = append(unary, rateLimitUnary(limiter))
+		stream = append(stream, rateLimitStream(limiter))
+	}
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	server := &Server{Leaderboard: lb}
+	if authn != nil {
+		server.Submissions = authn.Submissions
+	}
+	pb.RegisterLeaderboardServer(s, server)
+	return s
+}
+
+// HTTP API와 같은 status code를 gRPC status code로 바꿔서 반환합니다.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	code := codes.Internal
+	switch leaderboard.StatusCode(err) {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		code = codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotImplemented:
+		code = codes.Unimplemented
+	case http.StatusAccepted:
+		// 검토 대기열에 들어가서 아직 기록하지 않음
+		code = codes.Aborted
+	}
+	return status.Error(code, err.Error())
+}
+
+func toUser(user *pb.User) leaderboard.User {
+	return leaderboard.User{
+		Name:     user.GetName(),
+		Score:    user.GetScore(),
+		Segments: user.GetSegments(),
+	}
+}
+
+func fromUser(user leaderboard.User) *pb.User {
+	return &pb.User{
+		Name:     user.Name,
+		Score:    user.Score,
+		Segments: user.Segments,
+	}
+}
+
+func fromUserRank(userRank leaderboard.UserRank) *pb.UserRank {
+	return &pb.UserRank{
+		User: fromUser(userRank.User),
+		Rank: userRank.Rank,
+	}
+}
+
+func (s *Server) UserCount(ctx context.Context, _ *pb.UserCountRequest) (*pb.UserCountResponse, error) {
+	count, err := s.Leaderboard.UserCount(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.UserCountResponse{Count: count}, nil
+}
+
+func (s *Server) AddUser(ctx context.Context, user *pb.User) (*pb.AddUserResponse, error) {
+	if err := requirePlayer(ctx, user.GetName()); err != nil {
+		return nil, statusError(err)
+	}
+	if err := s.verifySubmission(ctx, toUser(user)); err != nil {
+		return nil, statusError(err)
+	}
+	userRank, err := s.addUser(ctx, toUser(user))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.AddUserResponse{UserRank: fromUserRank(*userRank)}, nil
+}
+
+// HTTP API처럼 기록한 시점의 rank를 함께 받을 수 없으면 기록한 뒤에 조회합니다.
+func (s *Server) addUser(ctx context.Context, user leaderboard.User) (*leaderboard.UserRank, error) {
+	if ranked, ok := s.Leaderboard.(leaderboard.RankedWriteInterface); ok {
+		return ranked.AddUserRank(ctx, user)
+	}
+	if err := s.Leaderboard.AddUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.Leaderboard.GetUser(ctx, user.Name)
+}
+
+func (s *Server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.UserRank, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user name is empty")
+	}
+	userRank, err := s.Leaderboard.GetUser(ctx, req.GetName())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return fromUserRank(*userRank), nil
+}
+
+func (s *Server) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user name is empty")
+	}
+	ok, err := s.Leaderboard.DeleteUser(ctx, req.GetName())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.DeleteUserResponse{Deleted: ok}, nil
+}
+
+func (s *Server) UpdateUser(ctx context.Context, user *pb.User) (*pb.UpdateUserResponse, error) {
+	if err := requirePlayer(ctx, user.GetName()); err != nil {
+		return nil, statusError(err)
+	}
+	if err := s.verifySubmission(ctx, toUser(user)); err != nil {
+		return nil, statusError(err)
+	}
+	userRank, err := s.updateUser(ctx, toUser(user))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.UpdateUserResponse{UserRank: fromUserRank(*userRank)}, nil
+}
+
+func (s *Server) updateUser(ctx context.Context, user leaderboard.User) (*leaderboard.UserRank, error) {
+	if ranked, ok := s.Leaderboard.(leaderboard.RankedWriteInterface); ok {
+		return ranked.UpdateUserRank(ctx, user)
+	}
+	if err := s.Leaderboard.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.Leaderboard.GetUser(ctx, user.Name)
+}
+
+// HTTP API처럼 한 번에 최대 MaxPageSize명까지 보냅니다.
+func (s *Server) GetUserList(req *pb.GetUserListRequest, stream pb.Leaderboard_GetUserListServer) error {
+	ctx := stream.Context()
+	if req.GetStart() < 0 || req.GetStop() < req.GetStart() {
+		return status.Error(codes.InvalidArgument, "invalid index range")
+	}
+	if req.GetStop()-req.GetStart()+1 > leaderboard.MaxPageSize {
+		return status.Error(codes.InvalidArgument, "index range is too large")
+	}
+	userList, err := s.Leaderboard.GetUserList(ctx, req.GetStart(), req.GetStop())
+	if err != nil {
+		return statusError(err)
+	}
+	for _, user := range userList {
+		if err := stream.Send(fromUser(user)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) Subscribe(req *pb.SubscribeRequest, stream pb.Leaderboard_SubscribeServer) error {
+	ctx := stream.Context()
+	subscriber, ok := s.Leaderboard.(leaderboard.SubscribeInterface)
+	if !ok {
+		return status.Error(codes.Unimplemented, "streaming is not supported")
+	}
+	watcher := leaderboard.NewRankWatcher(s.Leaderboard, 0, nil)
+	if err := watcher.SetTop(req.GetTop()); err != nil {
+		return statusError(err)
+	}
+	for _, name := range req.GetWatch() {
+		if err := watcher.Watch(name); err != nil {
+			return statusError(err)
+		}
+	}
+	for _, around := range req.GetAround() {
+		if err := watcher.WatchAround(around.GetName(), around.GetSize()); err != nil {
+			return statusError(err)
+		}
+	}
+
+	// 구독을 먼저 시작해야 첫 상태를 읽는 사이의 변경을 놓치지 않음
+	changes, err := subscriber.Subscribe(ctx)
+	if err != nil {
+		return statusError(err)
+	}
+	for {
+		update, err := watcher.Poll(ctx)
+		if err != nil {
+			return statusError(err)
+		}
+		if update != nil {
+			if err := stream.Send(fromRankUpdate(update)); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-changes:
+			if !ok {
+				return nil
+			}
+		}
+	}
+}
+
+func fromRankUpdate(update *leaderboard.RankUpdate) *pb.RankUpdate {
+	result := &pb.RankUpdate{
+		TopChanged: update.Top != nil,
+	}
+	for _, user := range update.Top {
+		result.Top = append(result.Top, fromUser(user))
+	}
+	for _, userRank := range update.Ranks {
+		result.Ranks = append(result.Ranks, fromUserRank(userRank))
+	}
+	for _, around := range update.Around {
+		aroundRank := &pb.AroundRank{Name: around.Name}
+		for _, userRank := range around.Users {
+			aroundRank.Users = append(aroundRank.Users, fromUserRank(userRank))
+		}
+		result.Around = append(result.Around, aroundRank)
+	}
+	return result
+}
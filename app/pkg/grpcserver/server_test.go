@@ -0,0 +1,249 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/JeongMinSik/go-leaderboard/pkg/pb"
+	"github.com/JeongMinSik/go-leaderboard/pkg/ratelimit"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+type FakeLeaderBoard struct {
+	UserSet *sortedset.SortedSet
+	Changes chan leaderboard.Change
+}
+
+func (lb *FakeLeaderBoard) UserCount(_ context.Context) (int64, error) {
+	return int64(lb.UserSet.GetCount()), nil
+}
+
+func (lb *FakeLeaderBoard) AddUser(_ context.Context, user leaderboard.User) error {
+	if lb.UserSet.GetByKey(user.Name) != nil {
+		return leaderboard.ErrorWithStatusCode(errors.New("already exists name: "+user.Name), http.StatusBadRequest)
+	}
+	lb.UserSet.AddOrUpdate(user.Name, sortedset.SCORE(user.Score), nil)
+	return nil
+}
+
+func (lb *FakeLeaderBoard) GetUser(_ context.Context, name string) (*leaderboard.UserRank, error) {
+	node := lb.UserSet.GetByKey(name)
+	if node == nil {
+		err := leaderboard.ErrorWithStatusCode(errors.New(name), http.StatusNotFound)
+		return nil, errors.Wrap(err, "not exists user")
+	}
+	return &leaderboard.UserRank{
+		User: leaderboard.User{Name: name, Score: float64(node.Score())},
+		Rank: int64(lb.UserSet.GetCount() - lb.UserSet.FindRank(name)),
+	}, nil
+}
+
+func (lb *FakeLeaderBoard) DeleteUser(_ context.Context, name string) (bool, error) {
+	return lb.UserSet.Remove(name) != nil, nil
+}
+
+func (lb *FakeLeaderBoard) UpdateUser(_ context.Context, user leaderboard.User) error {
+	if lb.UserSet.GetByKey(user.Name) == nil {
+		err := leaderboard.ErrorWithStatusCode(errors.New(user.Name), http.StatusNotFound)
+		return errors.Wrap(err, "not exists user")
+	}
+	lb.UserSet.AddOrUpdate(user.Name, sortedset.SCORE(user.Score), nil)
+	return nil
+}
+
+// 높은 점수부터 반환
+func (lb *FakeLeaderBoard) GetUserList(_ context.Context, start int64, stop int64) ([]leaderboard.User, error) {
+	nodes := lb.UserSet.GetByRankRange(int(-start-1), int(-stop-1), false)
+	result := make([]leaderboard.User, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, leaderboard.User{Name: node.Key(), Score: float64(node.Score())})
+	}
+	return result, nil
+}
+
+func (lb *FakeLeaderBoard) Subscribe(_ context.Context) (<-chan leaderboard.Change, error) {
+	return lb.Changes, nil
+}
+
+func newClient(t *testing.T, lb leaderboard.Interface) pb.LeaderboardClient {
+	return newAuthClient(t, lb, nil)
+}
+
+func newAuthClient(t *testing.T, lb leaderboard.Interface, authn *Auth) pb.LeaderboardClient {
+	return newLimitedClient(t, lb, authn, nil)
+}
+
+func newLimitedClient(t *testing.T, lb leaderboard.Interface, authn *Auth, limiter *ratelimit.Limiter) pb.LeaderboardClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := New(lb, authn, limiter)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewLeaderboardClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	lb := &FakeLeaderBoard{UserSet: sortedset.New()}
+	client := newClient(t, lb)
+
+	// AddUser
+	added, err := client.AddUser(ctx, &pb.User{Name: "Minsik", Score: 100})
+	if assert.NoError(t, err) {
+		assert.True(t, proto.Equal(&pb.UserRank{User: &pb.User{Name: "Minsik", Score: 100}, Rank: 0}, added.GetUserRank()))
+	}
+	_, err = client.AddUser(ctx, &pb.User{Name: "Yumi", Score: 500})
+	assert.NoError(t, err)
+	_, err = client.AddUser(ctx, &pb.User{Name: "Minsik", Score: 100})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// UserCount
+	count, err := client.UserCount(ctx, &pb.UserCountRequest{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), count.GetCount())
+	}
+
+	// GetUser
+	userRank, err := client.GetUser(ctx, &pb.GetUserRequest{Name: "Minsik"})
+	if assert.NoError(t, err) {
+		assert.True(t, proto.Equal(&pb.UserRank{User: &pb.User{Name: "Minsik", Score: 100}, Rank: 1}, userRank))
+	}
+	_, err = client.GetUser(ctx, &pb.GetUserRequest{Name: "Foo"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "not exists user: Foo", status.Convert(err).Message())
+	_, err = client.GetUser(ctx, &pb.GetUserRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// UpdateUser
+	updated, err := client.UpdateUser(ctx, &pb.User{Name: "Minsik", Score: 1000})
+	if assert.NoError(t, err) {
+		assert.True(t, proto.Equal(&pb.UserRank{User: &pb.User{Name: "Minsik", Score: 1000}, Rank: 0}, updated.GetUserRank()))
+	}
+	_, err = client.UpdateUser(ctx, &pb.User{Name: "Foo", Score: 1000})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// DeleteUser
+	deleted, err := client.DeleteUser(ctx, &pb.DeleteUserRequest{Name: "Yumi"})
+	if assert.NoError(t, err) {
+		assert.True(t, deleted.GetDeleted())
+	}
+	deleted, err = client.DeleteUser(ctx, &pb.DeleteUserRequest{Name: "Yumi"})
+	if assert.NoError(t, err) {
+		assert.False(t, deleted.GetDeleted())
+	}
+}
+
+func TestGetUserList(t *testing.T) {
+	ctx := context.Background()
+	lb := &FakeLeaderBoard{UserSet: sortedset.New()}
+	for i := 0; i < leaderboard.MaxPageSize+10; i++ {
+		lb.UserSet.AddOrUpdate(string(rune('A'+i)), sortedset.SCORE(i), nil)
+	}
+	client := newClient(t, lb)
+
+	// 한 번에 MaxPageSize명까지 받음
+	stream, err := client.GetUserList(ctx, &pb.GetUserListRequest{Start: 5, Stop: leaderboard.MaxPageSize + 4})
+	require.NoError(t, err)
+	var users []*pb.User
+	for {
+		user, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+	if assert.Len(t, users, leaderboard.MaxPageSize) {
+		assert.Equal(t, float64(leaderboard.MaxPageSize+4), users[0].GetScore())
+		assert.Equal(t, float64(5), users[len(users)-1].GetScore())
+	}
+
+	// 너무 큰 범위
+	stream, err = client.GetUserList(ctx, &pb.GetUserListRequest{Start: 5, Stop: leaderboard.MaxPageSize + 5})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "index range is too large", status.Convert(err).Message())
+
+	// 잘못된 범위
+	stream, err = client.GetUserList(ctx, &pb.GetUserListRequest{Start: 5, Stop: 1})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSubscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lb := &FakeLeaderBoard{
+		UserSet: sortedset.New(),
+		Changes: make(chan leaderboard.Change),
+	}
+	lb.UserSet.AddOrUpdate("Yumi", 500, nil)
+	lb.UserSet.AddOrUpdate("Minsik", 100, nil)
+	client := newClient(t, lb)
+
+	stream, err := client.Subscribe(ctx, &pb.SubscribeRequest{Top: 1, Watch: []string{"Minsik"}})
+	require.NoError(t, err)
+
+	// 현재 상태
+	update, err := stream.Recv()
+	if assert.NoError(t, err) {
+		assert.True(t, proto.Equal(&pb.RankUpdate{
+			TopChanged: true,
+			Top:        []*pb.User{{Name: "Yumi", Score: 500}},
+			Ranks:      []*pb.UserRank{{User: &pb.User{Name: "Minsik", Score: 100}, Rank: 1}},
+		}, update), update)
+	}
+
+	// 첫 상태를 받았다면 server는 변경 알림을 기다리는 중
+	lb.UserSet.AddOrUpdate("Yumi", 50, nil)
+	lb.Changes <- leaderboard.Change{Name: "Yumi", Score: 50}
+	update, err = stream.Recv()
+	if assert.NoError(t, err) {
+		assert.True(t, proto.Equal(&pb.RankUpdate{
+			TopChanged: true,
+			Top:        []*pb.User{{Name: "Minsik", Score: 100}},
+			Ranks:      []*pb.UserRank{{User: &pb.User{Name: "Minsik", Score: 100}, Rank: 0}},
+		}, update), update)
+	}
+
+	// 잘못된 요청
+	stream, err = client.Subscribe(ctx, &pb.SubscribeRequest{Top: 1000})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSubscribeNotSupported(t *testing.T) {
+	type onlyInterface struct{ leaderboard.Interface }
+	client := newClient(t, onlyInterface{&FakeLeaderBoard{UserSet: sortedset.New()}})
+
+	stream, err := client.Subscribe(context.Background(), &pb.SubscribeRequest{Top: 1})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
@@ -0,0 +1,34 @@
+package grpcserver
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+)
+
+// HTTP API의 body 대신 metadata로 받는 서명 값 (auth.Submission)
+const (
+	submissionGameMetadata      = "x-submission-game"
+	submissionNonceMetadata     = "x-submission-nonce"
+	submissionTimestampMetadata = "x-submission-timestamp"
+	submissionSignatureMetadata = "x-submission-signature"
+)
+
+// HTTP API의 RequireSignature와 같이 API key로 인증한 요청(서버)은 확인하지 않습니다.
+func (s *Server) verifySubmission(ctx context.Context, user leaderboard.User) error {
+	if s.Submissions == nil || ctx.Value(keyContextKey{}) != nil {
+		return nil
+	}
+	// 형식이 잘못된 timestamp는 0으로 두어 서명이 없는 요청으로 처리
+	timestamp, _ := strconv.ParseInt(metadataValue(ctx, submissionTimestampMetadata), 10, 64)
+	submission := auth.Submission{
+		Game:      metadataValue(ctx, submissionGameMetadata),
+		Nonce:     metadataValue(ctx, submissionNonceMetadata),
+		Timestamp: timestamp,
+		Signature: metadataValue(ctx, submissionSignatureMetadata),
+	}
+	// gRPC API는 전체 board만 다룸
+	return s.Submissions.Verify(ctx, "users", user, submission)
+}
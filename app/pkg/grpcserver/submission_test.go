@@ -0,0 +1,68 @@
+package grpcserver
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type FakeNonceStore map[string]bool
+
+func (s FakeNonceStore) UseNonce(_ context.Context, game string, nonce string, _ time.Duration) (bool, error) {
+	if s[game+":"+nonce] {
+		return false, nil
+	}
+	s[game+":"+nonce] = true
+	return true, nil
+}
+
+func TestSubmission(t *testing.T) {
+	lb := &FakeLeaderBoard{UserSet: sortedset.New()}
+	submissions, err := auth.NewSubmissionVerifier(map[string]string{"game-1": "secret"}, FakeNonceStore{}, time.Minute)
+	require.NoError(t, err)
+	client := newAuthClient(t, lb, &Auth{Keys: FakeKeyStore{
+		"server": {ID: "server", Scopes: []auth.Scope{auth.ScopeSubmit}},
+	}, Submissions: submissions})
+
+	timestamp := time.Now().Unix()
+	signed := func(name string, score float64, nonce string) context.Context {
+		signature := auth.SignSubmission("secret", "users", name, score, nil, timestamp, nonce)
+		return metadata.AppendToOutgoingContext(context.Background(),
+			submissionGameMetadata, "game-1",
+			submissionNonceMetadata, nonce,
+			submissionTimestampMetadata, strconv.FormatInt(timestamp, 10),
+			submissionSignatureMetadata, signature,
+		)
+	}
+
+	// Submissions만 설정하면 인증하지 않으므로 서명으로 확인
+	signedOnly := newAuthClient(t, lb, &Auth{Submissions: submissions})
+	_, err = signedOnly.AddUser(context.Background(), &pb.User{Name: "Alice", Score: 10})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = signedOnly.AddUser(signed("Alice", 10, "n1"), &pb.User{Name: "Alice", Score: 10})
+	assert.NoError(t, err)
+	_, err = signedOnly.UpdateUser(signed("Alice", 20, "n1"), &pb.User{Name: "Alice", Score: 20})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = signedOnly.UpdateUser(signed("Alice", 20, "n2"), &pb.User{Name: "Alice", Score: 99999})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = signedOnly.UpdateUser(signed("Alice", 20, "n3"), &pb.User{Name: "Alice", Score: 20, Segments: map[string]string{"country": "KR"}})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = signedOnly.UpdateUser(signed("Alice", 20, "n4"), &pb.User{Name: "Alice", Score: 20})
+	assert.NoError(t, err)
+
+	// API key로 인증한 서버는 서명하지 않아도 됨
+	_, err = client.AddUser(metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, "server"), &pb.User{Name: "Bob", Score: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lb.UserSet.GetCount())
+	assert.Equal(t, float64(20), float64(lb.UserSet.GetByKey("Alice").Score()))
+}
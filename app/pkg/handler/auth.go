@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+	// 브라우저의 EventSource, WebSocket은 header를 보낼 수 없으므로 query도 허용
+	apiKeyQuery = "api_key"
+	// 브라우저용 player token query. header는 "Authorization: Bearer <token>"
+	tokenQuery = "access_token"
+	// 인증된 auth.Key를 echo context에 저장하는 이름
+	authKeyContext = "auth.key"
+	// 인증된 player token의 subject(user name)를 echo context에 저장하는 이름
+	authPlayerContext = "auth.player"
+)
+
+// Keys와 Players가 모두 nil이면 인증하지 않습니다. Submissions는 RequireSignature에서 사용
+type Auth struct {
+	Keys        auth.KeyStore
+	Players     *auth.TokenVerifier
+	Submissions *auth.SubmissionVerifier
+}
+
+// 요청한 board 이름. group API는 "groups", segment param이 있으면 "segment:<attr>:<value>", 나머지는 "users"
+func requestBoard(c echo.Context) string {
+	if strings.HasPrefix(c.Path(), "/groups") || c.Path() == "/users/group" {
+		return "groups"
+	}
+	if segment := c.QueryParam("segment"); segment != "" {
+		return "segment:" + segment
+	}
+	return "users"
+}
+
+func bearerToken(c echo.Context) string {
+	if header := c.Request().Header.Get(echo.HeaderAuthorization); len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return header[7:]
+	}
+	return c.QueryParam(tokenQuery)
+}
+
+// API key가 scope를 가지고 있고 요청한 board에 접근할 수 있는지 확인합니다.
+// API key 대신 player token을 보내면 admin이 아닌 scope만 허용합니다.
+func (a *Auth) Require(scope auth.Scope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if a == nil || (a.Keys == nil && a.Players == nil) {
+				return next(c)
+			}
+			if token := bearerToken(c); token != "" && a.Players != nil {
+				subject, err := a.Players.Subject(token)
+				if err != nil {
+					return errorJSON(c, err)
+				}
+				if scope == auth.ScopeAdmin {
+					return errorJSON(c, leaderboard.ErrorWithStatusCode(errors.New("player token has no scope: "+string(scope)), http.StatusForbidden))
+				}
+				c.Set(authPlayerContext, subject)
+				return next(c)
+			}
+			if a.Keys == nil {
+				return errorJSON(c, leaderboard.ErrorWithStatusCode(errors.New("token is required"), http.StatusUnauthorized))
+			}
+			value := c.Request().Header.Get(APIKeyHeader)
+			if value == "" {
+				value = c.QueryParam(apiKeyQuery)
+			}
+			if value == "" {
+				return errorJSON(c, leaderboard.ErrorWithStatusCode(errors.New("api key is required"), http.StatusUnauthorized))
+			}
+			key, err := a.Keys.Lookup(c.Request().Context(), value)
+			if err != nil {
+				return errorJSON(c, err)
+			}
+			if key == nil {
+				return errorJSON(c, leaderboard.ErrorWithStatusCode(errors.New("invalid api key"), http.StatusUnauthorized))
+			}
+			if !key.Allows(scope) {
+				return errorJSON(c, leaderboard.ErrorWithStatusCode(errors.New("api key has no scope: "+string(scope)), http.StatusForbidden))
+			}
+			if board := requestBoard(c); !key.AllowsBoard(board) {
+				return errorJSON(c, leaderboard.ErrorWithStatusCode(errors.New("api key is not allowed for board: "+board), http.StatusForbidden))
+			}
+			c.Set(authKeyContext, key)
+			return next(c)
+		}
+	}
+}
+
+// player token으로 인증한 요청은 token의 subject와 같은 user만 수정할 수 있습니다. API key는 모든 user를 수정할 수 있음
+func requirePlayer(c echo.Context, userName string) error {
+	subject, ok := c.Get(authPlayerContext).(string)
+	if ok && subject != userName {
+		return leaderboard.ErrorWithStatusCode(errors.New("token subject does not match user: "+userName), http.StatusForbidden)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+type FakeKeyStore map[string]auth.Key
+
+func (s FakeKeyStore) Lookup(_ context.Context, key string) (*auth.Key, error) {
+	found, ok := s[key]
+	if !ok {
+		return nil, nil
+	}
+	return &found, nil
+}
+
+func TestAuthRequire(t *testing.T) {
+	// Setup
+	e := echo.New()
+	authn := &Auth{Keys: FakeKeyStore{
+		"reader": {ID: "reader", Scopes: []auth.Scope{auth.ScopeRead}},
+		"kr":     {ID: "kr", Scopes: []auth.Scope{auth.ScopeRead}, Boards: []string{"segment:country:KR"}},
+		"ops":    {ID: "ops", Scopes: []auth.Scope{auth.ScopeAdmin}},
+	}}
+	ok := func(c echo.Context) error {
+		key := c.Get(authKeyContext).(*auth.Key)
+		return c.String(http.StatusOK, key.ID)
+	}
+	e.GET("/users", ok, authn.Require(auth.ScopeRead))
+	e.DELETE("/users", ok, authn.Require(auth.ScopeAdmin))
+	e.GET("/groups/:group", ok, authn.Require(auth.ScopeRead))
+
+	testCases := []struct {
+		method string
+		target string
+		key    string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/users", "", http.StatusUnauthorized, `{"message": "api key is required"}`},
+		{http.MethodGet, "/users", "wrong", http.StatusUnauthorized, `{"message": "invalid api key"}`},
+		{http.MethodGet, "/users", "reader", http.StatusOK, "reader"},
+		{http.MethodGet, "/users?api_key=reader", "", http.StatusOK, "reader"},
+		{http.MethodDelete, "/users", "reader", http.StatusForbidden, `{"message": "api key has no scope: admin"}`},
+		{http.MethodDelete, "/users", "ops", http.StatusOK, "ops"},
+		{http.MethodGet, "/groups/Gophers", "ops", http.StatusOK, "ops"},
+		{http.MethodGet, "/users?segment=country:KR", "kr", http.StatusOK, "kr"},
+		{http.MethodGet, "/users", "kr", http.StatusForbidden, `{"message": "api key is not allowed for board: users"}`},
+		{http.MethodGet, "/groups/Gophers", "kr", http.StatusForbidden, `{"message": "api key is not allowed for board: groups"}`},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		if tc.key != "" {
+			req.Header.Set(APIKeyHeader, tc.key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.target)
+		if tc.code == http.StatusOK {
+			assert.Equal(t, tc.body, rec.Body.String())
+		} else {
+			require.JSONEq(t, tc.body, rec.Body.String())
+		}
+	}
+}
+
+func TestAuthDisabled(t *testing.T) {
+	e := echo.New()
+	authn := &Auth{}
+	e.GET("/users", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, authn.Require(auth.ScopeAdmin))
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAuthPlayer(t *testing.T) {
+	// Setup
+	e := echo.New()
+	players, err := auth.NewTokenVerifier("secret", "")
+	require.NoError(t, err)
+	authn := &Auth{Keys: FakeKeyStore{
+		"game-server": {ID: "game-server", Scopes: []auth.Scope{auth.ScopeSubmit}},
+	}, Players: players}
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+	e.POST("/users", h.AddUser, authn.Require(auth.ScopeSubmit))
+	e.PATCH("/users", h.UpdateUser, authn.Require(auth.ScopeSubmit))
+	e.DELETE("/users", h.DeleteUser, authn.Require(auth.ScopeAdmin))
+
+	sign := func(subject string) string {
+		claims := jwt.StandardClaims{Subject: subject, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		require.NoError(t, err)
+		return token
+	}
+
+	testCases := []struct {
+		method string
+		target string
+		token  string
+		key    string
+		body   string
+		code   int
+		result string
+	}{
+		{http.MethodPost, "/users", sign("Alice"), "", `{"name": "Alice", "score": 10}`, http.StatusCreated, `{"name": "Alice", "score": 10, "rank": 0}`},
+		{http.MethodPost, "/users", sign("Alice"), "", `{"name": "Bob", "score": 20}`, http.StatusForbidden, `{"message": "token subject does not match user: Bob"}`},
+		{http.MethodPatch, "/users", sign("Bob"), "", `{"name": "Alice", "score": 30}`, http.StatusForbidden, `{"message": "token subject does not match user: Alice"}`},
+		{http.MethodPatch, "/users?access_token=" + sign("Alice"), "", "", `{"name": "Alice", "score": 30}`, http.StatusOK, `{"name": "Alice", "score": 30, "rank": 0}`},
+		{http.MethodPost, "/users", "invalid", "", `{"name": "Bob", "score": 20}`, http.StatusUnauthorized, ""},
+		{http.MethodDelete, "/users?name=Alice", sign("Alice"), "", "", http.StatusForbidden, `{"message": "player token has no scope: admin"}`},
+		// server key는 모든 user를 수정할 수 있음
+		{http.MethodPost, "/users", "", "game-server", `{"name": "Bob", "score": 40}`, http.StatusCreated, `{"name": "Bob", "score": 40, "rank": 0}`},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if tc.token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+tc.token)
+		}
+		if tc.key != "" {
+			req.Header.Set(APIKeyHeader, tc.key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.body)
+		if tc.result != "" {
+			require.JSONEq(t, tc.result, rec.Body.String())
+		}
+	}
+}
+
+func TestAuthPlayerOnly(t *testing.T) {
+	e := echo.New()
+	players, err := auth.NewTokenVerifier("secret", "")
+	require.NoError(t, err)
+	authn := &Auth{Players: players}
+	e.GET("/users", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, authn.Require(auth.ScopeRead))
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(APIKeyHeader, "game-server")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.JSONEq(t, `{"message": "token is required"}`, rec.Body.String())
+}
+
+// player token으로 인증하면 LeaderBoard에 넘기는 context에 subject가 있음
+func TestRequestContextPlayer(t *testing.T) {
+	e := echo.New()
+	players, err := auth.NewTokenVerifier("secret", "")
+	require.NoError(t, err)
+	authn := &Auth{Keys: FakeKeyStore{
+		"reader": {ID: "reader", Scopes: []auth.Scope{auth.ScopeRead}},
+	}, Players: players}
+	e.GET("/users", func(c echo.Context) error {
+		player, _ := leaderboard.PlayerFromContext(requestContext(c))
+		return c.String(http.StatusOK, player)
+	}, authn.Require(auth.ScopeRead))
+
+	claims := jwt.StandardClaims{Subject: "Alice", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "Alice", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(APIKeyHeader, "reader")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "", rec.Body.String())
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+type banData struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	Shadow bool   `json:"shadow"`
+}
+
+type unbanData struct {
+	Name       string `json:"name"`
+	IsUnbanned bool   `json:"is_unbanned"`
+}
+
+func (h *Handler) bans() (leaderboard.BanInterface, error) {
+	bans, ok := h.Leaderboard.(leaderboard.BanInterface)
+	if !ok {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("bans are not supported"), http.StatusNotImplemented)
+	}
+	return bans, nil
+}
+
+// @Summary     Get banned users
+// @Tags        Admin
+// @Produce     json
+// @Success     200 {array}  leaderboard.Ban
+// @Failure     500 {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /admin/bans [get]
+func (h *Handler) GetBans(c echo.Context) error {
+	ctx := requestContext(c)
+	bans, err := h.bans()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	banList, err := bans.Bans(ctx)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, banList)
+}
+
+// @Summary     Ban a user
+// @Description user를 순위, 목록, user 수에서 제외합니다. 점수는 지우지 않습니다.
+// @Description shadow가 true면 계속 점수를 기록할 수 있고 자기 player token으로 name을 조회하면 자기 순위를 볼 수 있습니다 (다른 요청에는 404). false면 점수 기록도 막습니다.
+// @Tags        Admin
+// @accept      json
+// @Produce     json
+// @Param       ban body     banData true "Ban"
+// @Success     201 {object} leaderboard.Ban
+// @Failure     400 {object} messageData "request body 확인 필요"
+// @Failure     500 {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /admin/bans [post]
+func (h *Handler) BanUser(c echo.Context) error {
+	ctx := requestContext(c)
+	bans, err := h.bans()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	data := banData{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&data); err != nil {
+		return messageJSON(c, http.StatusBadRequest, "invalid body: ban info")
+	}
+	ban, err := bans.BanUser(ctx, data.Name, data.Reason, data.Shadow)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusCreated, ban)
+}
+
+// @Summary     Unban a user
+// @Description 차단을 풀고 user를 순위에 되돌립니다.
+// @Tags        Admin
+// @Produce     json
+// @Param       name query    string true "User name"
+// @Success     200  {object} unbanData
+// @Failure     400  {object} messageData "name 확인 필요"
+// @Failure     500  {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /admin/bans [delete]
+func (h *Handler) UnbanUser(c echo.Context) error {
+	ctx := requestContext(c)
+	bans, err := h.bans()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	userName := c.QueryParam("name")
+	if userName == "" {
+		return messageJSON(c, http.StatusBadRequest, "user name is empty")
+	}
+	ok, err := bans.UnbanUser(ctx, userName)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, unbanData{userName, ok})
+}
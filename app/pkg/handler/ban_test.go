@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+// 차단된 user를 UserSet에서 banned로 옮기는 fake
+type FakeBanLeaderBoard struct {
+	FakeLeaderBoard
+	bans   map[string]leaderboard.Ban
+	banned map[string]float64
+}
+
+func (lb *FakeBanLeaderBoard) Bans(_ context.Context) ([]leaderboard.Ban, error) {
+	result := []leaderboard.Ban{}
+	for _, ban := range lb.bans {
+		result = append(result, ban)
+	}
+	return result, nil
+}
+
+func (lb *FakeBanLeaderBoard) BanUser(_ context.Context, name string, reason string, shadow bool) (*leaderboard.Ban, error) {
+	if name == "" {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("user name is empty"), http.StatusBadRequest)
+	}
+	ban := leaderboard.Ban{Name: name, Reason: reason, Shadow: shadow, CreatedAt: time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)}
+	lb.bans[name] = ban
+	if node := lb.UserSet.Remove(name); node != nil {
+		lb.banned[name] = float64(node.Score())
+	}
+	return &ban, nil
+}
+
+func (lb *FakeBanLeaderBoard) UnbanUser(_ context.Context, name string) (bool, error) {
+	if _, ok := lb.bans[name]; !ok {
+		return false, nil
+	}
+	delete(lb.bans, name)
+	if score, ok := lb.banned[name]; ok {
+		lb.UserSet.AddOrUpdate(name, sortedset.SCORE(score), nil)
+		delete(lb.banned, name)
+	}
+	return true, nil
+}
+
+func TestBans(t *testing.T) {
+	// Setup
+	e := echo.New()
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	sortedSet.AddOrUpdate("Cheater", 999, nil)
+	lb := &FakeBanLeaderBoard{
+		FakeLeaderBoard: FakeLeaderBoard{UserSet: *sortedSet},
+		bans:            map[string]leaderboard.Ban{},
+		banned:          map[string]float64{},
+	}
+	h := &Handler{lb}
+
+	// BanUser
+	req := httptest.NewRequest(http.MethodPost, "/admin/bans", strings.NewReader(`{"name": "Cheater", "reason": "speed hack", "shadow": true}`))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.BanUser(c)) {
+		const banJSON = `{"name": "Cheater", "reason": "speed hack", "shadow": true, "created_at": "2022-07-01T12:00:00Z"}`
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		require.JSONEq(t, banJSON, rec.Body.String())
+		assert.Equal(t, 1, lb.UserSet.GetCount())
+	}
+
+	// BanUser - 잘못된 body
+	req = httptest.NewRequest(http.MethodPost, "/admin/bans", strings.NewReader(`{"name": `))
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, h.BanUser(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.JSONEq(t, `{"message": "invalid body: ban info"}`, rec.Body.String())
+	}
+
+	// GetBans
+	req = httptest.NewRequest(http.MethodGet, "/admin/bans", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, h.GetBans(c)) {
+		const bansJSON = `[{"name": "Cheater", "reason": "speed hack", "shadow": true, "created_at": "2022-07-01T12:00:00Z"}]`
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, bansJSON, rec.Body.String())
+	}
+
+	// UnbanUser
+	req = httptest.NewRequest(http.MethodDelete, "/admin/bans?name=Cheater", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, h.UnbanUser(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"name": "Cheater", "is_unbanned": true}`, rec.Body.String())
+		assert.Equal(t, 2, lb.UserSet.GetCount())
+	}
+
+	// UnbanUser - name 없음
+	req = httptest.NewRequest(http.MethodDelete, "/admin/bans", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, h.UnbanUser(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.JSONEq(t, `{"message": "user name is empty"}`, rec.Body.String())
+	}
+}
+
+func TestBansNotSupported(t *testing.T) {
+	e := echo.New()
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/bans", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.GetBans(c)) {
+		const errorJSON = `{"message": "bans are not supported"}`
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+		require.JSONEq(t, errorJSON, rec.Body.String())
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	ETagHeader    = "ETag"
+	IfMatchHeader = "If-Match"
+)
+
+func (h *Handler) conditional() (leaderboard.ConditionalInterface, error) {
+	conditional, ok := h.Leaderboard.(leaderboard.ConditionalInterface)
+	if !ok {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("conditional requests are not supported"), http.StatusNotImplemented)
+	}
+	return conditional, nil
+}
+
+// If-Match header의 ETag 목록. header가 없으면 nil
+// weak ETag(W/...)는 strong 비교에서 맞지 않으므로 제외합니다.
+func ifMatchETags(c echo.Context) []string {
+	header := c.Request().Header.Get(IfMatchHeader)
+	if header == "" {
+		return nil
+	}
+	etags := []string{}
+	for _, etag := range strings.Split(header, ",") {
+		if etag = strings.TrimSpace(etag); etag != "" && !strings.HasPrefix(etag, "W/") {
+			etags = append(etags, etag)
+		}
+	}
+	return etags
+}
+
+// If-Match header가 있으면 ETag가 맞을 때만 삭제합니다.
+func (h *Handler) deleteUser(ctx context.Context, c echo.Context, name string) (bool, error) {
+	etags := ifMatchETags(c)
+	if etags == nil {
+		return h.Leaderboard.DeleteUser(ctx, name)
+	}
+	conditional, err := h.conditional()
+	if err != nil {
+		return false, err
+	}
+	return conditional.DeleteUserIfMatch(ctx, name, etags)
+}
+
+func setETag(c echo.Context, user *leaderboard.UserRank) {
+	c.Response().Header().Set(ETagHeader, leaderboard.UserETag(user.User))
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+// 현재 score의 ETag가 맞을 때만 수정/삭제하는 fake
+type FakeConditionalLeaderBoard struct {
+	FakeLeaderBoard
+}
+
+func (lb *FakeConditionalLeaderBoard) match(ctx context.Context, name string, etags []string) error {
+	current, err := lb.GetUser(ctx, name)
+	if err == nil {
+		etag := leaderboard.UserETag(current.User)
+		for _, e := range etags {
+			if e == "*" || e == etag {
+				return nil
+			}
+		}
+	}
+	return leaderboard.ErrorWithStatusCode(errors.New("etag does not match: "+name), http.StatusPreconditionFailed)
+}
+
+func (lb *FakeConditionalLeaderBoard) UpdateUserIfMatch(ctx context.Context, user leaderboard.User, etags []string) (*leaderboard.UserRank, error) {
+	if err := lb.match(ctx, user.Name, etags); err != nil {
+		return nil, err
+	}
+	if err := lb.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return lb.GetUser(ctx, user.Name)
+}
+
+func (lb *FakeConditionalLeaderBoard) DeleteUserIfMatch(ctx context.Context, name string, etags []string) (bool, error) {
+	if err := lb.match(ctx, name, etags); err != nil {
+		return false, err
+	}
+	return lb.DeleteUser(ctx, name)
+}
+
+func TestETag(t *testing.T) {
+	// Setup
+	e := echo.New()
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	h := &Handler{&FakeConditionalLeaderBoard{
+		FakeLeaderBoard: FakeLeaderBoard{UserSet: *sortedSet},
+	}}
+	e.GET("/users", h.GetUser)
+	e.PATCH("/users", h.UpdateUser)
+	e.DELETE("/users", h.DeleteUser)
+
+	request := func(method string, target string, ifMatch string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if ifMatch != "" {
+			req.Header.Set(IfMatchHeader, ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// GET으로 받은 ETag로 수정
+	rec := request(http.MethodGet, "/users?name=Minsik", "", "")
+	etag := rec.Header().Get(ETagHeader)
+	assert.Equal(t, leaderboard.UserETag(leaderboard.User{Name: "Minsik", Score: 100}), etag)
+
+	rec = request(http.MethodPatch, "/users", etag, `{"name": "Minsik", "score": 200}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	newETag := rec.Header().Get(ETagHeader)
+	assert.NotEqual(t, etag, newETag)
+
+	// 이미 바뀐 user를 이전 ETag로 수정, 삭제
+	rec = request(http.MethodPatch, "/users", etag, `{"name": "Minsik", "score": 300}`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	require.JSONEq(t, `{"message": "etag does not match: Minsik"}`, rec.Body.String())
+	rec = request(http.MethodDelete, "/users?name=Minsik", etag, "")
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	// weak ETag는 맞지 않음
+	rec = request(http.MethodDelete, "/users?name=Minsik", "W/"+newETag, "")
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = request(http.MethodDelete, "/users?name=Minsik", `"other", `+newETag, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"name": "Minsik", "is_deleted": true}`, rec.Body.String())
+}
+
+func TestETagNotSupported(t *testing.T) {
+	e := echo.New()
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedSet,
+	}}
+
+	req := httptest.NewRequest(http.MethodDelete, "/users?name=Minsik", nil)
+	req.Header.Set(IfMatchHeader, "*")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.DeleteUser(c)) {
+		const errorJSON = `{"message": "conditional requests are not supported"}`
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+		require.JSONEq(t, errorJSON, rec.Body.String())
+	}
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+type memberData struct {
+	Name string `json:"name"`
+}
+
+type leaveData struct {
+	Group  string `json:"group"`
+	Name   string `json:"name"`
+	IsLeft bool   `json:"is_left"`
+}
+
+func (h *Handler) groups() (leaderboard.GroupInterface, error) {
+	groups, ok := h.Leaderboard.(leaderboard.GroupInterface)
+	if !ok {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("groups are not supported"), http.StatusNotImplemented)
+	}
+	return groups, nil
+}
+
+// @Summary     Join a group
+// @Description user를 그룹(클랜)에 가입시킵니다. 다른 그룹에 속해 있었다면 이동합니다.
+// @Tags        Groups
+// @accept      json
+// @Produce     json
+// @Param       group path     string     true "Group name"
+// @Param       user  body     memberData true "Member"
+// @Success     201   {object} leaderboard.GroupRank
+// @Failure     400   {object} messageData "request body 확인 필요"
+// @Failure     403   {object} messageData "다른 user의 player token"
+// @Failure     500   {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Security    BearerAuth
+// @Router      /groups/{group}/members [post]
+func (h *Handler) JoinGroup(c echo.Context) error {
+	ctx := requestContext(c)
+	groups, err := h.groups()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	group := c.Param("group")
+	member := memberData{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&member); err != nil || member.Name == "" {
+		return messageJSON(c, http.StatusBadRequest, "invalid body: member info")
+	}
+	if err := requirePlayer(c, member.Name); err != nil {
+		return errorJSON(c, err)
+	}
+	if err := groups.JoinGroup(ctx, group, member.Name); err != nil {
+		return errorJSON(c, err)
+	}
+	groupRank, err := groups.GetGroup(ctx, group)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusCreated, groupRank)
+}
+
+// @Summary     Leave a group
+// @Description user를 그룹(클랜)에서 탈퇴시킵니다.
+// @Tags        Groups
+// @Produce     json
+// @Param       group path     string true "Group name"
+// @Param       name  query    string true "User name"
+// @Success     200   {object} leaveData
+// @Failure     400   {object} messageData "name 확인 필요"
+// @Failure     403   {object} messageData "다른 user의 player token"
+// @Failure     500   {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Security    BearerAuth
+// @Router      /groups/{group}/members [delete]
+func (h *Handler) LeaveGroup(c echo.Context) error {
+	ctx := requestContext(c)
+	groups, err := h.groups()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	group := c.Param("group")
+	userName := c.QueryParam("name")
+	if userName == "" {
+		return messageJSON(c, http.StatusBadRequest, "user name is empty")
+	}
+	if err := requirePlayer(c, userName); err != nil {
+		return errorJSON(c, err)
+	}
+	ok, err := groups.LeaveGroup(ctx, group, userName)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, leaveData{
+		Group:  group,
+		Name:   userName,
+		IsLeft: ok,
+	})
+}
+
+// @Summary     Show a group info
+// @Description 그룹(클랜)의 score, rank, 멤버 수를 얻습니다.
+// @Tags        Groups
+// @Produce     json
+// @Param       group path     string true "Group name"
+// @Success     200   {object} leaderboard.GroupRank
+// @Failure     404   {object} messageData "없는 그룹"
+// @Failure     500   {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /groups/{group} [get]
+func (h *Handler) GetGroup(c echo.Context) error {
+	ctx := requestContext(c)
+	groups, err := h.groups()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	groupRank, err := groups.GetGroup(ctx, c.Param("group"))
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, groupRank)
+}
+
+// @Summary     Get group members
+// @Description 그룹(클랜)의 멤버 목록과 각 멤버의 score를 얻습니다.
+// @Tags        Groups
+// @Produce     json
+// @Param       group path     string true "Group name"
+// @Success     200   {array}  leaderboard.User
+// @Failure     500   {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /groups/{group}/members [get]
+func (h *Handler) GetGroupMembers(c echo.Context) error {
+	ctx := requestContext(c)
+	groups, err := h.groups()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	members, err := groups.GetGroupMembers(ctx, c.Param("group"))
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, members)
+}
+
+// @Summary     Get group list
+// @Description 그룹(클랜) 순위 list 를 받아옵니다.
+// @Tags        Groups
+// @Produce     json
+// @Param       start path     int true "start index"
+// @Param       stop  path     int true "stop index"
+// @Success     200   {array}  leaderboard.Group
+// @Failure     400   {object} messageData "param 확인 필요"
+// @Failure     500   {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /groups/{start}/to/{stop} [get]
+func (h *Handler) GetGroupList(c echo.Context) error {
+	ctx := requestContext(c)
+	groups, err := h.groups()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	start, err := strconv.ParseInt(c.Param("start"), 0, 64)
+	if err != nil {
+		return messageJSON(c, http.StatusBadRequest, "invalid start index")
+	}
+	stop, err := strconv.ParseInt(c.Param("stop"), 0, 64)
+	if err != nil {
+		return messageJSON(c, http.StatusBadRequest, "invalid stop index")
+	}
+	if start < 0 || stop < start {
+		return messageJSON(c, http.StatusBadRequest, "invalid index range")
+	}
+	if stop-start+1 > leaderboard.MaxPageSize {
+		return messageJSON(c, http.StatusBadRequest, "index range is too large")
+	}
+
+	groupList, err := groups.GetGroupList(ctx, start, stop)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, groupList)
+}
+
+// @Summary     Show a user's group info
+// @Description user가 속한 그룹(클랜)의 score, rank를 얻습니다.
+// @Tags        Users
+// @Produce     json
+// @Param       name query    string true "User name"
+// @Success     200  {object} leaderboard.GroupRank
+// @Failure     400  {object} messageData "name query param 확인 필요"
+// @Failure     404  {object} messageData "그룹 없음"
+// @Failure     500  {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /users/group [get]
+func (h *Handler) GetUserGroup(c echo.Context) error {
+	ctx := requestContext(c)
+	groups, err := h.groups()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	userName := c.QueryParam("name")
+	if userName == "" {
+		return messageJSON(c, http.StatusBadRequest, "user name is empty")
+	}
+	groupRank, err := groups.GetUserGroup(ctx, userName)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, groupRank)
+}
@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+type FakeGroupLeaderBoard struct {
+	FakeLeaderBoard
+	UserGroups map[string]string
+}
+
+func (lb *FakeGroupLeaderBoard) JoinGroup(_ context.Context, group string, name string) error {
+	lb.UserGroups[name] = group
+	return nil
+}
+
+func (lb *FakeGroupLeaderBoard) LeaveGroup(_ context.Context, group string, name string) (bool, error) {
+	if lb.UserGroups[name] != group {
+		return false, nil
+	}
+	delete(lb.UserGroups, name)
+	return true, nil
+}
+
+func (lb *FakeGroupLeaderBoard) groupSet() *sortedset.SortedSet {
+	groupSet := sortedset.New()
+	scores := map[string]float64{}
+	for name, group := range lb.UserGroups {
+		score := 0.0
+		if node := lb.UserSet.GetByKey(name); node != nil {
+			score = float64(node.Score())
+		}
+		scores[group] += score
+	}
+	for group, score := range scores {
+		groupSet.AddOrUpdate(group, sortedset.SCORE(score), nil)
+	}
+	return groupSet
+}
+
+func (lb *FakeGroupLeaderBoard) GetGroup(ctx context.Context, group string) (*leaderboard.GroupRank, error) {
+	groupSet := lb.groupSet()
+	node := groupSet.GetByKey(group)
+	if node == nil {
+		err := leaderboard.ErrorWithStatusCode(errors.New(group), http.StatusNotFound)
+		return nil, errors.Wrap(err, "not exists group")
+	}
+	members, _ := lb.GetGroupMembers(ctx, group)
+	return &leaderboard.GroupRank{
+		Group: leaderboard.Group{
+			Name:  group,
+			Score: float64(node.Score()),
+		},
+		Rank:        int64(groupSet.FindRank(group) - 1),
+		MemberCount: int64(len(members)),
+	}, nil
+}
+
+func (lb *FakeGroupLeaderBoard) GetGroupList(_ context.Context, start int64, stop int64) ([]leaderboard.Group, error) {
+	result := []leaderboard.Group{}
+	for _, node := range lb.groupSet().GetByRankRange(int(start+1), int(stop+1), false) {
+		result = append(result, leaderboard.Group{
+			Name:  node.Key(),
+			Score: float64(node.Score()),
+		})
+	}
+	return result, nil
+}
+
+func (lb *FakeGroupLeaderBoard) GetGroupMembers(_ context.Context, group string) ([]leaderboard.User, error) {
+	result := []leaderboard.User{}
+	for name, userGroup := range lb.UserGroups {
+		if userGroup != group {
+			continue
+		}
+		user := leaderboard.User{Name: name}
+		if node := lb.UserSet.GetByKey(name); node != nil {
+			user.Score = float64(node.Score())
+		}
+		result = append(result, user)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Score < result[j].Score
+	})
+	return result, nil
+}
+
+func (lb *FakeGroupLeaderBoard) GetUserGroup(ctx context.Context, name string) (*leaderboard.GroupRank, error) {
+	group, ok := lb.UserGroups[name]
+	if !ok {
+		err := leaderboard.ErrorWithStatusCode(errors.New(name), http.StatusNotFound)
+		return nil, errors.Wrap(err, "not in any group")
+	}
+	return lb.GetGroup(ctx, group)
+}
+
+func newFakeGroupLeaderBoard() *FakeGroupLeaderBoard {
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Yumi", 500, nil)
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	sortedSet.AddOrUpdate("Foo", 200, nil)
+	return &FakeGroupLeaderBoard{
+		FakeLeaderBoard: FakeLeaderBoard{
+			UserSet: *sortedSet,
+		},
+		UserGroups: map[string]string{
+			"Yumi": "Rustaceans",
+		},
+	}
+}
+
+func TestGroupsNotSupported(t *testing.T) {
+	e := echo.New()
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+
+	req := httptest.NewRequest(http.MethodGet, "/groups/:group", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("group")
+	c.SetParamValues("Gophers")
+	if assert.NoError(t, h.GetGroup(c)) {
+		const errorJSON = `{"message": "groups are not supported"}`
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+		require.JSONEq(t, errorJSON, rec.Body.String())
+	}
+}
+
+func TestJoinGroup(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &Handler{newFakeGroupLeaderBoard()}
+
+	// JoinGroup
+	req := httptest.NewRequest(http.MethodPost, "/groups/:group/members", strings.NewReader(`{"name": "Minsik"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("group")
+	c.SetParamValues("Gophers")
+	if assert.NoError(t, h.JoinGroup(c)) {
+		const groupJSON = `{"name": "Gophers", "score": 100, "rank": 0, "member_count": 1}`
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		require.JSONEq(t, groupJSON, rec.Body.String())
+	}
+
+	// JoinGroup - invalid body
+	req2 := httptest.NewRequest(http.MethodPost, "/groups/:group/members", strings.NewReader(`{"nam`))
+	req2.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec2 := httptest.NewRecorder()
+	c2 := e.NewContext(req2, rec2)
+	c2.SetParamNames("group")
+	c2.SetParamValues("Gophers")
+	if assert.NoError(t, h.JoinGroup(c2)) {
+		const errorJSON = `{"message": "invalid body: member info"}`
+		assert.Equal(t, http.StatusBadRequest, rec2.Code)
+		require.JSONEq(t, errorJSON, rec2.Body.String())
+	}
+
+	// LeaveGroup
+	req3 := httptest.NewRequest(http.MethodDelete, "/groups/:group/members?name=Minsik", nil)
+	rec3 := httptest.NewRecorder()
+	c3 := e.NewContext(req3, rec3)
+	c3.SetParamNames("group")
+	c3.SetParamValues("Gophers")
+	if assert.NoError(t, h.LeaveGroup(c3)) {
+		const leaveJSON = `{"group": "Gophers", "name": "Minsik", "is_left": true}`
+		assert.Equal(t, http.StatusOK, rec3.Code)
+		require.JSONEq(t, leaveJSON, rec3.Body.String())
+	}
+
+	// LeaveGroup - empty name
+	req4 := httptest.NewRequest(http.MethodDelete, "/groups/:group/members", nil)
+	rec4 := httptest.NewRecorder()
+	c4 := e.NewContext(req4, rec4)
+	c4.SetParamNames("group")
+	c4.SetParamValues("Gophers")
+	if assert.NoError(t, h.LeaveGroup(c4)) {
+		const errorJSON = `{"message": "user name is empty"}`
+		assert.Equal(t, http.StatusBadRequest, rec4.Code)
+		require.JSONEq(t, errorJSON, rec4.Body.String())
+	}
+}
+
+func TestGetGroup(t *testing.T) {
+	// Setup
+	e := echo.New()
+	lb := newFakeGroupLeaderBoard()
+	lb.UserGroups["Minsik"] = "Gophers"
+	lb.UserGroups["Foo"] = "Gophers"
+	h := &Handler{lb}
+
+	// GetGroup
+	req := httptest.NewRequest(http.MethodGet, "/groups/:group", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("group")
+	c.SetParamValues("Gophers")
+	if assert.NoError(t, h.GetGroup(c)) {
+		const groupJSON = `{"name": "Gophers", "score": 300, "rank": 0, "member_count": 2}`
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, groupJSON, rec.Body.String())
+	}
+
+	// GetGroup - not exists
+	req2 := httptest.NewRequest(http.MethodGet, "/groups/:group", nil)
+	rec2 := httptest.NewRecorder()
+	c2 := e.NewContext(req2, rec2)
+	c2.SetParamNames("group")
+	c2.SetParamValues("Bar")
+	if assert.NoError(t, h.GetGroup(c2)) {
+		const errorJSON = `{"message": "not exists group: Bar"}`
+		assert.Equal(t, http.StatusNotFound, rec2.Code)
+		require.JSONEq(t, errorJSON, rec2.Body.String())
+	}
+
+	// GetGroupMembers
+	req3 := httptest.NewRequest(http.MethodGet, "/groups/:group/members", nil)
+	rec3 := httptest.NewRecorder()
+	c3 := e.NewContext(req3, rec3)
+	c3.SetParamNames("group")
+	c3.SetParamValues("Gophers")
+	if assert.NoError(t, h.GetGroupMembers(c3)) {
+		const membersJSON = `[
+			{"name": "Minsik", "score": 100},
+			{"name": "Foo", "score": 200}
+		]`
+		assert.Equal(t, http.StatusOK, rec3.Code)
+		require.JSONEq(t, membersJSON, rec3.Body.String())
+	}
+
+	// GetGroupList
+	req4 := httptest.NewRequest(http.MethodGet, "/groups/:start/to/:stop", nil)
+	rec4 := httptest.NewRecorder()
+	c4 := e.NewContext(req4, rec4)
+	c4.SetParamNames("start", "stop")
+	c4.SetParamValues("0", "1")
+	if assert.NoError(t, h.GetGroupList(c4)) {
+		const groupListJSON = `[
+			{"name": "Gophers", "score": 300},
+			{"name": "Rustaceans", "score": 500}
+		]`
+		assert.Equal(t, http.StatusOK, rec4.Code)
+		require.JSONEq(t, groupListJSON, rec4.Body.String())
+	}
+
+	// GetGroupList - invalid stop
+	req5 := httptest.NewRequest(http.MethodGet, "/groups/:start/to/:stop", nil)
+	rec5 := httptest.NewRecorder()
+	c5 := e.NewContext(req5, rec5)
+	c5.SetParamNames("start", "stop")
+	c5.SetParamValues("0", "xyz")
+	if assert.NoError(t, h.GetGroupList(c5)) {
+		const errorJSON = `{"message": "invalid stop index"}`
+		assert.Equal(t, http.StatusBadRequest, rec5.Code)
+		require.JSONEq(t, errorJSON, rec5.Body.String())
+	}
+}
+
+func TestGroupListRange(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &Handler{newFakeGroupLeaderBoard()}
+
+	testCases := []struct {
+		title   string
+		start   string
+		stop    string
+		message string
+	}{
+		{"negative start", "-1", "1", "invalid index range"},
+		{"stop before start", "2", "1", "invalid index range"},
+		{"too large range", "0", "100", "index range is too large"},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/groups/:start/to/:stop", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("start", "stop")
+		c.SetParamValues(tc.start, tc.stop)
+		if assert.NoError(t, h.GetGroupList(c)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code, tc.title)
+			require.JSONEq(t, `{"message": "`+tc.message+`"}`, rec.Body.String(), tc.title)
+		}
+	}
+}
+
+func TestGetUserGroup(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &Handler{newFakeGroupLeaderBoard()}
+
+	// GetUserGroup
+	req := httptest.NewRequest(http.MethodGet, "/users/group?name=Yumi", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.GetUserGroup(c)) {
+		const groupJSON = `{"name": "Rustaceans", "score": 500, "rank": 0, "member_count": 1}`
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, groupJSON, rec.Body.String())
+	}
+
+	// GetUserGroup - not in group
+	req2 := httptest.NewRequest(http.MethodGet, "/users/group?name=Foo", nil)
+	rec2 := httptest.NewRecorder()
+	c2 := e.NewContext(req2, rec2)
+	if assert.NoError(t, h.GetUserGroup(c2)) {
+		const errorJSON = `{"message": "not in any group: Foo"}`
+		assert.Equal(t, http.StatusNotFound, rec2.Code)
+		require.JSONEq(t, errorJSON, rec2.Body.String())
+	}
+
+	// GetUserGroup - empty name
+	req3 := httptest.NewRequest(http.MethodGet, "/users/group", nil)
+	rec3 := httptest.NewRecorder()
+	c3 := e.NewContext(req3, rec3)
+	if assert.NoError(t, h.GetUserGroup(c3)) {
+		const errorJSON = `{"message": "user name is empty"}`
+		assert.Equal(t, http.StatusBadRequest, rec3.Code)
+		require.JSONEq(t, errorJSON, rec3.Body.String())
+	}
+}
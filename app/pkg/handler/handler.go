@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/JeongMinSik/go-leaderboard/pkg/logger"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 )
@@ -17,6 +18,8 @@ type Handler struct {
 
 type messageData struct {
 	Message string `json:"message"`
+	// error 응답에만 포함. X-Request-ID header와 같음
+	RequestID string `json:"request_id,omitempty"`
 }
 
 type userCountData struct {
@@ -32,16 +35,36 @@ func responseJSON(c echo.Context, statusCode int, data interface{}) error {
 	return errors.Wrap(c.JSON(statusCode, data), "c.JSON")
 }
 
+// 5xx error는 요청 ID와 함께 log로 남깁니다.
 func errorJSON(c echo.Context, err error) error {
 	if err == nil {
 		return nil
 	}
-	statusCode := http.StatusInternalServerError
-	var apiErr interface{ StatusCode() int }
-	if ok := errors.As(err, &apiErr); ok {
-		statusCode = apiErr.StatusCode()
+	statusCode, data := errorData(c, err)
+	return responseJSON(c, statusCode, data)
+}
+
+// err를 응답할 status code와 내용을 반환합니다. 5xx error는 요청 ID와 함께 log로 남김
+func errorData(c echo.Context, err error) (int, messageData) {
+	ctx := c.Request().Context()
+	statusCode := leaderboard.StatusCode(err)
+	if statusCode >= http.StatusInternalServerError {
+		logger.FromContext(ctx).WithError(err).WithField("status", statusCode).Error("request failed")
 	}
-	return responseJSON(c, statusCode, messageData{err.Error()})
+	return statusCode, newMessageData(c, statusCode, err.Error())
+}
+
+// error 응답에는 요청 ID를 포함합니다.
+func messageJSON(c echo.Context, statusCode int, message string) error {
+	return responseJSON(c, statusCode, newMessageData(c, statusCode, message))
+}
+
+func newMessageData(c echo.Context, statusCode int, message string) messageData {
+	data := messageData{Message: message}
+	if statusCode >= http.StatusBadRequest {
+		data.RequestID = logger.RequestID(c.Request().Context())
+	}
+	return data
 }
 
 // @Description 테스트용
@@ -64,12 +87,24 @@ func (h *Handler) Teapot(c echo.Context) error {
 // @Description 전체 유저 수
 // @Tags        Users
 // @Produce     json
-// @Success     200 {object} userCountData
-// @Failure     500 {object} messageData "서버에러"
+// @Param       segment query    string false "Segment (예: country:KR)"
+// @Success     200     {object} userCountData
+// @Failure     400     {object} messageData "segment 확인 필요"
+// @Failure     500     {object} messageData "서버에러"
+// @Security    ApiKeyAuth
 // @Router      /users/count [get]
 func (h *Handler) GetUserCount(c echo.Context) error {
-	ctx := context.Background()
-	count, err := h.Leaderboard.UserCount(ctx)
+	ctx := requestContext(c)
+	segments, segment, ok, err := h.segmentParam(c)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	var count int64
+	if ok {
+		count, err = segments.SegmentUserCount(ctx, segment)
+	} else {
+		count, err = h.Leaderboard.UserCount(ctx)
+	}
 	if err != nil {
 		return errorJSON(c, err)
 	}
@@ -77,50 +112,78 @@ func (h *Handler) GetUserCount(c echo.Context) error {
 }
 
 // @Summary     Show a user info
-// @Description name으로 User의 score와 rank를 얻습니다.
+// @Description name으로 User의 score와 rank를 얻습니다. name 없이 limit이나 cursor를 보내면 GET /users/list 와 같이 user page를 반환합니다.
 // @Tags        Users
 // @Produce     json
-// @Param       name query    string true "User name"
-// @Success     200  {object} leaderboard.UserRank
-// @Failure     400  {object} messageData "name query param 확인 필요"
-// @Failure     500  {object} messageData "서버에러"
+// @Param       name    query    string true  "User name"
+// @Param       segment query    string false "Segment (예: country:KR)"
+// @Success     200     {object} leaderboard.UserRank
+// @Header      200     {string} ETag "PATCH, DELETE의 If-Match에 사용"
+// @Failure     400     {object} messageData "name, segment query param 확인 필요"
+// @Failure     500     {object} messageData "서버에러"
+// @Security    ApiKeyAuth
 // @Router      /users [get]
 func (h *Handler) GetUser(c echo.Context) error {
-	ctx := context.Background()
+	ctx := requestContext(c)
 	userName := c.QueryParam("name")
 	if userName == "" {
-		return responseJSON(c, http.StatusBadRequest, messageData{"user name is empty"})
+		if params := c.QueryParams(); params.Has("limit") || params.Has("cursor") {
+			return h.GetUserPage(c)
+		}
+		return messageJSON(c, http.StatusBadRequest, "user name is empty")
+	}
+	segments, segment, ok, err := h.segmentParam(c)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	var user *leaderboard.UserRank
+	if ok {
+		user, err = segments.GetSegmentUser(ctx, segment, userName)
+	} else {
+		user, err = h.Leaderboard.GetUser(ctx, userName)
 	}
-	user, err := h.Leaderboard.GetUser(ctx, userName)
 	if err != nil {
 		return errorJSON(c, err)
 	}
+	setETag(c, user)
 	return responseJSON(c, http.StatusOK, user)
 }
 
 // @Summary     Add a user
-// @Description 신규 user를 추가합니다.
+// @Description 신규 user를 추가합니다. segments가 있으면 해당 segment board에도 함께 기록합니다.
+// @Description 서명 모드(SUBMISSION_SECRETS_FILE)에서 API key 없이 보내면 body에 game, nonce, timestamp, signature가 필요합니다.
+// @Description signature는 board, name, score, timestamp, nonce, segments(attr 순으로 정렬한 query string)를 서명한 값입니다 (auth.SignSubmission).
 // @Tags        Users
 // @accept      json
 // @Produce     json
-// @Param       user body     leaderboard.User true "New User"
-// @Success     201  {object} leaderboard.UserRank
-// @Failure     400  {object} messageData "request body 확인 필요"
-// @Failure     500  {object} messageData "서버에러"
+// @Param       user            body     leaderboard.User true  "New User"
+// @Param       Idempotency-Key header   string           false "같은 key로 다시 보내면 첫 응답을 다시 보냄"
+// @Success     201             {object} leaderboard.UserRank
+// @Success     202             {object} messageData "점수 기록 규칙 위반으로 검토 대기"
+// @Failure     400             {object} messageData "request body 확인 필요"
+// @Failure     401             {object} messageData "서명 확인 실패"
+// @Failure     403             {object} messageData "다른 user의 player token, 차단된 user"
+// @Failure     409             {object} messageData "이미 사용한 nonce, 같은 Idempotency-Key 요청 처리 중"
+// @Failure     422             {object} messageData "다른 요청에 사용한 Idempotency-Key"
+// @Failure     429             {object} messageData "분당 기록 횟수 초과"
+// @Failure     500             {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Security    BearerAuth
 // @Router      /users [post]
 func (h *Handler) AddUser(c echo.Context) error {
-	ctx := context.Background()
+	ctx := requestContext(c)
 	user := leaderboard.User{}
 	if err := json.NewDecoder(c.Request().Body).Decode(&user); err != nil {
-		return responseJSON(c, http.StatusBadRequest, messageData{"invalid body: user info"})
+		return messageJSON(c, http.StatusBadRequest, "invalid body: user info")
 	}
-	if err := h.Leaderboard.AddUser(ctx, user); err != nil {
+	if err := requirePlayer(c, user.Name); err != nil {
 		return errorJSON(c, err)
 	}
-	userRank, err := h.Leaderboard.GetUser(ctx, user.Name)
+	userRank, err := h.addUser(ctx, user)
 	if err != nil {
 		return errorJSON(c, err)
 	}
+	setETag(c, userRank)
 	return responseJSON(c, http.StatusCreated, userRank)
 }
 
@@ -128,18 +191,24 @@ func (h *Handler) AddUser(c echo.Context) error {
 // @Description 기존 user를 삭제합니다.
 // @Tags        Users
 // @Produce     json
-// @Param       name query    string true "User name"
-// @Success     200  {object} deleteData
-// @Failure     400  {object} messageData "name 확인 필요"
-// @Failure     500  {object} messageData "서버에러"
+// @Param       name            query    string true  "User name"
+// @Param       Idempotency-Key header   string false "같은 key로 다시 보내면 첫 응답을 다시 보냄"
+// @Param       If-Match        header   string false "GET /users의 ETag. 다르면 삭제하지 않음"
+// @Success     200             {object} deleteData
+// @Failure     400             {object} messageData "name 확인 필요"
+// @Failure     409             {object} messageData "같은 Idempotency-Key 요청 처리 중"
+// @Failure     412             {object} messageData "ETag 불일치"
+// @Failure     422             {object} messageData "다른 요청에 사용한 Idempotency-Key"
+// @Failure     500             {object} messageData "서버에러"
+// @Security    ApiKeyAuth
 // @Router      /users [delete]
 func (h *Handler) DeleteUser(c echo.Context) error {
-	ctx := context.Background()
+	ctx := requestContext(c)
 	userName := c.QueryParam("name")
 	if userName == "" {
-		return responseJSON(c, http.StatusBadRequest, messageData{"user name is empty"})
+		return messageJSON(c, http.StatusBadRequest, "user name is empty")
 	}
-	ok, err := h.Leaderboard.DeleteUser(ctx, userName)
+	ok, err := h.deleteUser(ctx, c, userName)
 	if err != nil {
 		return errorJSON(c, err)
 	}
@@ -150,55 +219,115 @@ func (h *Handler) DeleteUser(c echo.Context) error {
 }
 
 // @Summary     Update a user
-// @Description 기존 user를 수정합니다.
+// @Description 기존 user를 수정합니다. 바뀐 segments는 segment board에도 반영됩니다.
+// @Description 서명 모드(SUBMISSION_SECRETS_FILE)에서 API key 없이 보내면 body에 game, nonce, timestamp, signature가 필요합니다.
+// @Description signature는 board, name, score, timestamp, nonce, segments(attr 순으로 정렬한 query string)를 서명한 값입니다 (auth.SignSubmission).
 // @Tags        Users
 // @accept      json
 // @Produce     json
-// @Param       user body     leaderboard.User true "Updated User"
-// @Success     200  {object} leaderboard.UserRank
-// @Failure     400  {object} messageData "request body 확인 필요"
-// @Failure     500  {object} messageData "서버에러"
+// @Param       user            body     leaderboard.User true  "Updated User"
+// @Param       Idempotency-Key header   string           false "같은 key로 다시 보내면 첫 응답을 다시 보냄"
+// @Param       If-Match        header   string           false "GET /users의 ETag. 다르면 수정하지 않음"
+// @Success     200             {object} leaderboard.UserRank
+// @Success     202             {object} messageData "점수 기록 규칙 위반으로 검토 대기"
+// @Failure     400             {object} messageData "request body 확인 필요"
+// @Failure     401             {object} messageData "서명 확인 실패"
+// @Failure     403             {object} messageData "다른 user의 player token, 차단된 user"
+// @Failure     409             {object} messageData "이미 사용한 nonce, 같은 Idempotency-Key 요청 처리 중"
+// @Failure     412             {object} messageData "ETag 불일치"
+// @Failure     422             {object} messageData "다른 요청에 사용한 Idempotency-Key"
+// @Failure     429             {object} messageData "분당 기록 횟수 초과"
+// @Failure     500             {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Security    BearerAuth
 // @Router      /users [patch]
 func (h *Handler) UpdateUser(c echo.Context) error {
-	ctx := context.Background()
+	ctx := requestContext(c)
 	user := leaderboard.User{}
 	if err := json.NewDecoder(c.Request().Body).Decode(&user); err != nil {
-		return responseJSON(c, http.StatusBadRequest, messageData{"invalid body: user info"})
+		return messageJSON(c, http.StatusBadRequest, "invalid body: user info")
 	}
-	if err := h.Leaderboard.UpdateUser(ctx, user); err != nil {
+	if err := requirePlayer(c, user.Name); err != nil {
 		return errorJSON(c, err)
 	}
-	userRank, err := h.Leaderboard.GetUser(ctx, user.Name)
+	userRank, err := h.updateUser(ctx, c, user)
 	if err != nil {
 		return errorJSON(c, err)
 	}
+	setETag(c, userRank)
 	return responseJSON(c, http.StatusOK, userRank)
 }
 
 // @Summary     Get user list
-// @Description user list 를 받아옵니다.
+// @Description user list 를 받아옵니다. 한 번에 최대 100명까지 받을 수 있습니다.
 // @Tags        Users
 // @Produce     json
-// @Param       start path     int true "start index"
-// @Param       stop  path     int true "stop index"
-// @Success     200   {array}  leaderboard.UserRank
-// @Failure     400   {object} messageData "param 확인 필요"
-// @Failure     500   {object} messageData "서버에러"
+// @Param       start   path     int    true  "start index"
+// @Param       stop    path     int    true  "stop index"
+// @Param       segment query    string false "Segment (예: country:KR)"
+// @Success     200     {array}  leaderboard.User
+// @Failure     400     {object} messageData "param 확인 필요"
+// @Failure     500     {object} messageData "서버에러"
+// @Security    ApiKeyAuth
 // @Router      /users/{start}/to/{stop} [get]
 func (h *Handler) GetUserList(c echo.Context) error {
-	ctx := context.Background()
+	ctx := requestContext(c)
 	start, err := strconv.ParseInt(c.Param("start"), 0, 64)
 	if err != nil {
-		return responseJSON(c, http.StatusBadRequest, messageData{"invalid start index"})
+		return messageJSON(c, http.StatusBadRequest, "invalid start index")
 	}
 	stop, err := strconv.ParseInt(c.Param("stop"), 0, 64)
 	if err != nil {
-		return responseJSON(c, http.StatusBadRequest, messageData{"invalid stop index"})
+		return messageJSON(c, http.StatusBadRequest, "invalid stop index")
+	}
+	if start < 0 || stop < start {
+		return messageJSON(c, http.StatusBadRequest, "invalid index range")
+	}
+	if stop-start+1 > leaderboard.MaxPageSize {
+		return messageJSON(c, http.StatusBadRequest, "index range is too large")
 	}
 
-	userList, err := h.Leaderboard.GetUserList(ctx, start, stop)
+	segments, segment, ok, err := h.segmentParam(c)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	var userList []leaderboard.User
+	if ok {
+		userList, err = segments.GetSegmentUserList(ctx, segment, start, stop)
+	} else {
+		userList, err = h.Leaderboard.GetUserList(ctx, start, stop)
+	}
 	if err != nil {
 		return errorJSON(c, err)
 	}
 	return responseJSON(c, http.StatusOK, userList)
 }
+
+// 기록한 시점의 rank를 함께 받을 수 없으면 기록한 뒤에 조회합니다.
+func (h *Handler) addUser(ctx context.Context, user leaderboard.User) (*leaderboard.UserRank, error) {
+	if ranked, ok := h.Leaderboard.(leaderboard.RankedWriteInterface); ok {
+		return ranked.AddUserRank(ctx, user)
+	}
+	if err := h.Leaderboard.AddUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return h.Leaderboard.GetUser(ctx, user.Name)
+}
+
+// If-Match header가 있으면 ETag가 맞을 때만 수정합니다.
+func (h *Handler) updateUser(ctx context.Context, c echo.Context, user leaderboard.User) (*leaderboard.UserRank, error) {
+	if etags := ifMatchETags(c); etags != nil {
+		conditional, err := h.conditional()
+		if err != nil {
+			return nil, err
+		}
+		return conditional.UpdateUserIfMatch(ctx, user, etags)
+	}
+	if ranked, ok := h.Leaderboard.(leaderboard.RankedWriteInterface); ok {
+		return ranked.UpdateUserRank(ctx, user)
+	}
+	if err := h.Leaderboard.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return h.Leaderboard.GetUser(ctx, user.Name)
+}
@@ -308,6 +308,59 @@ func TestUpdateUser(t *testing.T) {
 	}
 }
 
+// 기록하면서 rank를 반환하므로 GetUser로 다시 조회하지 않는 fake
+type FakeRankedLeaderBoard struct {
+	FakeLeaderBoard
+}
+
+func (lb *FakeRankedLeaderBoard) GetUser(_ context.Context, name string) (*leaderboard.UserRank, error) {
+	return nil, errors.New("unexpected GetUser: " + name)
+}
+
+func (lb *FakeRankedLeaderBoard) AddUserRank(ctx context.Context, user leaderboard.User) (*leaderboard.UserRank, error) {
+	if err := lb.AddUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return lb.FakeLeaderBoard.GetUser(ctx, user.Name)
+}
+
+func (lb *FakeRankedLeaderBoard) UpdateUserRank(ctx context.Context, user leaderboard.User) (*leaderboard.UserRank, error) {
+	if err := lb.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return lb.FakeLeaderBoard.GetUser(ctx, user.Name)
+}
+
+func TestRankedWrite(t *testing.T) {
+	// Setup
+	e := echo.New()
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Yumi", 500, nil)
+	h := &Handler{&FakeRankedLeaderBoard{
+		FakeLeaderBoard: FakeLeaderBoard{UserSet: *sortedSet},
+	}}
+
+	// AddUser
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Minsik", "score": 100}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.AddUser(c)) {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		require.JSONEq(t, `{"name": "Minsik", "score": 100, "rank": 1}`, rec.Body.String())
+	}
+
+	// UpdateUser
+	req = httptest.NewRequest(http.MethodPatch, "/users", strings.NewReader(`{"name": "Minsik", "score": 10000}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, h.UpdateUser(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"name": "Minsik", "score": 10000, "rank": 0}`, rec.Body.String())
+	}
+}
+
 func TestUserList(t *testing.T) {
 	// Setup
 	e := echo.New()
@@ -376,3 +429,35 @@ func TestUserList(t *testing.T) {
 		require.JSONEq(t, errorJSON, rec4.Body.String())
 	}
 }
+
+func TestUserListRange(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+
+	// GetUserList - negative index
+	req := httptest.NewRequest(http.MethodGet, "/users/:start/to/:stop", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("start", "stop")
+	c.SetParamValues("0", "-1")
+	if assert.NoError(t, h.GetUserList(c)) {
+		const errorJSON = `{"message": "invalid index range"}`
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.JSONEq(t, errorJSON, rec.Body.String())
+	}
+
+	// GetUserList - too large range
+	req2 := httptest.NewRequest(http.MethodGet, "/users/:start/to/:stop", nil)
+	rec2 := httptest.NewRecorder()
+	c2 := e.NewContext(req2, rec2)
+	c2.SetParamNames("start", "stop")
+	c2.SetParamValues("0", "100")
+	if assert.NoError(t, h.GetUserList(c2)) {
+		const errorJSON = `{"message": "index range is too large"}`
+		assert.Equal(t, http.StatusBadRequest, rec2.Code)
+		require.JSONEq(t, errorJSON, rec2.Body.String())
+	}
+}
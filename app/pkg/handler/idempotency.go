@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/logger"
+	"github.com/JeongMinSik/go-leaderboard/pkg/ratelimit"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// 저장한 응답을 다시 보낼 때 붙이는 header
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// 응답을 저장하는 기본 시간
+	DefaultIdempotencyTTL   = 24 * time.Hour
+	maxIdempotencyKeyLength = 255
+	// 처리 중인 요청의 key. 처리 중에 서버가 죽어도 이 시간이 지나면 다시 보낼 수 있음
+	idempotencyLockTTL = time.Minute
+)
+
+type IdempotencyStore interface {
+	// 처음 사용한 key면 data를 ttl 동안 저장하고 true, 이미 있으면 false와 저장된 data를 반환합니다.
+	ReserveIdempotencyKey(ctx context.Context, key string, data string, ttl time.Duration) (bool, string, error)
+	SaveIdempotencyKey(ctx context.Context, key string, data string, ttl time.Duration) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+}
+
+// Idempotency-Key header가 있는 요청의 첫 응답을 TTL 동안 저장하고, 같은 key로 다시 보내면 저장한 응답을 보냅니다.
+type Idempotency struct {
+	Store IdempotencyStore
+	TTL   time.Duration
+}
+
+// 저장하는 요청 hash와 응답. Status가 0이면 처리 중
+type idempotentResponse struct {
+	Hash        string `json:"hash"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	ETag        string `json:"etag,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// handler가 보낸 body를 함께 저장합니다.
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// key는 요청자(player token의 user, API key, IP)별로 구분합니다. 같은 key로 다른 요청을 보내면 422, 첫 요청이 처리 중이면 409
+// 5xx 응답은 저장하지 않으므로 다시 보내면 다시 처리합니다. i가 nil이면 확인하지 않음
+// 다시 보낸 요청은 nonce를 이미 사용했으므로 Require 뒤, RequireSignature 앞에 등록해야 합니다.
+func (i *Idempotency) Require() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			value := c.Request().Header.Get(IdempotencyKeyHeader)
+			if i == nil || i.Store == nil || value == "" {
+				return next(c)
+			}
+			if len(value) > maxIdempotencyKeyLength {
+				return messageJSON(c, http.StatusBadRequest, "idempotency key is too long")
+			}
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return messageJSON(c, http.StatusBadRequest, "invalid body")
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := c.Request().Context()
+			key := requestIdentity(c, ratelimit.KeyByUser) + ":" + value
+			hash := requestHash(c, body)
+			pending, err := json.Marshal(idempotentResponse{Hash: hash})
+			if err != nil {
+				return errorJSON(c, errors.Wrap(err, "json.Marshal"))
+			}
+			reserved, data, err := i.Store.ReserveIdempotencyKey(ctx, key, string(pending), idempotencyLockTTL)
+			if err != nil {
+				return errorJSON(c, err)
+			}
+			if !reserved {
+				return replayResponse(c, hash, data)
+			}
+
+			recorder := &bodyRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+			err = next(c)
+			c.Response().Writer = recorder.ResponseWriter
+			// 응답은 이미 보냈으므로 저장하지 못해도 log만 남김
+			if err != nil || c.Response().Status >= http.StatusInternalServerError {
+				if err := i.Store.DeleteIdempotencyKey(ctx, key); err != nil {
+					logger.FromContext(ctx).WithError(err).Error("i.Store.DeleteIdempotencyKey")
+				}
+				return err
+			}
+			saved := idempotentResponse{
+				Hash:        hash,
+				Status:      c.Response().Status,
+				ContentType: c.Response().Header().Get(echo.HeaderContentType),
+				ETag:        c.Response().Header().Get(ETagHeader),
+				Body:        recorder.body.Bytes(),
+			}
+			if err := i.save(ctx, key, saved); err != nil {
+				logger.FromContext(ctx).WithError(err).Error("i.save")
+			}
+			return nil
+		}
+	}
+}
+
+func (i *Idempotency) save(ctx context.Context, key string, saved idempotentResponse) error {
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+	ttl := i.TTL
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return i.Store.SaveIdempotencyKey(ctx, key, string(data), ttl)
+}
+
+func replayResponse(c echo.Context, hash string, data string) error {
+	saved := idempotentResponse{}
+	if err := json.Unmarshal([]byte(data), &saved); err != nil {
+		return errorJSON(c, errors.Wrap(err, "json.Unmarshal"))
+	}
+	if saved.Hash != hash {
+		return messageJSON(c, http.StatusUnprocessableEntity, "idempotency key is reused with a different request")
+	}
+	if saved.Status == 0 {
+		return messageJSON(c, http.StatusConflict, "request with the same idempotency key is in progress")
+	}
+	c.Response().Header().Set(IdempotentReplayedHeader, "true")
+	if saved.ETag != "" {
+		c.Response().Header().Set(ETagHeader, saved.ETag)
+	}
+	return c.Blob(saved.Status, saved.ContentType, saved.Body)
+}
+
+// method, path, query(인증 값 제외), body의 sha256 hex
+func requestHash(c echo.Context, body []byte) string {
+	query := c.Request().URL.Query()
+	query.Del(apiKeyQuery)
+	query.Del(tokenQuery)
+	hash := sha256.New()
+	hash.Write([]byte(c.Request().Method + "\n" + c.Request().URL.Path + "\n" + query.Encode() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+type FakeIdempotencyStore map[string]string
+
+func (s FakeIdempotencyStore) ReserveIdempotencyKey(_ context.Context, key string, data string, _ time.Duration) (bool, string, error) {
+	if saved, ok := s[key]; ok {
+		return false, saved, nil
+	}
+	s[key] = data
+	return true, data, nil
+}
+
+func (s FakeIdempotencyStore) SaveIdempotencyKey(_ context.Context, key string, data string, _ time.Duration) error {
+	s[key] = data
+	return nil
+}
+
+func (s FakeIdempotencyStore) DeleteIdempotencyKey(_ context.Context, key string) error {
+	delete(s, key)
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	// Setup
+	e := echo.New()
+	store := FakeIdempotencyStore{}
+	idempotency := &Idempotency{Store: store, TTL: time.Hour}
+	authn := &Auth{Keys: FakeKeyStore{
+		"game-1": {ID: "game-1", Scopes: []auth.Scope{auth.ScopeAdmin}},
+		"game-2": {ID: "game-2", Scopes: []auth.Scope{auth.ScopeAdmin}},
+	}}
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+	e.POST("/users", h.AddUser, authn.Require(auth.ScopeSubmit), idempotency.Require())
+	e.DELETE("/users", h.DeleteUser, authn.Require(auth.ScopeAdmin), idempotency.Require())
+	e.POST("/fail", func(c echo.Context) error {
+		return messageJSON(c, http.StatusInternalServerError, "fail")
+	}, authn.Require(auth.ScopeSubmit), idempotency.Require())
+
+	testCases := []struct {
+		name     string
+		method   string
+		target   string
+		key      string
+		idemKey  string
+		body     string
+		code     int
+		result   string
+		replayed bool
+	}{
+		{"첫 요청", http.MethodPost, "/users", "game-1", "k1", `{"name": "Alice", "score": 10}`, http.StatusCreated, `{"name": "Alice", "score": 10, "rank": 0}`, false},
+		{"다시 보내면 저장한 응답", http.MethodPost, "/users", "game-1", "k1", `{"name": "Alice", "score": 10}`, http.StatusCreated, `{"name": "Alice", "score": 10, "rank": 0}`, true},
+		{"다른 body", http.MethodPost, "/users", "game-1", "k1", `{"name": "Alice", "score": 20}`, http.StatusUnprocessableEntity, `{"message": "idempotency key is reused with a different request"}`, false},
+		{"다른 route", http.MethodDelete, "/users?name=Alice", "game-1", "k1", "", http.StatusUnprocessableEntity, `{"message": "idempotency key is reused with a different request"}`, false},
+		{"다른 API key는 따로 저장", http.MethodPost, "/users", "game-2", "k1", `{"name": "Alice", "score": 10}`, http.StatusBadRequest, `{"message": "lb.UserSet.GetByKey: already exists name: Alice"}`, false},
+		{"4xx 응답도 저장", http.MethodPost, "/users", "game-2", "k1", `{"name": "Alice", "score": 10}`, http.StatusBadRequest, `{"message": "lb.UserSet.GetByKey: already exists name: Alice"}`, true},
+		{"key가 없으면 확인하지 않음", http.MethodDelete, "/users?name=Alice", "game-1", "", "", http.StatusOK, `{"name": "Alice", "is_deleted": true}`, false},
+		{"너무 긴 key", http.MethodPost, "/users", "game-1", strings.Repeat("k", 256), `{"name": "Alice", "score": 10}`, http.StatusBadRequest, `{"message": "idempotency key is too long"}`, false},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(APIKeyHeader, tc.key)
+		if tc.idemKey != "" {
+			req.Header.Set(IdempotencyKeyHeader, tc.idemKey)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.name)
+		require.JSONEq(t, tc.result, rec.Body.String(), tc.name)
+		assert.Equal(t, tc.replayed, rec.Header().Get(IdempotentReplayedHeader) == "true", tc.name)
+		if tc.code == http.StatusCreated {
+			assert.Equal(t, leaderboard.UserETag(leaderboard.User{Name: "Alice", Score: 10}), rec.Header().Get(ETagHeader), tc.name)
+		}
+	}
+	assert.Len(t, store, 2)
+
+	// 5xx 응답은 저장하지 않음
+	req := httptest.NewRequest(http.MethodPost, "/fail", nil)
+	req.Header.Set(APIKeyHeader, "game-1")
+	req.Header.Set(IdempotencyKeyHeader, "k2")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, store, "key:game-1:k2")
+
+	// 첫 요청이 처리 중
+	store["key:game-1:k3"] = `{"hash": "` + requestHash(e.NewContext(req, rec), nil) + `"}`
+	req = httptest.NewRequest(http.MethodPost, "/fail", nil)
+	req.Header.Set(APIKeyHeader, "game-1")
+	req.Header.Set(IdempotencyKeyHeader, "k3")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	require.JSONEq(t, `{"message": "request with the same idempotency key is in progress"}`, rec.Body.String())
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/metrics"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// route, status별 요청 수와 처리 시간을 m에 기록합니다. 등록되지 않은 path, method는 route를 "unmatched"로 기록
+func Metrics(m *metrics.Metrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			m.ObserveRequest(c.Request().Method, requestRoute(c, err), responseStatus(c, err), time.Since(start))
+			return err
+		}
+	}
+}
+
+// handler가 error를 반환하면 아직 응답을 쓰기 전이므로 error의 status code
+func responseStatus(c echo.Context, err error) int {
+	status := c.Response().Status
+	if err != nil {
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			status = httpErr.Code
+		} else if !c.Response().Committed {
+			status = http.StatusInternalServerError
+		}
+	}
+	return status
+}
+
+// 등록되지 않은 path, method면 router가 비슷한 path를 넣어두므로 구분합니다.
+func requestRoute(c echo.Context, err error) string {
+	route := c.Path()
+	if route == "" || errors.Is(err, echo.ErrNotFound) || errors.Is(err, echo.ErrMethodNotAllowed) {
+		return "unmatched"
+	}
+	return route
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/metrics"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/wangjia184/sortedset"
+)
+
+func TestMetrics(t *testing.T) {
+	// Setup
+	e := echo.New()
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedSet,
+	}}
+	m := metrics.New()
+	e.Use(Metrics(m))
+	e.GET("/users/:start/to/:stop", h.GetUserList)
+	e.GET("/metrics", echo.WrapHandler(m.Handler()))
+
+	for _, path := range []string{"/users/0/to/10", "/users/0/to/1", "/users/9/to/0", "/not-exists"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users/0/to/1", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if assert.Equal(t, http.StatusOK, rec.Code) {
+		body := rec.Body.String()
+		// path parameter 값이 아니라 등록한 route로 기록
+		assert.Contains(t, body, `leaderboard_http_requests_total{method="GET",route="/users/:start/to/:stop",status="200"} 2`)
+		assert.Contains(t, body, `leaderboard_http_requests_total{method="GET",route="/users/:start/to/:stop",status="400"} 1`)
+		assert.Contains(t, body, `leaderboard_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+		assert.Contains(t, body, `leaderboard_http_requests_total{method="POST",route="unmatched",status="405"} 1`)
+		assert.Contains(t, body, `leaderboard_http_request_duration_seconds_count{method="GET",route="/users/:start/to/:stop",status="200"} 2`)
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+func (h *Handler) pages() (leaderboard.PageInterface, error) {
+	pages, ok := h.Leaderboard.(leaderboard.PageInterface)
+	if !ok {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("paging is not supported"), http.StatusNotImplemented)
+	}
+	return pages, nil
+}
+
+// @Summary     Get user page
+// @Description user list 를 cursor 기반으로 받아옵니다. 다음 page는 응답의 next_cursor로 요청합니다.
+// @Description limit은 최대 100까지 허용됩니다. cursor는 (score, name) 기준이라 점수가 바뀌지 않은 user는 중복되지 않지만,
+// @Description 읽는 도중 점수가 cursor를 넘어 바뀐 user는 누락되거나 두 번 나올 수 있습니다.
+// @Description name 없이 GET /users?limit=&cursor= 로 요청해도 같습니다.
+// @Tags        Users
+// @Produce     json
+// @Param       limit   query    int    false "page size (기본 20, 최대 100)"
+// @Param       cursor  query    string false "이전 응답의 next_cursor"
+// @Param       segment query    string false "Segment (예: country:KR)"
+// @Success     200     {object} leaderboard.UserPage
+// @Failure     400     {object} messageData "param 확인 필요"
+// @Failure     500     {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /users/list [get]
+func (h *Handler) GetUserPage(c echo.Context) error {
+	ctx := requestContext(c)
+	limit := int64(leaderboard.DefaultPageSize)
+	if param := c.QueryParam("limit"); param != "" {
+		parsed, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return messageJSON(c, http.StatusBadRequest, "invalid limit")
+		}
+		limit = parsed
+	}
+	cursor := c.QueryParam("cursor")
+
+	segments, segment, ok, err := h.segmentParam(c)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	var page *leaderboard.UserPage
+	if ok {
+		page, err = segments.GetSegmentUserPage(ctx, segment, cursor, limit)
+	} else {
+		pages, pagesErr := h.pages()
+		if pagesErr != nil {
+			return errorJSON(c, pagesErr)
+		}
+		page, err = pages.GetUserPage(ctx, cursor, limit)
+	}
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, page)
+}
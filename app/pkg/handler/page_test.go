@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+// cursor로 다음 index를 그대로 사용하는 fake
+type FakePageLeaderBoard struct {
+	FakeLeaderBoard
+}
+
+func (lb *FakePageLeaderBoard) GetUserPage(ctx context.Context, cursor string, limit int64) (*leaderboard.UserPage, error) {
+	start := int64(0)
+	if cursor != "" {
+		parsed, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			err := leaderboard.ErrorWithStatusCode(errors.New("cursor"), http.StatusBadRequest)
+			return nil, errors.Wrap(err, "invalid")
+		}
+		start = parsed
+	}
+	if limit > leaderboard.MaxPageSize {
+		limit = leaderboard.MaxPageSize
+	}
+	users, _ := lb.GetUserList(ctx, start, start+limit-1)
+	total, _ := lb.UserCount(ctx)
+	page := &leaderboard.UserPage{
+		Users: users,
+		Total: total,
+	}
+	if start+limit < total {
+		page.NextCursor = strconv.FormatInt(start+limit, 10)
+	}
+	return page, nil
+}
+
+func TestGetUserPage(t *testing.T) {
+	// Setup
+	e := echo.New()
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Yumi", 500, nil)
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	sortedSet.AddOrUpdate("Foo", 200, nil)
+	h := &Handler{&FakePageLeaderBoard{FakeLeaderBoard{
+		UserSet: *sortedSet,
+	}}}
+
+	// GetUserPage - first page
+	req := httptest.NewRequest(http.MethodGet, "/users/list?limit=2", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.GetUserPage(c)) {
+		const pageJSON = `{
+			"users": [
+				{"name": "Foo", "score": 200},
+				{"name": "Minsik", "score": 100}
+			],
+			"total": 3,
+			"next_cursor": "2"
+		}`
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, pageJSON, rec.Body.String())
+	}
+
+	// GetUserPage - last page
+	req2 := httptest.NewRequest(http.MethodGet, "/users/list?limit=2&cursor=2", nil)
+	rec2 := httptest.NewRecorder()
+	c2 := e.NewContext(req2, rec2)
+	if assert.NoError(t, h.GetUserPage(c2)) {
+		const pageJSON = `{
+			"users": [
+				{"name": "Yumi", "score": 500}
+			],
+			"total": 3
+		}`
+		assert.Equal(t, http.StatusOK, rec2.Code)
+		require.JSONEq(t, pageJSON, rec2.Body.String())
+	}
+
+	// GetUserPage - invalid limit
+	req3 := httptest.NewRequest(http.MethodGet, "/users/list?limit=abc", nil)
+	rec3 := httptest.NewRecorder()
+	c3 := e.NewContext(req3, rec3)
+	if assert.NoError(t, h.GetUserPage(c3)) {
+		const errorJSON = `{"message": "invalid limit"}`
+		assert.Equal(t, http.StatusBadRequest, rec3.Code)
+		require.JSONEq(t, errorJSON, rec3.Body.String())
+	}
+
+	// GetUserPage - invalid cursor
+	req4 := httptest.NewRequest(http.MethodGet, "/users/list?cursor=abc", nil)
+	rec4 := httptest.NewRecorder()
+	c4 := e.NewContext(req4, rec4)
+	if assert.NoError(t, h.GetUserPage(c4)) {
+		const errorJSON = `{"message": "invalid: cursor"}`
+		assert.Equal(t, http.StatusBadRequest, rec4.Code)
+		require.JSONEq(t, errorJSON, rec4.Body.String())
+	}
+
+	// GetUser - name 없이 limit, cursor를 보내면 page
+	req6 := httptest.NewRequest(http.MethodGet, "/users?limit=2&cursor=2", nil)
+	rec6 := httptest.NewRecorder()
+	c6 := e.NewContext(req6, rec6)
+	if assert.NoError(t, h.GetUser(c6)) {
+		const pageJSON = `{
+			"users": [
+				{"name": "Yumi", "score": 500}
+			],
+			"total": 3
+		}`
+		assert.Equal(t, http.StatusOK, rec6.Code)
+		require.JSONEq(t, pageJSON, rec6.Body.String())
+	}
+
+	// DeleteUser - limit이 있어도 page를 반환하지 않음
+	req7 := httptest.NewRequest(http.MethodDelete, "/users?limit=10", nil)
+	rec7 := httptest.NewRecorder()
+	c7 := e.NewContext(req7, rec7)
+	if assert.NoError(t, h.DeleteUser(c7)) {
+		const errorJSON = `{"message": "user name is empty"}`
+		assert.Equal(t, http.StatusBadRequest, rec7.Code)
+		require.JSONEq(t, errorJSON, rec7.Body.String())
+	}
+
+	// GetUserPage - not supported
+	h2 := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+	req5 := httptest.NewRequest(http.MethodGet, "/users/list", nil)
+	rec5 := httptest.NewRecorder()
+	c5 := e.NewContext(req5, rec5)
+	if assert.NoError(t, h2.GetUserPage(c5)) {
+		assert.Equal(t, http.StatusNotImplemented, rec5.Code)
+	}
+}
+
+func TestGetSegmentUserPage(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &Handler{newFakeSegmentLeaderBoard()}
+
+	// GetUserPage - segment
+	req := httptest.NewRequest(http.MethodGet, "/users/list?segment=country:KR", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.GetUserPage(c)) {
+		const pageJSON = `{
+			"users": [
+				{"name": "Foo", "score": 200},
+				{"name": "Minsik", "score": 100}
+			],
+			"total": 2
+		}`
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, pageJSON, rec.Body.String())
+	}
+}
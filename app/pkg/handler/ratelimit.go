@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/ratelimit"
+	"github.com/labstack/echo/v4"
+)
+
+// route에 규칙이 있으면 요청 수를 제한하고, 넘으면 429와 Retry-After(초)를 보냅니다. limiter가 nil이면 제한하지 않음
+// API key, player token으로 구분하려면 Auth.Require 뒤에 두어야 합니다.
+func RateLimit(limiter *ratelimit.Limiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if limiter == nil {
+				return next(c)
+			}
+			route := c.Request().Method + " " + c.Path()
+			rule, ok := limiter.Rule(route)
+			if !ok {
+				return next(c)
+			}
+			allowed, retryAfter, err := limiter.Allow(c.Request().Context(), route, requestIdentity(c, rule.Key))
+			if err != nil {
+				return errorJSON(c, err)
+			}
+			if !allowed {
+				seconds := int(math.Max(1, math.Ceil(retryAfter.Seconds())))
+				c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+				return messageJSON(c, http.StatusTooManyRequests, "rate limit exceeded")
+			}
+			return next(c)
+		}
+	}
+}
+
+// keyBy로 요청자를 구분합니다. 예: user:Alice, key:game-server, ip:127.0.0.1
+func requestIdentity(c echo.Context, keyBy ratelimit.KeyBy) string {
+	if keyBy == ratelimit.KeyByUser {
+		if subject, ok := c.Get(authPlayerContext).(string); ok {
+			return "user:" + subject
+		}
+	}
+	if keyBy == ratelimit.KeyByUser || keyBy == ratelimit.KeyByAPIKey {
+		if key, ok := c.Get(authKeyContext).(*auth.Key); ok {
+			return "key:" + key.ID
+		}
+	}
+	return "ip:" + c.RealIP()
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/ratelimit"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// key별로 limit번까지 허용하고, 넘으면 1.5초 뒤에 다시 요청하라고 하는 fake
+type FakeTokenStore map[string]int64
+
+func (s FakeTokenStore) TakeToken(_ context.Context, key string, limit int64, _ time.Duration, _ time.Time) (bool, time.Duration, error) {
+	if s[key] >= limit {
+		return false, 1500 * time.Millisecond, nil
+	}
+	s[key]++
+	return true, 0, nil
+}
+
+func TestRateLimit(t *testing.T) {
+	// Setup
+	e := echo.New()
+	store := FakeTokenStore{}
+	limiter, err := ratelimit.NewLimiter(map[string]ratelimit.Rule{
+		"POST /users":                {Limit: 1, Window: time.Minute, Key: ratelimit.KeyByUser},
+		"GET /users/:start/to/:stop": {Limit: 2, Window: time.Second, Key: ratelimit.KeyByAPIKey},
+	}, store)
+	require.NoError(t, err)
+	players, err := auth.NewTokenVerifier("secret", "")
+	require.NoError(t, err)
+	authn := &Auth{Keys: FakeKeyStore{
+		"reader": {ID: "reader", Scopes: []auth.Scope{auth.ScopeRead, auth.ScopeSubmit}},
+	}, Players: players}
+	ok := func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	}
+	e.POST("/users", ok, authn.Require(auth.ScopeSubmit), RateLimit(limiter))
+	e.GET("/users/:start/to/:stop", ok, authn.Require(auth.ScopeRead), RateLimit(limiter))
+	e.GET("/users/count", ok, authn.Require(auth.ScopeRead), RateLimit(limiter))
+
+	sign := func(subject string) string {
+		claims := jwt.StandardClaims{Subject: subject, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		require.NoError(t, err)
+		return token
+	}
+	request := func(method string, target string, key string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// route별 규칙
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/users/0/to/9", "reader", "").Code)
+	}
+	rec := request(http.MethodGet, "/users/0/to/9", "reader", "")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	require.JSONEq(t, `{"message": "rate limit exceeded"}`, rec.Body.String())
+
+	// 규칙이 없는 route
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/users/count", "reader", "").Code)
+	}
+
+	// user별로 구분. player token이 없으면 API key
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/users", "", sign("Alice")).Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/users", "", sign("Bob")).Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/users", "reader", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(http.MethodPost, "/users", "", sign("Alice")).Code)
+	assert.Equal(t, FakeTokenStore{
+		"GET /users/:start/to/:stop:key:reader": 2,
+		"POST /users:user:Alice":                1,
+		"POST /users:user:Bob":                  1,
+		"POST /users:key:reader":                1,
+	}, store)
+}
+
+func TestRateLimitByIP(t *testing.T) {
+	e := echo.New()
+	limiter, err := ratelimit.NewLimiter(map[string]ratelimit.Rule{
+		"GET /users": {Limit: 1, Window: time.Minute, Key: ratelimit.KeyByIP},
+	}, FakeTokenStore{})
+	require.NoError(t, err)
+	e.GET("/users", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	}, RateLimit(limiter))
+
+	for _, testCase := range []struct {
+		ip   string
+		code int
+	}{
+		{"10.0.0.1", http.StatusOK},
+		{"10.0.0.2", http.StatusOK},
+		{"10.0.0.1", http.StatusTooManyRequests},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.RemoteAddr = testCase.ip + ":1234"
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, testCase.code, rec.Code, testCase.ip)
+	}
+
+	// limiter가 nil이면 제한하지 않음
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/users", nil), rec)
+	assert.NoError(t, RateLimit(nil)(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/JeongMinSik/go-leaderboard/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// client가 보낸 요청 ID의 최대 길이
+const maxRequestIDLength = 128
+
+// 요청의 X-Request-ID header를 이어받거나 새로 만들어서 응답 header와 요청 context에 넣습니다.
+// 비어 있거나 너무 길거나 출력할 수 없는 문자가 있으면 새로 만듦
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if !validRequestID(id) {
+				id = newRequestID()
+				req.Header.Set(echo.HeaderXRequestID, id)
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+			c.SetRequest(req.WithContext(logger.WithRequestID(req.Context(), id)))
+			return next(c)
+		}
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	data := make([]byte, 16)
+	// crypto/rand는 실패하지 않음
+	_, _ = rand.Read(data)
+	return hex.EncodeToString(data)
+}
+
+// 등록되지 않은 path, method 같은 echo의 error도 errorJSON과 같은 형식으로 응답합니다.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		message, ok := httpErr.Message.(string)
+		if !ok {
+			message = http.StatusText(httpErr.Code)
+		}
+		err = leaderboard.ErrorWithStatusCode(errors.New(message), httpErr.Code)
+	}
+	if err := errorJSON(c, err); err != nil {
+		logger.FromContext(c.Request().Context()).WithError(err).Error("errorJSON")
+	}
+}
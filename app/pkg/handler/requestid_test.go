@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/JeongMinSik/go-leaderboard/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	// Setup
+	log, err := logger.New(logger.Config{Sink: logger.SinkStdout})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	logger.SetDefault(log)
+
+	e := echo.New()
+	e.Pre(RequestID())
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.GET("/fail", func(c echo.Context) error {
+		assert.Equal(t, c.Response().Header().Get(echo.HeaderXRequestID), logger.RequestID(requestContext(c)))
+		return errorJSON(c, errors.New("redis down"))
+	})
+	e.GET("/ok", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	// 없으면 새로 만듦
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	assert.Len(t, rec.Header().Get(echo.HeaderXRequestID), 32)
+
+	// 보낸 요청 ID를 이어받고, error 응답과 log에 포함
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(echo.HeaderXRequestID, "client-request-1")
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "client-request-1", rec.Header().Get(echo.HeaderXRequestID))
+	require.JSONEq(t, `{"message": "redis down", "request_id": "client-request-1"}`, rec.Body.String())
+	var entry map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry)) {
+		assert.Equal(t, "client-request-1", entry[logger.RequestIDField])
+		assert.Equal(t, "redis down", entry["error"])
+	}
+
+	// 너무 길거나 출력할 수 없는 문자가 있으면 새로 만듦
+	for _, id := range []string{strings.Repeat("a", maxRequestIDLength+1), "id with space", "id\x00"} {
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/ok", nil)
+		req.Header.Set(echo.HeaderXRequestID, id)
+		e.ServeHTTP(rec, req)
+		assert.Len(t, rec.Header().Get(echo.HeaderXRequestID), 32)
+	}
+
+	// 등록되지 않은 path도 같은 형식으로 응답
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/not-exists", nil)
+	req.Header.Set(echo.HeaderXRequestID, "client-request-2")
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	require.JSONEq(t, `{"message": "Not Found", "request_id": "client-request-2"}`, rec.Body.String())
+}
+
+func TestMessageJSON(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(logger.WithRequestID(req.Context(), "request-1"))
+
+	// error 응답에만 요청 ID를 포함
+	rec := httptest.NewRecorder()
+	assert.NoError(t, messageJSON(e.NewContext(req, rec), http.StatusOK, "restored: 1"))
+	require.JSONEq(t, `{"message": "restored: 1"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	err := leaderboard.ErrorWithStatusCode(errors.New("user name is empty"), http.StatusBadRequest)
+	assert.NoError(t, errorJSON(e.NewContext(req, rec), err))
+	require.JSONEq(t, `{"message": "user name is empty", "request_id": "request-1"}`, rec.Body.String())
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+type discardReviewData struct {
+	ID          string `json:"id"`
+	IsDiscarded bool   `json:"is_discarded"`
+}
+
+func (h *Handler) reviews() (leaderboard.ReviewInterface, error) {
+	reviews, ok := h.Leaderboard.(leaderboard.ReviewInterface)
+	if !ok {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("reviews are not supported"), http.StatusNotImplemented)
+	}
+	return reviews, nil
+}
+
+// @Summary     Get review queue
+// @Description 점수 기록 규칙(SCORE_RULES_FILE)을 어겨서 검토 대기 중인 기록을 오래된 순서로 반환합니다.
+// @Tags        Admin
+// @Produce     json
+// @Success     200 {array}  leaderboard.Review
+// @Failure     500 {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /admin/reviews [get]
+func (h *Handler) GetReviews(c echo.Context) error {
+	ctx := requestContext(c)
+	reviews, err := h.reviews()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	reviewList, err := reviews.Reviews(ctx)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, reviewList)
+}
+
+// @Summary     Approve a review
+// @Description 검토 대기 중인 기록을 규칙 확인 없이 board에 기록합니다.
+// @Tags        Admin
+// @Produce     json
+// @Param       id  path     string true "Review ID"
+// @Success     200 {object} messageData
+// @Failure     404 {object} messageData "없는 review"
+// @Failure     500 {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /admin/reviews/{id}/approve [post]
+func (h *Handler) ApproveReview(c echo.Context) error {
+	ctx := requestContext(c)
+	reviews, err := h.reviews()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	id := c.Param("id")
+	if err := reviews.ApproveReview(ctx, id); err != nil {
+		return errorJSON(c, err)
+	}
+	return messageJSON(c, http.StatusOK, "approved: "+id)
+}
+
+// @Summary     Discard a review
+// @Description 검토 대기 중인 기록을 기록하지 않고 지웁니다.
+// @Tags        Admin
+// @Produce     json
+// @Param       id  path     string true "Review ID"
+// @Success     200 {object} discardReviewData
+// @Failure     500 {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /admin/reviews/{id} [delete]
+func (h *Handler) DiscardReview(c echo.Context) error {
+	ctx := requestContext(c)
+	reviews, err := h.reviews()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	id := c.Param("id")
+	ok, err := reviews.DiscardReview(ctx, id)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, discardReviewData{id, ok})
+}
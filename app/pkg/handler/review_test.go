@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+// MaxScore를 넘는 점수는 검토 대기열에 넣는 fake
+type FakeReviewLeaderBoard struct {
+	FakeLeaderBoard
+	MaxScore float64
+	reviews  []leaderboard.Review
+}
+
+func (lb *FakeReviewLeaderBoard) AddUser(ctx context.Context, user leaderboard.User) error {
+	if user.Score > lb.MaxScore {
+		review := leaderboard.Review{
+			ID:        strconv.Itoa(len(lb.reviews) + 1),
+			User:      user,
+			Board:     "users",
+			Reason:    "score exceeds 1000",
+			CreatedAt: time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC),
+		}
+		lb.reviews = append(lb.reviews, review)
+		return leaderboard.ErrorWithStatusCode(errors.New("submission is under review: "+review.ID), http.StatusAccepted)
+	}
+	return lb.FakeLeaderBoard.AddUser(ctx, user)
+}
+
+func (lb *FakeReviewLeaderBoard) Reviews(_ context.Context) ([]leaderboard.Review, error) {
+	return lb.reviews, nil
+}
+
+func (lb *FakeReviewLeaderBoard) ApproveReview(ctx context.Context, id string) error {
+	for i, review := range lb.reviews {
+		if review.ID == id {
+			lb.reviews = append(lb.reviews[:i], lb.reviews[i+1:]...)
+			return lb.FakeLeaderBoard.AddUser(ctx, review.User)
+		}
+	}
+	return leaderboard.ErrorWithStatusCode(errors.New("not exists review: "+id), http.StatusNotFound)
+}
+
+func (lb *FakeReviewLeaderBoard) DiscardReview(_ context.Context, id string) (bool, error) {
+	for i, review := range lb.reviews {
+		if review.ID == id {
+			lb.reviews = append(lb.reviews[:i], lb.reviews[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestReviews(t *testing.T) {
+	// Setup
+	e := echo.New()
+	lb := &FakeReviewLeaderBoard{
+		FakeLeaderBoard: FakeLeaderBoard{UserSet: *sortedset.New()},
+		MaxScore:        1000,
+	}
+	h := &Handler{lb}
+
+	// AddUser - 검토 대기
+	for _, userJSON := range []string{`{"name": "Alice", "score": 5000}`, `{"name": "Bob", "score": 9000}`} {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(userJSON))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if assert.NoError(t, h.AddUser(c)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+		}
+	}
+
+	// GetReviews
+	req := httptest.NewRequest(http.MethodGet, "/admin/reviews", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.GetReviews(c)) {
+		const reviewsJSON = `[
+			{"id": "1", "user": {"name": "Alice", "score": 5000}, "update": false, "board": "users", "reason": "score exceeds 1000", "created_at": "2022-07-01T12:00:00Z"},
+			{"id": "2", "user": {"name": "Bob", "score": 9000}, "update": false, "board": "users", "reason": "score exceeds 1000", "created_at": "2022-07-01T12:00:00Z"}
+		]`
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, reviewsJSON, rec.Body.String())
+	}
+
+	// ApproveReview
+	req = httptest.NewRequest(http.MethodPost, "/admin/reviews/:id/approve", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	if assert.NoError(t, h.ApproveReview(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"message": "approved: 1"}`, rec.Body.String())
+		assert.NotNil(t, lb.UserSet.GetByKey("Alice"))
+	}
+
+	// ApproveReview - 없는 review
+	req = httptest.NewRequest(http.MethodPost, "/admin/reviews/:id/approve", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	if assert.NoError(t, h.ApproveReview(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		require.JSONEq(t, `{"message": "not exists review: 1"}`, rec.Body.String())
+	}
+
+	// DiscardReview
+	req = httptest.NewRequest(http.MethodDelete, "/admin/reviews/:id", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("2")
+	if assert.NoError(t, h.DiscardReview(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"id": "2", "is_discarded": true}`, rec.Body.String())
+		assert.Nil(t, lb.UserSet.GetByKey("Bob"))
+		assert.Empty(t, lb.reviews)
+	}
+}
+
+func TestReviewsNotSupported(t *testing.T) {
+	e := echo.New()
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/reviews", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.GetReviews(c)) {
+		const errorJSON = `{"message": "reviews are not supported"}`
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+		require.JSONEq(t, errorJSON, rec.Body.String())
+	}
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// segment query param이 있으면 segment board를 읽을 수 있는 SegmentInterface를 반환합니다.
+func (h *Handler) segmentParam(c echo.Context) (leaderboard.SegmentInterface, leaderboard.Segment, bool, error) {
+	param := c.QueryParam("segment")
+	if param == "" {
+		return nil, leaderboard.Segment{}, false, nil
+	}
+	segment, err := leaderboard.ParseSegment(param)
+	if err != nil {
+		err := leaderboard.ErrorWithStatusCode(errors.New("invalid segment: "+param), http.StatusBadRequest)
+		return nil, leaderboard.Segment{}, false, err
+	}
+	segments, ok := h.Leaderboard.(leaderboard.SegmentInterface)
+	if !ok {
+		err := leaderboard.ErrorWithStatusCode(errors.New("segments are not supported"), http.StatusNotImplemented)
+		return nil, leaderboard.Segment{}, false, err
+	}
+	return segments, segment, true, nil
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+type FakeSegmentLeaderBoard struct {
+	FakeLeaderBoard
+	SegmentSets map[string]*FakeLeaderBoard
+}
+
+func (lb *FakeSegmentLeaderBoard) segmentSet(segment leaderboard.Segment) *FakeLeaderBoard {
+	if set, ok := lb.SegmentSets[segment.String()]; ok {
+		return set
+	}
+	return &FakeLeaderBoard{UserSet: *sortedset.New()}
+}
+
+func (lb *FakeSegmentLeaderBoard) SegmentUserCount(ctx context.Context, segment leaderboard.Segment) (int64, error) {
+	return lb.segmentSet(segment).UserCount(ctx)
+}
+
+func (lb *FakeSegmentLeaderBoard) GetSegmentUser(ctx context.Context, segment leaderboard.Segment, name string) (*leaderboard.UserRank, error) {
+	return lb.segmentSet(segment).GetUser(ctx, name)
+}
+
+func (lb *FakeSegmentLeaderBoard) GetSegmentUserList(ctx context.Context, segment leaderboard.Segment, start int64, stop int64) ([]leaderboard.User, error) {
+	return lb.segmentSet(segment).GetUserList(ctx, start, stop)
+}
+
+func newFakeSegmentLeaderBoard() *FakeSegmentLeaderBoard {
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Yumi", 500, nil)
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	sortedSet.AddOrUpdate("Foo", 200, nil)
+	krSet := sortedset.New()
+	krSet.AddOrUpdate("Minsik", 100, nil)
+	krSet.AddOrUpdate("Foo", 200, nil)
+	return &FakeSegmentLeaderBoard{
+		FakeLeaderBoard: FakeLeaderBoard{
+			UserSet: *sortedSet,
+		},
+		SegmentSets: map[string]*FakeLeaderBoard{
+			"country:KR": {UserSet: *krSet},
+		},
+	}
+}
+
+func TestSegmentUserCount(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &Handler{newFakeSegmentLeaderBoard()}
+
+	// GetUserCount - segment
+	req := httptest.NewRequest(http.MethodGet, "/users/count?segment=country:KR", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.GetUserCount(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"count": 2}`, rec.Body.String())
+	}
+
+	// GetUserCount - invalid segment
+	req2 := httptest.NewRequest(http.MethodGet, "/users/count?segment=country", nil)
+	rec2 := httptest.NewRecorder()
+	c2 := e.NewContext(req2, rec2)
+	if assert.NoError(t, h.GetUserCount(c2)) {
+		const errorJSON = `{"message": "invalid segment: country"}`
+		assert.Equal(t, http.StatusBadRequest, rec2.Code)
+		require.JSONEq(t, errorJSON, rec2.Body.String())
+	}
+
+	// GetUserCount - not supported
+	h2 := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+	req3 := httptest.NewRequest(http.MethodGet, "/users/count?segment=country:KR", nil)
+	rec3 := httptest.NewRecorder()
+	c3 := e.NewContext(req3, rec3)
+	if assert.NoError(t, h2.GetUserCount(c3)) {
+		const errorJSON = `{"message": "segments are not supported"}`
+		assert.Equal(t, http.StatusNotImplemented, rec3.Code)
+		require.JSONEq(t, errorJSON, rec3.Body.String())
+	}
+}
+
+func TestGetSegmentUser(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &Handler{newFakeSegmentLeaderBoard()}
+
+	// GetUser - segment
+	req := httptest.NewRequest(http.MethodGet, "/users?name=Foo&segment=country:KR", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.GetUser(c)) {
+		const userJSON = `{"name": "Foo", "score": 200, "rank": 0}`
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, userJSON, rec.Body.String())
+	}
+
+	// GetUser - not in segment
+	req2 := httptest.NewRequest(http.MethodGet, "/users?name=Yumi&segment=country:KR", nil)
+	rec2 := httptest.NewRecorder()
+	c2 := e.NewContext(req2, rec2)
+	if assert.NoError(t, h.GetUser(c2)) {
+		assert.Equal(t, http.StatusNotFound, rec2.Code)
+	}
+}
+
+func TestSegmentUserList(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &Handler{newFakeSegmentLeaderBoard()}
+
+	// GetUserList - segment
+	req := httptest.NewRequest(http.MethodGet, "/users/:start/to/:stop?segment=country:KR", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("start", "stop")
+	c.SetParamValues("0", "9")
+	if assert.NoError(t, h.GetUserList(c)) {
+		const userListJSON = `[
+			{"name": "Foo", "score": 200},
+			{"name": "Minsik", "score": 100}
+		]`
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, userListJSON, rec.Body.String())
+	}
+}
+
+func (lb *FakeSegmentLeaderBoard) GetSegmentUserPage(ctx context.Context, segment leaderboard.Segment, cursor string, limit int64) (*leaderboard.UserPage, error) {
+	return (&FakePageLeaderBoard{*lb.segmentSet(segment)}).GetUserPage(ctx, cursor, limit)
+}
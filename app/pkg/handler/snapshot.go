@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+type deleteSnapshotData struct {
+	ID        string `json:"id"`
+	IsDeleted bool   `json:"is_deleted"`
+}
+
+func (h *Handler) snapshots() (leaderboard.SnapshotInterface, error) {
+	snapshots, ok := h.Leaderboard.(leaderboard.SnapshotInterface)
+	if !ok {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("snapshots are not supported"), http.StatusNotImplemented)
+	}
+	return snapshots, nil
+}
+
+func (h *Handler) movers() (leaderboard.MoversInterface, error) {
+	movers, ok := h.Leaderboard.(leaderboard.MoversInterface)
+	if !ok {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("snapshots are not supported"), http.StatusNotImplemented)
+	}
+	return movers, nil
+}
+
+// @Summary     Create a snapshot
+// @Description 전체 board를 복사해 snapshot을 만듭니다. SNAPSHOT_RETENTION(기본 24)개를 넘는 오래된 snapshot은 지웁니다.
+// @Tags        Admin
+// @Produce     json
+// @Success     201 {object} leaderboard.Snapshot
+// @Failure     400 {object} messageData "같은 초에 만든 snapshot 있음"
+// @Failure     500 {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /admin/snapshots [post]
+func (h *Handler) CreateSnapshot(c echo.Context) error {
+	ctx := requestContext(c)
+	snapshots, err := h.snapshots()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	snapshot, err := snapshots.CreateSnapshot(ctx)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusCreated, snapshot)
+}
+
+// @Summary     Get snapshot list
+// @Description 최신 snapshot부터 반환합니다.
+// @Tags        Admin
+// @Produce     json
+// @Success     200 {array}  leaderboard.Snapshot
+// @Failure     500 {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /admin/snapshots [get]
+func (h *Handler) GetSnapshots(c echo.Context) error {
+	ctx := requestContext(c)
+	snapshots, err := h.snapshots()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	snapshotList, err := snapshots.Snapshots(ctx)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, snapshotList)
+}
+
+// @Summary     Restore a snapshot
+// @Description 전체 board를 snapshot 시점의 점수로 되돌립니다. group 소속과 segment 속성은 현재 상태를 유지합니다.
+// @Tags        Admin
+// @Produce     json
+// @Param       id  path     string true "Snapshot ID"
+// @Success     200 {object} messageData
+// @Failure     404 {object} messageData "없는 snapshot"
+// @Failure     500 {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /admin/snapshots/{id}/restore [post]
+func (h *Handler) RestoreSnapshot(c echo.Context) error {
+	ctx := requestContext(c)
+	snapshots, err := h.snapshots()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	id := c.Param("id")
+	if err := snapshots.RestoreSnapshot(ctx, id); err != nil {
+		return errorJSON(c, err)
+	}
+	return messageJSON(c, http.StatusOK, "restored: "+id)
+}
+
+// @Summary     Delete a snapshot
+// @Tags        Admin
+// @Produce     json
+// @Param       id  path     string true "Snapshot ID"
+// @Success     200 {object} deleteSnapshotData
+// @Failure     500 {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /admin/snapshots/{id} [delete]
+func (h *Handler) DeleteSnapshot(c echo.Context) error {
+	ctx := requestContext(c)
+	snapshots, err := h.snapshots()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	id := c.Param("id")
+	ok, err := snapshots.DeleteSnapshot(ctx, id)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, deleteSnapshotData{id, ok})
+}
+
+// @Summary     Get rank movers since a snapshot
+// @Description snapshot 이후 순위가 많이 오른 user, 많이 내려간 user, 새로 추가된 user, 삭제된 user를 반환합니다.
+// @Description 두 board를 모두 읽어서 비교하므로 user 수가 많으면 느릴 수 있습니다.
+// @Tags        Admin
+// @Produce     json
+// @Param       id    path     string true  "Snapshot ID"
+// @Param       limit query    int    false "목록별 최대 user 수 (기본 10, 최대 100)"
+// @Success     200   {object} leaderboard.Movers
+// @Failure     400   {object} messageData "limit 확인 필요"
+// @Failure     404   {object} messageData "없는 snapshot"
+// @Failure     500   {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /admin/snapshots/{id}/movers [get]
+func (h *Handler) GetMovers(c echo.Context) error {
+	ctx := requestContext(c)
+	movers, err := h.movers()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	limit := int64(leaderboard.DefaultMoversSize)
+	if param := c.QueryParam("limit"); param != "" {
+		parsed, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return messageJSON(c, http.StatusBadRequest, "invalid limit")
+		}
+		limit = parsed
+	}
+	result, err := movers.GetMovers(ctx, c.Param("id"), limit)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return responseJSON(c, http.StatusOK, result)
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+// snapshot을 sortedset 복사본으로 들고 있는 fake
+type FakeSnapshotLeaderBoard struct {
+	FakeLeaderBoard
+	snapshots []leaderboard.Snapshot
+	copies    map[string]*sortedset.SortedSet
+}
+
+func copySortedSet(set *sortedset.SortedSet) *sortedset.SortedSet {
+	copied := sortedset.New()
+	for _, node := range set.GetByRankRange(1, -1, false) {
+		copied.AddOrUpdate(node.Key(), node.Score(), nil)
+	}
+	return copied
+}
+
+func (lb *FakeSnapshotLeaderBoard) CreateSnapshot(_ context.Context) (*leaderboard.Snapshot, error) {
+	createdAt := time.Date(2022, 7, 1, len(lb.snapshots), 0, 0, 0, time.UTC)
+	snapshot := leaderboard.Snapshot{
+		ID:        createdAt.Format("20060102T150405Z"),
+		CreatedAt: createdAt,
+		Count:     int64(lb.UserSet.GetCount()),
+	}
+	lb.snapshots = append([]leaderboard.Snapshot{snapshot}, lb.snapshots...)
+	lb.copies[snapshot.ID] = copySortedSet(&lb.UserSet)
+	return &snapshot, nil
+}
+
+func (lb *FakeSnapshotLeaderBoard) Snapshots(_ context.Context) ([]leaderboard.Snapshot, error) {
+	return lb.snapshots, nil
+}
+
+func (lb *FakeSnapshotLeaderBoard) RestoreSnapshot(_ context.Context, id string) error {
+	copied, ok := lb.copies[id]
+	if !ok {
+		return leaderboard.ErrorWithStatusCode(errors.New("not exists snapshot: "+id), http.StatusNotFound)
+	}
+	lb.UserSet = *copySortedSet(copied)
+	return nil
+}
+
+func (lb *FakeSnapshotLeaderBoard) DeleteSnapshot(_ context.Context, id string) (bool, error) {
+	if _, ok := lb.copies[id]; !ok {
+		return false, nil
+	}
+	delete(lb.copies, id)
+	for i, snapshot := range lb.snapshots {
+		if snapshot.ID == id {
+			lb.snapshots = append(lb.snapshots[:i], lb.snapshots[i+1:]...)
+			break
+		}
+	}
+	return true, nil
+}
+
+// snapshot 이후 점수가 바뀐 user만 climbers, fallers로 돌려주는 fake
+func (lb *FakeSnapshotLeaderBoard) GetMovers(ctx context.Context, id string, limit int64) (*leaderboard.Movers, error) {
+	if limit <= 0 {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("invalid limit"), http.StatusBadRequest)
+	}
+	copied, ok := lb.copies[id]
+	if !ok {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("not exists snapshot: "+id), http.StatusNotFound)
+	}
+	movers := &leaderboard.Movers{
+		Climbers:     []leaderboard.UserMove{},
+		Fallers:      []leaderboard.UserMove{},
+		NewUsers:     []leaderboard.UserRank{},
+		DroppedUsers: []leaderboard.UserRank{},
+	}
+	for _, node := range copied.GetByRankRange(1, -1, false) {
+		prev, _ := (&FakeLeaderBoard{UserSet: *copied}).GetUser(ctx, node.Key())
+		current, err := lb.GetUser(ctx, node.Key())
+		if err != nil {
+			continue
+		}
+		move := leaderboard.UserMove{
+			Name:       current.Name,
+			Rank:       current.Rank,
+			PrevRank:   prev.Rank,
+			Score:      current.Score,
+			PrevScore:  prev.Score,
+			RankDelta:  prev.Rank - current.Rank,
+			ScoreDelta: current.Score - prev.Score,
+		}
+		if move.RankDelta > 0 {
+			movers.Climbers = append(movers.Climbers, move)
+		} else if move.RankDelta < 0 {
+			movers.Fallers = append(movers.Fallers, move)
+		}
+	}
+	return movers, nil
+}
+
+func TestSnapshots(t *testing.T) {
+	// Setup
+	e := echo.New()
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	sortedSet.AddOrUpdate("Yumi", 200, nil)
+	lb := &FakeSnapshotLeaderBoard{
+		FakeLeaderBoard: FakeLeaderBoard{UserSet: *sortedSet},
+		copies:          map[string]*sortedset.SortedSet{},
+	}
+	h := &Handler{lb}
+
+	// CreateSnapshot
+	req := httptest.NewRequest(http.MethodPost, "/admin/snapshots", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.CreateSnapshot(c)) {
+		const snapshotJSON = `{"id": "20220701T000000Z", "created_at": "2022-07-01T00:00:00Z", "count": 2}`
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		require.JSONEq(t, snapshotJSON, rec.Body.String())
+	}
+
+	// GetSnapshots
+	req = httptest.NewRequest(http.MethodGet, "/admin/snapshots", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, h.GetSnapshots(c)) {
+		const snapshotsJSON = `[{"id": "20220701T000000Z", "created_at": "2022-07-01T00:00:00Z", "count": 2}]`
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, snapshotsJSON, rec.Body.String())
+	}
+
+	// GetMovers
+	lb.UserSet.AddOrUpdate("Minsik", 300, nil)
+	req = httptest.NewRequest(http.MethodGet, "/admin/snapshots/:id/movers?limit=5", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("20220701T000000Z")
+	if assert.NoError(t, h.GetMovers(c)) {
+		const moversJSON = `{
+			"snapshot": {"id": "", "created_at": "0001-01-01T00:00:00Z", "count": 0},
+			"climbers": [{"name": "Minsik", "rank": 0, "prev_rank": 1, "score": 300, "prev_score": 100, "rank_delta": 1, "score_delta": 200}],
+			"fallers": [{"name": "Yumi", "rank": 1, "prev_rank": 0, "score": 200, "prev_score": 200, "rank_delta": -1, "score_delta": 0}],
+			"new_users": [], "new_user_count": 0, "dropped_users": [], "dropped_user_count": 0
+		}`
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, moversJSON, rec.Body.String())
+	}
+
+	// GetMovers - 잘못된 limit
+	req = httptest.NewRequest(http.MethodGet, "/admin/snapshots/:id/movers?limit=abc", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("20220701T000000Z")
+	if assert.NoError(t, h.GetMovers(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.JSONEq(t, `{"message": "invalid limit"}`, rec.Body.String())
+	}
+
+	// RestoreSnapshot
+	lb.UserSet.Remove("Yumi")
+	req = httptest.NewRequest(http.MethodPost, "/admin/snapshots/:id/restore", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("20220701T000000Z")
+	if assert.NoError(t, h.RestoreSnapshot(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"message": "restored: 20220701T000000Z"}`, rec.Body.String())
+		assert.NotNil(t, lb.UserSet.GetByKey("Yumi"))
+	}
+
+	// RestoreSnapshot - 없는 snapshot
+	req = httptest.NewRequest(http.MethodPost, "/admin/snapshots/:id/restore", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("20000101T000000Z")
+	if assert.NoError(t, h.RestoreSnapshot(c)) {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		require.JSONEq(t, `{"message": "not exists snapshot: 20000101T000000Z"}`, rec.Body.String())
+	}
+
+	// DeleteSnapshot
+	req = httptest.NewRequest(http.MethodDelete, "/admin/snapshots/:id", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("20220701T000000Z")
+	if assert.NoError(t, h.DeleteSnapshot(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"id": "20220701T000000Z", "is_deleted": true}`, rec.Body.String())
+		assert.Empty(t, lb.snapshots)
+	}
+}
+
+func TestSnapshotsNotSupported(t *testing.T) {
+	e := echo.New()
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/snapshots", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.GetSnapshots(c)) {
+		const errorJSON = `{"message": "snapshots are not supported"}`
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+		require.JSONEq(t, errorJSON, rec.Body.String())
+	}
+}
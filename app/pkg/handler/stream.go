@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultStreamTop = 10
+	// proxy가 idle 연결을 끊지 않도록 주기적으로 comment를 보냄
+	heartbeatInterval = 15 * time.Second
+)
+
+func (h *Handler) subscriber() (leaderboard.SubscribeInterface, error) {
+	subscriber, ok := h.Leaderboard.(leaderboard.SubscribeInterface)
+	if !ok {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("streaming is not supported"), http.StatusNotImplemented)
+	}
+	return subscriber, nil
+}
+
+// top, watch query param을 해석합니다. 둘 다 최대 MaxPageSize 까지 허용합니다.
+func watchParams(c echo.Context) (int64, []string, error) {
+	top := int64(defaultStreamTop)
+	if param := c.QueryParam("top"); param != "" {
+		parsed, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || parsed < 0 || parsed > leaderboard.MaxPageSize {
+			return 0, nil, leaderboard.ErrorWithStatusCode(errors.New("invalid top"), http.StatusBadRequest)
+		}
+		top = parsed
+	}
+	var names []string
+	if param := c.QueryParam("watch"); param != "" {
+		names = strings.Split(param, ",")
+		if len(names) > leaderboard.MaxPageSize {
+			return 0, nil, leaderboard.ErrorWithStatusCode(errors.New("too many watched users"), http.StatusBadRequest)
+		}
+	}
+	return top, names, nil
+}
+
+func writeEvent(c echo.Context, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+	if _, err := fmt.Fprintf(c.Response(), "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return errors.Wrap(err, "fmt.Fprintf")
+	}
+	c.Response().Flush()
+	return nil
+}
+
+func writeRankUpdate(c echo.Context, update *leaderboard.RankUpdate) error {
+	if update == nil {
+		return nil
+	}
+	if update.Top != nil {
+		if err := writeEvent(c, "top", update.Top); err != nil {
+			return err
+		}
+	}
+	for _, userRank := range update.Ranks {
+		if err := writeEvent(c, "rank", userRank); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// @Summary     Stream rank changes
+// @Description Server-Sent Events로 top N과 watch 대상 user의 순위 변화를 받아옵니다.
+// @Description 연결 직후 현재 상태를 보내고, 이후에는 바뀐 부분만 보냅니다.
+// @Description top N은 GET /users/{start}/to/{stop}과 같이 0위부터 N-1위(점수가 낮은 순)입니다.
+// @Description event: top (data: user list), rank (data: user rank, 없는 user는 rank -1), error
+// @Tags        Users
+// @Produce     text/event-stream
+// @Param       top   query    int    false "top N (기본 10, 최대 100, 0이면 top을 보내지 않음)"
+// @Param       watch query    string false "순위를 받을 user name 목록 (쉼표로 구분)"
+// @Success     200   {string} string "event stream"
+// @Failure     400   {object} messageData "param 확인 필요"
+// @Failure     500   {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /users/stream [get]
+func (h *Handler) StreamRanks(c echo.Context) error {
+	ctx := c.Request().Context()
+	top, names, err := watchParams(c)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	subscriber, err := h.subscriber()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	// 구독을 먼저 시작해야 첫 상태를 읽는 사이의 변경을 놓치지 않음
+	changes, err := subscriber.Subscribe(ctx)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	watcher := leaderboard.NewRankWatcher(h.Leaderboard, top, names)
+	update, err := watcher.Poll(ctx)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+	c.Response().Header().Set(echo.HeaderConnection, "keep-alive")
+	c.Response().WriteHeader(http.StatusOK)
+	if err := writeRankUpdate(c, update); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-changes:
+			if !ok {
+				return nil
+			}
+			update, err := watcher.Poll(ctx)
+			if err != nil {
+				return writeEvent(c, "error", messageData{Message: err.Error()})
+			}
+			if err := writeRankUpdate(c, update); err != nil {
+				return err
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Response(), ": ping\n\n"); err != nil {
+				return errors.Wrap(err, "fmt.Fprint")
+			}
+			c.Response().Flush()
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+type FakeStreamLeaderBoard struct {
+	FakeLeaderBoard
+	Changes chan leaderboard.Change
+	mu      sync.Mutex
+}
+
+func (lb *FakeStreamLeaderBoard) GetUser(ctx context.Context, name string) (*leaderboard.UserRank, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.FakeLeaderBoard.GetUser(ctx, name)
+}
+
+func (lb *FakeStreamLeaderBoard) GetUserList(ctx context.Context, start int64, stop int64) ([]leaderboard.User, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.FakeLeaderBoard.GetUserList(ctx, start, stop)
+}
+
+func (lb *FakeStreamLeaderBoard) setScore(name string, score float64) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.UserSet.AddOrUpdate(name, sortedset.SCORE(score), nil)
+}
+
+func (lb *FakeStreamLeaderBoard) Subscribe(_ context.Context) (<-chan leaderboard.Change, error) {
+	return lb.Changes, nil
+}
+
+func TestStreamRanks(t *testing.T) {
+	// Setup
+	e := echo.New()
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Yumi", 500, nil)
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	lb := &FakeStreamLeaderBoard{
+		FakeLeaderBoard: FakeLeaderBoard{
+			UserSet: *sortedSet,
+		},
+		Changes: make(chan leaderboard.Change),
+	}
+	h := &Handler{lb}
+
+	// StreamRanks
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/users/stream?top=1&watch=Minsik", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	done := make(chan error)
+	go func() {
+		done <- h.StreamRanks(c)
+	}()
+
+	// 알림을 받았다면 앞선 알림의 처리는 끝난 상태
+	change := leaderboard.Change{Name: "Minsik", Score: 1000}
+	lb.Changes <- change
+	lb.setScore("Minsik", 1000)
+	lb.Changes <- change
+	lb.Changes <- change
+	cancel()
+	require.NoError(t, <-done)
+
+	const expected = "event: top\ndata: [{\"name\":\"Minsik\",\"score\":100}]\n\n" +
+		"event: rank\ndata: {\"name\":\"Minsik\",\"score\":100,\"rank\":1}\n\n" +
+		"event: top\ndata: [{\"name\":\"Yumi\",\"score\":500}]\n\n" +
+		"event: rank\ndata: {\"name\":\"Minsik\",\"score\":1000,\"rank\":0}\n\n"
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, expected, rec.Body.String())
+}
+
+func TestStreamRanksError(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+
+	// StreamRanks - invalid top
+	req := httptest.NewRequest(http.MethodGet, "/users/stream?top=1000", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.StreamRanks(c)) {
+		const errorJSON = `{"message": "invalid top"}`
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.JSONEq(t, errorJSON, rec.Body.String())
+	}
+
+	// StreamRanks - not supported
+	req2 := httptest.NewRequest(http.MethodGet, "/users/stream", nil)
+	rec2 := httptest.NewRecorder()
+	c2 := e.NewContext(req2, rec2)
+	if assert.NoError(t, h.StreamRanks(c2)) {
+		const errorJSON = `{"message": "streaming is not supported"}`
+		assert.Equal(t, http.StatusNotImplemented, rec2.Code)
+		require.JSONEq(t, errorJSON, rec2.Body.String())
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+)
+
+// 서명한 점수 기록 요청의 body. user 정보와 서명 값을 함께 보냅니다.
+type signedUser struct {
+	leaderboard.User
+	auth.Submission
+}
+
+// Submissions가 있으면 점수 기록 요청의 서명을 확인합니다. API key로 인증한 요청(서버)은 확인하지 않음
+// Require 뒤에 등록해야 합니다.
+func (a *Auth) RequireSignature() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if a == nil || a.Submissions == nil || c.Get(authKeyContext) != nil {
+				return next(c)
+			}
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return messageJSON(c, http.StatusBadRequest, "invalid body: user info")
+			}
+			user := signedUser{}
+			if err := json.Unmarshal(body, &user); err != nil {
+				return messageJSON(c, http.StatusBadRequest, "invalid body: user info")
+			}
+			if err := a.Submissions.Verify(c.Request().Context(), requestBoard(c), user.User, user.Submission); err != nil {
+				return errorJSON(c, err)
+			}
+			// handler가 다시 읽을 수 있도록 body를 되돌림
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+			return next(c)
+		}
+	}
+}
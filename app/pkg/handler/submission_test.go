@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/auth"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+type FakeNonceStore map[string]bool
+
+func (s FakeNonceStore) UseNonce(_ context.Context, game string, nonce string, _ time.Duration) (bool, error) {
+	if s[game+":"+nonce] {
+		return false, nil
+	}
+	s[game+":"+nonce] = true
+	return true, nil
+}
+
+func TestRequireSignature(t *testing.T) {
+	// Setup
+	e := echo.New()
+	submissions, err := auth.NewSubmissionVerifier(map[string]string{"game-1": "secret"}, FakeNonceStore{}, time.Minute)
+	require.NoError(t, err)
+	authn := &Auth{Keys: FakeKeyStore{
+		"game-server": {ID: "game-server", Scopes: []auth.Scope{auth.ScopeSubmit}},
+	}, Submissions: submissions}
+	authnPlayers := &Auth{Submissions: submissions}
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+	e.POST("/users", h.AddUser, authn.Require(auth.ScopeSubmit), authn.RequireSignature())
+	e.POST("/players", h.AddUser, authnPlayers.RequireSignature())
+
+	timestamp := time.Now().Unix()
+	signed := func(name string, score float64, nonce string) string {
+		signature := auth.SignSubmission("secret", "users", name, score, nil, timestamp, nonce)
+		return fmt.Sprintf(`{"name": %q, "score": %v, "game": "game-1", "nonce": %q, "timestamp": %d, "signature": %q}`, name, score, nonce, timestamp, signature)
+	}
+
+	testCases := []struct {
+		target string
+		key    string
+		body   string
+		code   int
+		result string
+	}{
+		{"/players", "", signed("Alice", 10, "n1"), http.StatusCreated, `{"name": "Alice", "score": 10, "rank": 0}`},
+		{"/players", "", signed("Alice", 10, "n1"), http.StatusConflict, `{"message": "nonce already used: n1"}`},
+		{"/players", "", strings.Replace(signed("Bob", 20, "n2"), `"score": 20`, `"score": 99999`, 1), http.StatusUnauthorized, `{"message": "invalid signature"}`},
+		{"/players", "", strings.Replace(signed("Bob", 20, "n3"), `"score": 20`, `"score": 20, "segments": {"country": "KR"}`, 1), http.StatusUnauthorized, `{"message": "invalid signature"}`},
+		{"/players", "", `{"name": "Bob", "score": 20}`, http.StatusUnauthorized, `{"message": "signature is required"}`},
+		{"/players", "", `{"name": "Bo`, http.StatusBadRequest, `{"message": "invalid body: user info"}`},
+		// API key로 인증한 서버는 서명하지 않아도 됨
+		{"/users", "game-server", `{"name": "Carol", "score": 5}`, http.StatusCreated, `{"name": "Carol", "score": 5, "rank": 1}`},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if tc.key != "" {
+			req.Header.Set(APIKeyHeader, tc.key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.body)
+		require.JSONEq(t, tc.result, rec.Body.String())
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/JeongMinSik/go-leaderboard/pkg/logger"
+	"github.com/JeongMinSik/go-leaderboard/pkg/tracing"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 요청마다 "<method> <route>" server span을 만듭니다. traceparent header가 있으면 그 trace를 이어받음
+func Tracing() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			ctx, span := tracing.Start(ctx, req.Method+" "+c.Path(), trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", c.Path(), req)...))
+			defer span.End()
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+			status := responseStatus(c, err)
+			span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, trace.SpanKindServer))
+			if route := requestRoute(c, err); route != c.Path() {
+				span.SetName(req.Method + " " + route)
+			}
+			if err != nil {
+				span.RecordError(err)
+			}
+			return err
+		}
+	}
+}
+
+// 요청의 span과 요청 ID, player token의 subject를 이어받지만, client가 연결을 끊어도 기록은 끝까지 처리하도록 요청의 취소는 이어받지 않습니다.
+func requestContext(c echo.Context) context.Context {
+	ctx := c.Request().Context()
+	detached := logger.WithRequestID(tracing.Detach(ctx), logger.RequestID(ctx))
+	if subject, ok := c.Get(authPlayerContext).(string); ok {
+		detached = leaderboard.WithPlayer(detached, subject)
+	}
+	return detached
+}
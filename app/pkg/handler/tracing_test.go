@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/wangjia184/sortedset"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	// Setup
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	e := echo.New()
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedSet,
+	}}
+	e.Use(Tracing())
+	e.GET("/users/:start/to/:stop", h.GetUserList)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/0/to/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/9/to/0", nil))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/not-exists", nil))
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 3) {
+		// traceparent의 trace를 이어받음
+		assert.Equal(t, "GET /users/:start/to/:stop", spans[0].Name())
+		assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+		assert.Contains(t, spans[0].Attributes(), semconv.HTTPStatusCodeKey.Int(http.StatusOK))
+		assert.Contains(t, spans[0].Attributes(), semconv.HTTPRouteKey.String("/users/:start/to/:stop"))
+
+		// server span은 4xx를 실패로 표시하지 않음
+		assert.False(t, spans[1].Parent().IsValid())
+		assert.Contains(t, spans[1].Attributes(), semconv.HTTPStatusCodeKey.Int(http.StatusBadRequest))
+		assert.Equal(t, codes.Unset, spans[1].Status().Code)
+
+		assert.Equal(t, "GET unmatched", spans[2].Name())
+		assert.Contains(t, spans[2].Attributes(), semconv.HTTPStatusCodeKey.Int(http.StatusNotFound))
+	}
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+var exportContentTypes = map[string]string{
+	leaderboard.FormatCSV:   "text/csv; charset=utf-8",
+	leaderboard.FormatJSONL: "application/x-ndjson",
+	leaderboard.FormatJSON:  echo.MIMEApplicationJSONCharsetUTF8,
+}
+
+// 중간에 실패한 import. 실패하기 전까지 저장한 결과를 함께 반환
+type importErrorData struct {
+	messageData
+	Result *leaderboard.ImportResult `json:"result"`
+}
+
+func (h *Handler) transfers() (leaderboard.TransferInterface, error) {
+	transfers, ok := h.Leaderboard.(leaderboard.TransferInterface)
+	if !ok {
+		return nil, leaderboard.ErrorWithStatusCode(errors.New("import and export are not supported"), http.StatusNotImplemented)
+	}
+	return transfers, nil
+}
+
+func formatParam(c echo.Context) (string, error) {
+	format := c.QueryParam("format")
+	if format == "" {
+		return leaderboard.FormatJSON, nil
+	}
+	if _, ok := exportContentTypes[format]; !ok {
+		return "", leaderboard.ErrorWithStatusCode(errors.New("invalid format: "+format), http.StatusBadRequest)
+	}
+	return format, nil
+}
+
+// @Summary     Export users
+// @Description 전체 user를 순위 순서대로 내려받습니다. 서버는 500명씩 나눠 읽어 바로 보냅니다.
+// @Description csv는 name, score와 SEGMENT_ATTRIBUTES의 segment column을 가집니다.
+// @Tags        Users
+// @Produce     json
+// @Produce     text/csv
+// @Produce     application/x-ndjson
+// @Param       format query    string false "csv, jsonl, json (기본 json)"
+// @Success     200    {array}  leaderboard.User
+// @Failure     400    {object} messageData "format 확인 필요"
+// @Failure     500    {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /users/export [get]
+func (h *Handler) ExportUsers(c echo.Context) error {
+	ctx := c.Request().Context()
+	format, err := formatParam(c)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	transfers, err := h.transfers()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	encoder, err := leaderboard.NewUserEncoder(c.Response(), format, transfers.SegmentAttributes())
+	if err != nil {
+		return errorJSON(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, exportContentTypes[format])
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=users."+format)
+	c.Response().WriteHeader(http.StatusOK)
+	// 응답을 시작한 뒤에는 status를 바꿀 수 없으므로 에러는 로그로만 남김
+	err = transfers.ExportUsers(ctx, func(users []leaderboard.User) error {
+		for _, user := range users {
+			if err := encoder.Encode(user); err != nil {
+				return err
+			}
+		}
+		c.Response().Flush()
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "transfers.ExportUsers")
+	}
+	return errors.Wrap(encoder.Close(), "encoder.Close")
+}
+
+// @Summary     Import users
+// @Description csv, jsonl, json 파일의 user를 500명씩 나눠 추가 또는 수정합니다.
+// @Description 잘못된 record, 차단된 user, 점수 규칙을 어긴 record는 건너뛰고 결과에 기록합니다(최대 100개). dry_run이면 검사만 합니다.
+// @Description csv는 name, score column이 필요하며 나머지 column은 segment 속성으로 읽습니다.
+// @Description 파일을 읽다가 중간에 실패하면 그 전까지 저장한 결과를 result에 담아 반환합니다.
+// @Tags        Users
+// @accept      json
+// @accept      text/csv
+// @accept      application/x-ndjson
+// @Produce     json
+// @Param       format  query    string false "csv, jsonl, json (기본 json)"
+// @Param       dry_run query    bool   false "true면 저장하지 않고 검사만 함"
+// @Param       users   body     string true  "user 파일"
+// @Success     200     {object} leaderboard.ImportResult
+// @Failure     400     {object} importErrorData "format, 파일 확인 필요"
+// @Failure     500     {object} importErrorData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /users/import [post]
+func (h *Handler) ImportUsers(c echo.Context) error {
+	ctx := requestContext(c)
+	format, err := formatParam(c)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	dryRun := false
+	if param := c.QueryParam("dry_run"); param != "" {
+		if dryRun, err = strconv.ParseBool(param); err != nil {
+			return messageJSON(c, http.StatusBadRequest, "invalid dry_run")
+		}
+	}
+	transfers, err := h.transfers()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	decoder, err := leaderboard.NewUserDecoder(c.Request().Body, format)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	result, err := transfers.ImportUsers(ctx, decoder, dryRun)
+	if err != nil {
+		if result == nil {
+			return errorJSON(c, err)
+		}
+		statusCode, data := errorData(c, err)
+		return responseJSON(c, statusCode, importErrorData{messageData: data, Result: result})
+	}
+	return responseJSON(c, http.StatusOK, result)
+}
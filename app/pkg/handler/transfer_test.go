@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+// 점수 오름차순으로 내보내고 AddUser, UpdateUser로 가져오는 fake
+type FakeTransferLeaderBoard struct {
+	FakeLeaderBoard
+}
+
+func (lb *FakeTransferLeaderBoard) ExportUsers(_ context.Context, fn func([]leaderboard.User) error) error {
+	users := []leaderboard.User{}
+	for _, node := range lb.UserSet.GetByScoreRange(sortedset.SCORE(-1<<62), sortedset.SCORE(1<<62), nil) {
+		users = append(users, leaderboard.User{Name: node.Key(), Score: float64(node.Score())})
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	return fn(users)
+}
+
+func (lb *FakeTransferLeaderBoard) ImportUsers(ctx context.Context, decoder leaderboard.UserDecoder, dryRun bool) (*leaderboard.ImportResult, error) {
+	result := &leaderboard.ImportResult{DryRun: dryRun}
+	for {
+		user, err := decoder.Decode()
+		if err == io.EOF {
+			return result, nil
+		}
+		result.Total++
+		var invalid leaderboard.InvalidRecordError
+		if errors.As(err, &invalid) {
+			result.Invalid++
+			result.Errors = append(result.Errors, leaderboard.ImportError{Record: result.Total, Message: err.Error()})
+			continue
+		} else if err != nil {
+			return result, err
+		}
+		exists := lb.UserSet.GetByKey(user.Name) != nil
+		if exists {
+			result.Updated++
+		} else {
+			result.Added++
+		}
+		if dryRun {
+			continue
+		}
+		if exists {
+			err = lb.UpdateUser(ctx, user)
+		} else {
+			err = lb.AddUser(ctx, user)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (lb *FakeTransferLeaderBoard) SegmentAttributes() []string {
+	return []string{"country"}
+}
+
+func newFakeTransferLeaderBoard() *FakeTransferLeaderBoard {
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	sortedSet.AddOrUpdate("Yumi", 200, nil)
+	return &FakeTransferLeaderBoard{FakeLeaderBoard{
+		UserSet: *sortedSet,
+	}}
+}
+
+func TestExportUsers(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &Handler{newFakeTransferLeaderBoard()}
+
+	testCases := []struct {
+		format      string
+		contentType string
+		body        string
+	}{
+		{"", echo.MIMEApplicationJSONCharsetUTF8, "[\n{\"name\":\"Minsik\",\"score\":100},\n{\"name\":\"Yumi\",\"score\":200}\n]\n"},
+		{"csv", "text/csv; charset=utf-8", "name,score,country\nMinsik,100,\nYumi,200,\n"},
+		{"jsonl", "application/x-ndjson", "{\"name\":\"Minsik\",\"score\":100}\n{\"name\":\"Yumi\",\"score\":200}\n"},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/users/export?format="+tc.format, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if assert.NoError(t, h.ExportUsers(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tc.contentType, rec.Header().Get(echo.HeaderContentType))
+			assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), "attachment")
+			assert.Equal(t, tc.body, rec.Body.String())
+		}
+	}
+
+	// 잘못된 format
+	req := httptest.NewRequest(http.MethodGet, "/users/export?format=xml", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.ExportUsers(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.JSONEq(t, `{"message": "invalid format: xml"}`, rec.Body.String())
+	}
+}
+
+func TestImportUsers(t *testing.T) {
+	// Setup
+	e := echo.New()
+	lb := newFakeTransferLeaderBoard()
+	h := &Handler{lb}
+	const body = "name,score\nMinsik,300\nFoo,50\nBar,abc\n"
+
+	// dry run
+	req := httptest.NewRequest(http.MethodPost, "/users/import?format=csv&dry_run=true", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.ImportUsers(c)) {
+		const resultJSON = `{"dry_run": true, "total": 3, "added": 1, "updated": 1, "invalid": 1,
+			"errors": [{"record": 3, "message": "invalid score: abc"}]}`
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, resultJSON, rec.Body.String())
+		assert.Nil(t, lb.UserSet.GetByKey("Foo"))
+	}
+
+	// 저장
+	req = httptest.NewRequest(http.MethodPost, "/users/import?format=csv", strings.NewReader(body))
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, h.ImportUsers(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, sortedset.SCORE(300), lb.UserSet.GetByKey("Minsik").Score())
+		assert.Equal(t, sortedset.SCORE(50), lb.UserSet.GetByKey("Foo").Score())
+	}
+
+	// 중간에 실패하면 그 전까지의 결과를 함께 반환
+	req = httptest.NewRequest(http.MethodPost, "/users/import", strings.NewReader(`[{"name": "Bora", "score": 70}, {"name": `))
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, h.ImportUsers(c)) {
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		var data importErrorData
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &data))
+		assert.Contains(t, data.Message, "invalid json")
+		assert.Equal(t, &leaderboard.ImportResult{Total: 2, Added: 1}, data.Result)
+		assert.Equal(t, sortedset.SCORE(70), lb.UserSet.GetByKey("Bora").Score())
+	}
+
+	// 잘못된 요청
+	testCases := []struct {
+		target  string
+		body    string
+		message string
+	}{
+		{"/users/import?dry_run=maybe", "[]", "invalid dry_run"},
+		{"/users/import?format=xml", "[]", "invalid format: xml"},
+		{"/users/import?format=csv", "name\nMinsik\n", "csv header must have name and score"},
+		{"/users/import", `{"name": "Minsik"}`, "json body must be an array"},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if assert.NoError(t, h.ImportUsers(c)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code, tc.target)
+			assert.Contains(t, rec.Body.String(), tc.message, tc.target)
+		}
+	}
+}
+
+func TestTransferNotSupported(t *testing.T) {
+	e := echo.New()
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/export", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.ExportUsers(c)) {
+		const errorJSON = `{"message": "import and export are not supported"}`
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+		require.JSONEq(t, errorJSON, rec.Body.String())
+	}
+}
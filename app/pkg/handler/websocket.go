@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// 이 시간 안에 쓰지 못하는 느린 client는 연결을 끊음
+	wsWriteWait = 10 * time.Second
+	// 이 시간 안에 pong이 오지 않으면 연결을 끊음
+	wsPongWait     = 60 * time.Second
+	wsPingInterval = wsPongWait * 9 / 10
+	// client 메시지 최대 크기
+	wsMaxMessageSize = 1024
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// client -> server
+// {"action": "subscribe", "channel": "top", "size": 10}
+// {"action": "subscribe", "channel": "rank", "name": "Minsik"}
+// {"action": "subscribe", "channel": "around", "name": "Minsik", "size": 5}
+// {"action": "unsubscribe", "channel": "rank", "name": "Minsik"}
+type wsRequest struct {
+	Action  string `json:"action"`
+	Channel string `json:"channel"`
+	Name    string `json:"name,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+}
+
+// server -> client
+// {"type": "top", "users": [...]}
+// {"type": "rank", "user": {...}}
+// {"type": "around", "name": "Minsik", "users": [...]}
+// {"type": "error", "message": "..."}
+type wsEvent struct {
+	Type    string                `json:"type"`
+	Name    string                `json:"name,omitempty"`
+	Users   interface{}           `json:"users,omitempty"`
+	User    *leaderboard.UserRank `json:"user,omitempty"`
+	Message string                `json:"message,omitempty"`
+}
+
+func applyWSRequest(watcher *leaderboard.RankWatcher, request wsRequest) error {
+	subscribe := false
+	switch request.Action {
+	case "subscribe":
+		subscribe = true
+	case "unsubscribe":
+	default:
+		return leaderboard.ErrorWithStatusCode(errors.New("invalid action"), http.StatusBadRequest)
+	}
+	switch request.Channel {
+	case "top":
+		if !subscribe {
+			return watcher.SetTop(0)
+		}
+		if request.Size <= 0 {
+			return leaderboard.ErrorWithStatusCode(errors.New("invalid top"), http.StatusBadRequest)
+		}
+		return watcher.SetTop(request.Size)
+	case "rank":
+		if !subscribe {
+			watcher.Unwatch(request.Name)
+			return nil
+		}
+		return watcher.Watch(request.Name)
+	case "around":
+		if !subscribe {
+			watcher.UnwatchAround(request.Name)
+			return nil
+		}
+		return watcher.WatchAround(request.Name, request.Size)
+	}
+	return leaderboard.ErrorWithStatusCode(errors.New("invalid channel"), http.StatusBadRequest)
+}
+
+func writeWSEvent(conn *websocket.Conn, event wsEvent) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err != nil {
+		return errors.Wrap(err, "conn.SetWriteDeadline")
+	}
+	return errors.Wrap(conn.WriteJSON(event), "conn.WriteJSON")
+}
+
+func writeWSUpdate(conn *websocket.Conn, update *leaderboard.RankUpdate) error {
+	if update == nil {
+		return nil
+	}
+	if update.Top != nil {
+		if err := writeWSEvent(conn, wsEvent{Type: "top", Users: update.Top}); err != nil {
+			return err
+		}
+	}
+	for i := range update.Ranks {
+		if err := writeWSEvent(conn, wsEvent{Type: "rank", User: &update.Ranks[i]}); err != nil {
+			return err
+		}
+	}
+	for _, around := range update.Around {
+		if err := writeWSEvent(conn, wsEvent{Type: "around", Name: around.Name, Users: around.Users}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pollWS(ctx context.Context, conn *websocket.Conn, watcher *leaderboard.RankWatcher) error {
+	update, err := watcher.Poll(ctx)
+	if err != nil {
+		return writeWSEvent(conn, wsEvent{Type: "error", Message: err.Error()})
+	}
+	return writeWSUpdate(conn, update)
+}
+
+// client 메시지를 읽어 전달합니다. 연결이 끊기면 cancel을 호출합니다.
+func readWSMessages(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, messages chan<- []byte) {
+	defer cancel()
+	conn.SetReadLimit(wsMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		select {
+		case messages <- message:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// 밀려있는 변경 알림을 모두 비웁니다. 한 번만 다시 읽으면 최신 상태를 얻을 수 있습니다.
+func drainChanges(changes <-chan leaderboard.Change) bool {
+	for {
+		select {
+		case _, ok := <-changes:
+			if !ok {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
+
+// @Summary     Subscribe rank changes
+// @Description WebSocket으로 top N, 특정 user의 순위, user 주변 순위(around)를 구독합니다.
+// @Description 구독 직후 현재 상태를 보내고, 이후에는 바뀐 부분만 보냅니다.
+// @Description top N은 GET /users/{start}/to/{stop}과 같이 0위부터 N-1위(점수가 낮은 순)입니다.
+// @Description 요청: {"action": "subscribe"|"unsubscribe", "channel": "top"|"rank"|"around", "name": "user name", "size": N}
+// @Description 응답: {"type": "top"|"rank"|"around"|"error", "users": [...], "user": {...}, "name": "...", "message": "..."}
+// @Description 느린 client에게는 중간 변경을 건너뛰고 최신 상태만 보냅니다.
+// @Tags        Users
+// @Success     101 {string} string "switching protocols"
+// @Failure     500 {object} messageData "서버에러"
+// @Security    ApiKeyAuth
+// @Router      /ws [get]
+func (h *Handler) SubscribeRanks(c echo.Context) error {
+	subscriber, err := h.subscriber()
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// Upgrade가 이미 에러 응답을 보냄
+		return nil
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+	changes, err := subscriber.Subscribe(ctx)
+	if err != nil {
+		_ = writeWSEvent(conn, wsEvent{Type: "error", Message: err.Error()})
+		return nil
+	}
+	messages := make(chan []byte)
+	go readWSMessages(ctx, cancel, conn, messages)
+
+	// upgrade 이후에는 echo가 응답을 쓸 수 없으므로 쓰기 실패는 연결 종료로 처리
+	watcher := leaderboard.NewRankWatcher(h.Leaderboard, 0, nil)
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return nil
+		case message := <-messages:
+			request := wsRequest{}
+			if json.Unmarshal(message, &request) != nil {
+				err = writeWSEvent(conn, wsEvent{Type: "error", Message: "invalid request"})
+			} else if applyErr := applyWSRequest(watcher, request); applyErr != nil {
+				err = writeWSEvent(conn, wsEvent{Type: "error", Message: applyErr.Error()})
+			} else {
+				err = pollWS(ctx, conn, watcher)
+			}
+		case _, ok := <-changes:
+			if !ok || !drainChanges(changes) {
+				return nil
+			}
+			err = pollWS(ctx, conn, watcher)
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
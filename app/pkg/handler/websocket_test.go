@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/leaderboard"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wangjia184/sortedset"
+)
+
+// redis zset처럼 점수가 낮은 user부터 0위로 순위를 매기는 fake
+type FakeZSetLeaderBoard struct {
+	FakeStreamLeaderBoard
+}
+
+func (lb *FakeZSetLeaderBoard) GetUser(_ context.Context, name string) (*leaderboard.UserRank, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	node := lb.UserSet.GetByKey(name)
+	if node == nil {
+		err := leaderboard.ErrorWithStatusCode(errors.New(name), http.StatusNotFound)
+		return nil, errors.Wrap(err, "not exists user")
+	}
+	return &leaderboard.UserRank{
+		User: leaderboard.User{Name: name, Score: float64(node.Score())},
+		Rank: int64(lb.UserSet.FindRank(name) - 1),
+	}, nil
+}
+
+func (lb *FakeZSetLeaderBoard) GetUserList(_ context.Context, start int64, stop int64) ([]leaderboard.User, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	nodes := lb.UserSet.GetByRankRange(int(start+1), int(stop+1), false)
+	result := make([]leaderboard.User, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, leaderboard.User{Name: node.Key(), Score: float64(node.Score())})
+	}
+	return result, nil
+}
+
+func TestSubscribeRanks(t *testing.T) {
+	// Setup
+	e := echo.New()
+	sortedSet := sortedset.New()
+	sortedSet.AddOrUpdate("Yumi", 500, nil)
+	sortedSet.AddOrUpdate("Minsik", 100, nil)
+	sortedSet.AddOrUpdate("Foo", 50, nil)
+	lb := &FakeZSetLeaderBoard{FakeStreamLeaderBoard{
+		FakeLeaderBoard: FakeLeaderBoard{
+			UserSet: *sortedSet,
+		},
+		Changes: make(chan leaderboard.Change),
+	}}
+	h := &Handler{lb}
+	e.GET("/ws", h.SubscribeRanks)
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	expect := func(expected string) {
+		_, message, err := conn.ReadMessage()
+		if assert.NoError(t, err) {
+			require.JSONEq(t, expected, string(message))
+		}
+	}
+	send := func(request string) {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(request)))
+	}
+
+	// 구독하면 현재 상태를 받음. top은 0위부터, 즉 점수가 낮은 user부터
+	send(`{"action": "subscribe", "channel": "top", "size": 2}`)
+	expect(`{"type": "top", "users": [{"name": "Foo", "score": 50}, {"name": "Minsik", "score": 100}]}`)
+	send(`{"action": "subscribe", "channel": "rank", "name": "Minsik"}`)
+	expect(`{"type": "rank", "user": {"name": "Minsik", "score": 100, "rank": 1}}`)
+	send(`{"action": "subscribe", "channel": "around", "name": "Minsik", "size": 1}`)
+	expect(`{"type": "around", "name": "Minsik", "users": [
+		{"name": "Foo", "score": 50, "rank": 0},
+		{"name": "Minsik", "score": 100, "rank": 1},
+		{"name": "Yumi", "score": 500, "rank": 2}
+	]}`)
+
+	// 잘못된 요청
+	send(`not json`)
+	expect(`{"type": "error", "message": "invalid request"}`)
+	send(`{"action": "subscribe", "channel": "around", "name": "Minsik", "size": 1000}`)
+	expect(`{"type": "error", "message": "invalid size"}`)
+	send(`{"action": "subscribe", "channel": "foo"}`)
+	expect(`{"type": "error", "message": "invalid channel"}`)
+
+	// 잘못된 요청의 응답을 받았다면 앞선 구독 해제도 처리된 상태
+	send(`{"action": "unsubscribe", "channel": "rank", "name": "Minsik"}`)
+	send(`{"action": "foo"}`)
+	expect(`{"type": "error", "message": "invalid action"}`)
+
+	// 변경 알림을 받으면 바뀐 부분을 받음
+	lb.setScore("Minsik", 1000)
+	lb.Changes <- leaderboard.Change{Name: "Minsik", Score: 1000}
+	expect(`{"type": "top", "users": [{"name": "Foo", "score": 50}, {"name": "Yumi", "score": 500}]}`)
+	expect(`{"type": "around", "name": "Minsik", "users": [
+		{"name": "Yumi", "score": 500, "rank": 1},
+		{"name": "Minsik", "score": 1000, "rank": 2}
+	]}`)
+}
+
+func TestSubscribeRanksNotSupported(t *testing.T) {
+	// Setup
+	e := echo.New()
+	h := &Handler{&FakeLeaderBoard{
+		UserSet: *sortedset.New(),
+	}}
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h.SubscribeRanks(c)) {
+		const errorJSON = `{"message": "streaming is not supported"}`
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+		require.JSONEq(t, errorJSON, rec.Body.String())
+	}
+}
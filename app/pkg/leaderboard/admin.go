@@ -0,0 +1,58 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 운영용 기능
+type AdminInterface interface {
+	Boards(ctx context.Context) ([]Board, error)
+	// 전체 board와 group, segment 정보, 차단된 user의 점수를 모두 지웁니다. archive와 차단 목록은 남겨둡니다.
+	ResetBoard(ctx context.Context) error
+	// 전체 board를 "archive:<name>" board로 복사하고 복사한 user 수를 반환합니다. 차단된 user는 포함하지 않음
+	// 빈 board는 archive를 만들지 않고 0을 반환
+	ArchiveBoard(ctx context.Context, name string) (int64, error)
+}
+
+// Name은 전체 board가 "users", group board가 "groups", 나머지는 "segment:<attr>:<value>", "archive:<name>"
+type Board struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+func (lb *LeaderBoard) Boards(ctx context.Context) ([]Board, error) {
+	names, counts, err := lb.redisStorage.Boards(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.Boards")
+	}
+	result := make([]Board, 0, len(names))
+	for i, name := range names {
+		if name == "" {
+			name = "users"
+		}
+		result = append(result, Board{Name: name, Count: counts[i]})
+	}
+	return result, nil
+}
+
+func (lb *LeaderBoard) ResetBoard(ctx context.Context) error {
+	return errors.Wrap(lb.redisStorage.Reset(ctx), "lb.redisStorage.Reset")
+}
+
+func (lb *LeaderBoard) ArchiveBoard(ctx context.Context, name string) (int64, error) {
+	if name == "" || strings.ContainsAny(name, ":*?[]") {
+		return 0, ErrorWithStatusCode(errors.New("invalid archive name: "+name), http.StatusBadRequest)
+	}
+	ok, count, err := lb.redisStorage.Archive(ctx, name)
+	if err != nil {
+		return 0, errors.Wrap(err, "lb.redisStorage.Archive")
+	}
+	if !ok {
+		return 0, ErrorWithStatusCode(errors.New("already exists archive: "+name), http.StatusBadRequest)
+	}
+	return count, nil
+}
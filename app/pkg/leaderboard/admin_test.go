@@ -0,0 +1,135 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoards(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+
+	mock.ExpectScan(0, ZSetKeyName+":segment:*", 1000).SetVal([]string{ZSetKeyName + ":segment:country:KR"}, 0)
+	mock.ExpectScan(0, ZSetKeyName+":archive:*", 1000).SetVal([]string{ZSetKeyName + ":archive:2022"}, 0)
+	mock.ExpectZCard(ZSetKeyName).SetVal(3)
+	mock.ExpectZCard(ZSetKeyName + ":groups").SetVal(0)
+	mock.ExpectZCard(ZSetKeyName + ":segment:country:KR").SetVal(2)
+	mock.ExpectZCard(ZSetKeyName + ":archive:2022").SetVal(10)
+
+	boards, err := lb.Boards(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Board{
+			{Name: "users", Count: 3},
+			{Name: "groups", Count: 0},
+			{Name: "segment:country:KR", Count: 2},
+			{Name: "archive:2022", Count: 10},
+		}, boards)
+	}
+
+	// scan한 board 목록을 다시 사용하고, 비워진 segment board는 제외
+	mock.ExpectZCard(ZSetKeyName).SetVal(2)
+	mock.ExpectZCard(ZSetKeyName + ":groups").SetVal(0)
+	mock.ExpectZCard(ZSetKeyName + ":segment:country:KR").SetVal(0)
+	mock.ExpectZCard(ZSetKeyName + ":archive:2022").SetVal(10)
+	boards, err = lb.Boards(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Board{
+			{Name: "users", Count: 2},
+			{Name: "groups", Count: 0},
+			{Name: "archive:2022", Count: 10},
+		}, boards)
+	}
+
+	// archive를 만들면 다시 scan
+	mock.Regexp().ExpectEvalSha(scriptSHA, []string{ZSetKeyName, ZSetKeyName + ":archive:2023"}).SetVal(int64(2))
+	_, err = lb.ArchiveBoard(ctx, "2023")
+	assert.NoError(t, err)
+	mock.ExpectScan(0, ZSetKeyName+":segment:*", 1000).SetVal([]string{}, 0)
+	mock.ExpectScan(0, ZSetKeyName+":archive:*", 1000).SetVal([]string{ZSetKeyName + ":archive:2022", ZSetKeyName + ":archive:2023"}, 0)
+	mock.ExpectZCard(ZSetKeyName).SetVal(2)
+	mock.ExpectZCard(ZSetKeyName + ":groups").SetVal(0)
+	mock.ExpectZCard(ZSetKeyName + ":archive:2022").SetVal(10)
+	mock.ExpectZCard(ZSetKeyName + ":archive:2023").SetVal(2)
+	boards, err = lb.Boards(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Board{
+			{Name: "users", Count: 2},
+			{Name: "groups", Count: 0},
+			{Name: "archive:2022", Count: 10},
+			{Name: "archive:2023", Count: 2},
+		}, boards)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestResetBoard(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+
+	mock.ExpectScan(0, ZSetKeyName+":group:*", 1000).SetVal([]string{ZSetKeyName + ":group:A"}, 0)
+	mock.ExpectScan(0, ZSetKeyName+":segment:*", 1000).SetVal([]string{}, 0)
+	mock.ExpectScan(0, ZSetKeyName+":user-segments:*", 1000).SetVal([]string{ZSetKeyName + ":user-segments:Minsik"}, 0)
+	mock.ExpectDel(ZSetKeyName, ZSetKeyName+":banned", ZSetKeyName+":user-groups", ZSetKeyName+":groups",
+		ZSetKeyName+":group:A", ZSetKeyName+":user-segments:Minsik").SetVal(6)
+	mock.ExpectPublish(ZSetKeyName+":changes", `{"deleted":true}`).SetVal(0)
+
+	assert.NoError(t, lb.ResetBoard(ctx))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestArchiveBoard(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+
+	archiveKeys := []string{ZSetKeyName, ZSetKeyName + ":archive:2022"}
+	mock.Regexp().ExpectEvalSha(scriptSHA, archiveKeys).SetVal(int64(3))
+	count, err := lb.ArchiveBoard(ctx, "2022")
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(3), count)
+	}
+
+	// 빈 board
+	mock.Regexp().ExpectEvalSha(scriptSHA, []string{ZSetKeyName, ZSetKeyName + ":archive:empty"}).SetVal(int64(0))
+	count, err = lb.ArchiveBoard(ctx, "empty")
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(0), count)
+	}
+
+	// 이미 있는 archive
+	mock.Regexp().ExpectEvalSha(scriptSHA, archiveKeys).SetVal(int64(-1))
+	_, err = lb.ArchiveBoard(ctx, "2022")
+	var apiErr interface{ StatusCode() int }
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	}
+
+	// 잘못된 이름
+	_, err = lb.ArchiveBoard(ctx, "a:b")
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,114 @@
+package leaderboard
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/pkg/errors"
+)
+
+// 차단된 user는 점수를 지우지 않고 전체 board에서 banned board로 옮겨서 순위, 목록, user 수에서 제외합니다.
+type BanInterface interface {
+	// name 순서로 반환합니다.
+	Bans(ctx context.Context) ([]Ban, error)
+	// 이미 차단된 user면 차단 정보를 바꿉니다.
+	BanUser(ctx context.Context, name string, reason string, shadow bool) (*Ban, error)
+	UnbanUser(ctx context.Context, name string) (bool, error)
+}
+
+type Ban struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	// true면 계속 점수를 기록할 수 있고 자기 player token으로 name을 조회하면 자기 순위를 볼 수 있지만, 목록과 user 수에는 나오지 않음
+	// false면 점수 기록도 403
+	Shadow    bool      `json:"shadow"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (lb *LeaderBoard) Bans(ctx context.Context) ([]Ban, error) {
+	bans, err := lb.redisStorage.Bans(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.Bans")
+	}
+	result := make([]Ban, 0, len(bans))
+	for _, data := range bans {
+		ban := Ban{}
+		if err := json.Unmarshal([]byte(data), &ban); err != nil {
+			return nil, errors.Wrap(err, "json.Unmarshal")
+		}
+		result = append(result, ban)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (lb *LeaderBoard) BanUser(ctx context.Context, name string, reason string, shadow bool) (*Ban, error) {
+	if name == "" {
+		return nil, ErrorWithStatusCode(errors.New("user name is empty"), http.StatusBadRequest)
+	}
+	ban := &Ban{Name: name, Reason: reason, Shadow: shadow, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return nil, errors.Wrap(err, "json.Marshal")
+	}
+	// 전체 board에서 빠지면 group 점수도 같은 script에서 다시 계산
+	if _, err := lb.redisStorage.Ban(ctx, name, string(data), lb.groupScore.mode(), lb.groupScore.TopN); err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.Ban")
+	}
+	return ban, nil
+}
+
+func (lb *LeaderBoard) UnbanUser(ctx context.Context, name string) (bool, error) {
+	ok, err := lb.redisStorage.Unban(ctx, name, lb.groupScore.mode(), lb.groupScore.TopN)
+	return ok, errors.Wrap(err, "lb.redisStorage.Unban")
+}
+
+// player token의 subject(user name)를 context에 저장하는 key
+type playerContextKey struct{}
+
+// player token으로 인증한 요청이면 subject를 담은 context를 만듭니다. shadow ban된 user의 순위는 그 player 자신에게만 보여줌
+func WithPlayer(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, playerContextKey{}, name)
+}
+
+// player token으로 인증한 요청의 subject. API key로 인증했거나 인증하지 않았으면 false
+func PlayerFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(playerContextKey{}).(string)
+	return name, ok
+}
+
+// shadow ban된 user가 자기 자신을 조회하면 전체 board에 있었다면 받았을 rank로 보여줍니다.
+// 다른 요청에는 없는 user와 같은 404를 반환해서 shadow ban 여부를 알 수 없게 함
+func (lb *LeaderBoard) getBannedUser(ctx context.Context, name string) (*UserRank, error) {
+	notExists := ErrorWithStatusCode(errors.New("not exists user: "+name), http.StatusNotFound)
+	if player, ok := PlayerFromContext(ctx); !ok || player != name {
+		return nil, notExists
+	}
+	banned, err := lb.redisStorage.BannedUser(ctx, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.BannedUser")
+	}
+	if banned != nil && banned.Exists {
+		ban := Ban{}
+		if err := json.Unmarshal([]byte(banned.Data), &ban); err != nil {
+			return nil, errors.Wrap(err, "json.Unmarshal")
+		}
+		if ban.Shadow {
+			return &UserRank{User: User{Name: name, Score: banned.Score, Segments: banned.Segments}, Rank: banned.Rank}, nil
+		}
+	}
+	return nil, notExists
+}
+
+func bannedError(err error, name string) error {
+	if errors.Is(err, redisstorage.ErrBanned) {
+		return ErrorWithStatusCode(errors.New("banned user: "+name), http.StatusForbidden)
+	}
+	return err
+}
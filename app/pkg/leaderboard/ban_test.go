@@ -0,0 +1,131 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBanUser(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+		groupScore:   GroupScore{Mode: GroupScoreSum},
+	}
+	banData := `^\{"name":"Cheater","reason":"speed hack","shadow":true,"created_at":"[^"]+"\}$`
+
+	// 전체 board에 있던 user면 group 점수도 다시 계산
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Cheater"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Cheater", banData, ZSetKeyName+":group:", GroupScoreSum, 0).SetVal(int64(1))
+	ban, err := lb.BanUser(ctx, "Cheater", "speed hack", true)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Cheater", ban.Name)
+		assert.True(t, ban.Shadow)
+	}
+
+	_, err = lb.BanUser(ctx, "", "speed hack", true)
+	assert.Equal(t, http.StatusBadRequest, StatusCode(err))
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Cheater"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Cheater", ZSetKeyName+":group:", GroupScoreSum, 0).SetVal(int64(1))
+	ok, err := lb.UnbanUser(ctx, "Cheater")
+	if assert.NoError(t, err) {
+		assert.True(t, ok)
+	}
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Foo"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Foo", ZSetKeyName+":group:", GroupScoreSum, 0).SetVal(int64(0))
+	ok, err = lb.UnbanUser(ctx, "Foo")
+	if assert.NoError(t, err) {
+		assert.False(t, ok)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBans(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+	created := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectHGetAll(ZSetKeyName + ":bans").SetVal(map[string]string{
+		"Zed":     `{"name":"Zed","reason":"abuse","shadow":false,"created_at":"2022-07-01T12:00:00Z"}`,
+		"Cheater": `{"name":"Cheater","reason":"speed hack","shadow":true,"created_at":"2022-07-01T12:00:00Z"}`,
+	})
+	bans, err := lb.Bans(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Ban{
+			{Name: "Cheater", Reason: "speed hack", Shadow: true, CreatedAt: created},
+			{Name: "Zed", Reason: "abuse", CreatedAt: created},
+		}, bans)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetBannedUser(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+	expectBanned := func(name string, ban string) {
+		mock.Regexp().ExpectEvalSha(scriptSHA, getKeys(ZSetKeyName, name), name).RedisNil()
+		mock.Regexp().ExpectEvalSha(scriptSHA, bannedUserKeys(name), name).SetVal([]interface{}{ban, "300", int64(2), []interface{}{}})
+	}
+
+	// shadow ban된 user는 자기 player token으로 자기 순위를 볼 수 있음
+	expectBanned("Cheater", `{"name":"Cheater","shadow":true}`)
+	userRank, err := lb.GetUser(WithPlayer(ctx, "Cheater"), "Cheater")
+	if assert.NoError(t, err) {
+		assert.Equal(t, UserRank{User: User{Name: "Cheater", Score: 300}, Rank: 2}, *userRank)
+	}
+
+	// 다른 player나 API key에는 없는 user와 같음
+	mock.Regexp().ExpectEvalSha(scriptSHA, getKeys(ZSetKeyName, "Cheater"), "Cheater").RedisNil()
+	_, err = lb.GetUser(WithPlayer(ctx, "Alice"), "Cheater")
+	assert.EqualError(t, err, "not exists user: Cheater")
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+	mock.Regexp().ExpectEvalSha(scriptSHA, getKeys(ZSetKeyName, "Cheater"), "Cheater").RedisNil()
+	_, err = lb.GetUser(ctx, "Cheater")
+	assert.EqualError(t, err, "not exists user: Cheater")
+
+	expectBanned("Zed", `{"name":"Zed","shadow":false}`)
+	_, err = lb.GetUser(WithPlayer(ctx, "Zed"), "Zed")
+	assert.EqualError(t, err, "not exists user: Zed")
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWriteBannedUser(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Zed"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Zed", float64(100), ZSetKeyName+":group:", GroupScoreSum, 0).SetVal([]interface{}{int64(-1)})
+	err := lb.AddUser(ctx, User{Name: "Zed", Score: 100})
+	assert.Equal(t, http.StatusForbidden, StatusCode(err))
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Zed"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Zed", float64(100), ZSetKeyName+":group:", GroupScoreSum, 0).SetVal([]interface{}{int64(-1)})
+	err = lb.UpdateUser(ctx, User{Name: "Zed", Score: 100})
+	assert.Equal(t, http.StatusForbidden, StatusCode(err))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,115 @@
+package leaderboard
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// 구독자별 buffer 크기. buffer가 가득 찬 구독자에게는 알림을 보내지 않습니다.
+// 구독자는 알림을 받을 때마다 순위를 다시 읽으므로 밀린 알림을 건너뛰어도 최신 상태를 얻습니다.
+// 아직 읽지 않은 알림이 있으면 그 알림으로 한 번 더 읽게 되므로 1개면 충분합니다.
+const changeBufferSize = 1
+
+// 변경 알림을 모아서 보내는 간격. 점수 기록이 몰려도 구독자마다 이 간격에 한 번만 순위를 다시 읽습니다.
+const changeCoalesceInterval = 100 * time.Millisecond
+
+type SubscribeInterface interface {
+	// ctx가 끝나면 channel이 닫힙니다.
+	Subscribe(ctx context.Context) (<-chan Change, error)
+}
+
+type Change struct {
+	Name    string  `json:"name"`
+	Score   float64 `json:"score"`
+	Deleted bool    `json:"deleted,omitempty"`
+}
+
+// redis 구독 하나를 여러 구독자에게 나눠줍니다.
+// interval 동안 받은 알림은 마지막 알림 하나로 합쳐서 보냅니다.
+type changeHub struct {
+	mu          sync.Mutex
+	subscribers map[chan Change]struct{}
+	interval    time.Duration
+}
+
+func newChangeHub(interval time.Duration) *changeHub {
+	return &changeHub{
+		subscribers: map[chan Change]struct{}{},
+		interval:    interval,
+	}
+}
+
+func (h *changeHub) run(messages <-chan *redis.Message) {
+	var pending *Change
+	var flush <-chan time.Time
+	for {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				if pending != nil {
+					h.publish(*pending)
+				}
+				return
+			}
+			change := Change{}
+			if err := json.Unmarshal([]byte(message.Payload), &change); err != nil {
+				continue
+			}
+			pending = &change
+			if flush == nil {
+				flush = time.After(h.interval)
+			}
+		case <-flush:
+			h.publish(*pending)
+			pending = nil
+			flush = nil
+		}
+	}
+}
+
+func (h *changeHub) publish(change Change) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+func (h *changeHub) subscribe(ctx context.Context) <-chan Change {
+	ch := make(chan Change, changeBufferSize)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		h.mu.Lock()
+		delete(h.subscribers, ch)
+		close(ch)
+		h.mu.Unlock()
+	}()
+	return ch
+}
+
+// 처음 호출될 때 redis 변경 알림 channel을 구독하고, 이후 호출은 같은 구독을 공유합니다.
+func (lb *LeaderBoard) Subscribe(ctx context.Context) (<-chan Change, error) {
+	lb.changesMu.Lock()
+	defer lb.changesMu.Unlock()
+	if lb.changes == nil {
+		pubsub, err := lb.redisStorage.SubscribeChanges(context.Background())
+		if err != nil {
+			return nil, errors.Wrap(err, "lb.redisStorage.SubscribeChanges")
+		}
+		lb.changes = newChangeHub(changeCoalesceInterval)
+		go lb.changes.run(pubsub.Channel())
+	}
+	return lb.changes.subscribe(ctx), nil
+}
@@ -0,0 +1,68 @@
+package leaderboard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeHub(t *testing.T) {
+	hub := newChangeHub(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := hub.subscribe(ctx)
+
+	// interval 안에 받은 알림은 마지막 하나로 합쳐짐
+	messages := make(chan *redis.Message, 3)
+	messages <- &redis.Message{Payload: `{"name": "Minsik", "score": 100}`}
+	messages <- &redis.Message{Payload: `{"name": "Foo", "deleted": true}`}
+	messages <- &redis.Message{Payload: `invalid`}
+	close(messages)
+	hub.run(messages)
+
+	assert.Equal(t, Change{Name: "Foo", Deleted: true}, <-changes)
+	assert.Len(t, changes, 0)
+
+	// buffer가 가득 차도 publish가 막히지 않음
+	for i := 0; i < changeBufferSize+1; i++ {
+		hub.publish(Change{Name: "Yumi"})
+	}
+	assert.Len(t, changes, changeBufferSize)
+
+	// 구독 취소
+	cancel()
+	assert.Eventually(t, func() bool {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		return len(hub.subscribers) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestChangeHubInterval(t *testing.T) {
+	hub := newChangeHub(50 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := hub.subscribe(ctx)
+
+	messages := make(chan *redis.Message, 2)
+	messages <- &redis.Message{Payload: `{"name": "Minsik", "score": 100}`}
+	messages <- &redis.Message{Payload: `{"name": "Minsik", "score": 200}`}
+	done := make(chan struct{})
+	go func() {
+		hub.run(messages)
+		close(done)
+	}()
+
+	// interval이 지나면 channel이 닫히지 않아도 보냄
+	assert.Equal(t, Change{Name: "Minsik", Score: 200}, <-changes)
+
+	// 다음 interval의 알림도 보냄
+	messages <- &redis.Message{Payload: `{"name": "Foo", "score": 300}`}
+	assert.Equal(t, Change{Name: "Foo", Score: 300}, <-changes)
+
+	close(messages)
+	<-done
+	assert.Len(t, changes, 0)
+}
@@ -0,0 +1,106 @@
+package leaderboard
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/metrics"
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/JeongMinSik/go-leaderboard/pkg/tracing"
+	"github.com/pkg/errors"
+)
+
+// 다른 요청이 먼저 수정했으면 덮어쓰지 않도록, 읽을 때 받은 ETag가 현재 user의 ETag와 같을 때만 수정/삭제합니다.
+type ConditionalInterface interface {
+	// etags 중 현재 user의 ETag가 없으면 412. "*"는 모든 ETag
+	UpdateUserIfMatch(ctx context.Context, user User, etags []string) (*UserRank, error)
+	DeleteUserIfMatch(ctx context.Context, name string, etags []string) (bool, error)
+}
+
+// user의 name, score, segments로 만든 strong ETag. score나 segment가 바뀌면 ETag도 바뀝니다.
+// segments는 attr 순으로 정렬한 query string으로 넣습니다.
+func UserETag(user User) string {
+	values := url.Values{}
+	for attr, value := range user.Segments {
+		values.Set(attr, value)
+	}
+	hash := sha256.Sum256([]byte(user.Name + "\n" + strconv.FormatFloat(user.Score, 'g', -1, 64) + "\n" + values.Encode()))
+	return `"` + hex.EncodeToString(hash[:8]) + `"`
+}
+
+func matchETag(etags []string, etag string) bool {
+	for _, e := range etags {
+		if e == "*" || e == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// 수정한 score와 rank를 반환합니다.
+func (lb *LeaderBoard) UpdateUserIfMatch(ctx context.Context, user User, etags []string) (userRank *UserRank, err error) {
+	defer func() { countSubmission(metrics.SubmissionUpdated, err) }()
+	ctx, span := startSpan(ctx, "UpdateUserIfMatch", tracing.BoardKey.String(usersBoard), tracing.UserKey.String(user.Name))
+	defer func() { endSpan(ctx, span, err) }()
+	current, err := lb.currentIfMatch(ctx, user.Name, etags)
+	if err != nil {
+		return nil, err
+	}
+	if err := lb.validateSegments(user.Segments); err != nil {
+		return nil, err
+	}
+	if err := lb.checkScoreRules(ctx, user, true); err != nil {
+		return nil, err
+	}
+	// 읽은 뒤에 다른 요청이 수정했으면 redis에서 확인
+	exists, rank, score, segments, err := lb.redisStorage.CompareAndUpdate(ctx, user.Name, current.Score, current.Segments, user.Score, user.Segments, lb.groupScore.mode(), lb.groupScore.TopN)
+	if err != nil {
+		return nil, errors.Wrap(preconditionError(bannedError(err, user.Name), user.Name), "lb.redisStorage.CompareAndUpdate")
+	}
+	if !exists {
+		return nil, preconditionError(redisstorage.ErrScoreChanged, user.Name)
+	}
+	return &UserRank{User: User{Name: user.Name, Score: score, Segments: segments}, Rank: rank}, nil
+}
+
+func (lb *LeaderBoard) DeleteUserIfMatch(ctx context.Context, name string, etags []string) (ok bool, err error) {
+	ctx, span := startSpan(ctx, "DeleteUserIfMatch", tracing.BoardKey.String(usersBoard), tracing.UserKey.String(name))
+	defer func() { endSpan(ctx, span, err) }()
+	current, err := lb.currentIfMatch(ctx, name, etags)
+	if err != nil {
+		return false, err
+	}
+	ok, err = lb.redisStorage.CompareAndDelete(ctx, name, current.Score, current.Segments, lb.groupScore.mode(), lb.groupScore.TopN)
+	if err != nil {
+		return false, errors.Wrap(preconditionError(err, name), "lb.redisStorage.CompareAndDelete")
+	}
+	if !ok {
+		return false, preconditionError(redisstorage.ErrScoreChanged, name)
+	}
+	return ok, nil
+}
+
+// 없는 user는 맞는 ETag가 없으므로 412
+func (lb *LeaderBoard) currentIfMatch(ctx context.Context, name string, etags []string) (*User, error) {
+	current, err := lb.GetUser(ctx, name)
+	if StatusCode(err) == http.StatusNotFound {
+		return nil, preconditionError(redisstorage.ErrScoreChanged, name)
+	} else if err != nil {
+		return nil, err
+	}
+	if !matchETag(etags, UserETag(current.User)) {
+		return nil, preconditionError(redisstorage.ErrScoreChanged, name)
+	}
+	return &current.User, nil
+}
+
+func preconditionError(err error, name string) error {
+	if errors.Is(err, redisstorage.ErrScoreChanged) {
+		return ErrorWithStatusCode(errors.New("etag does not match: "+name), http.StatusPreconditionFailed)
+	}
+	return err
+}
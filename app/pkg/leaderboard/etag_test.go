@@ -0,0 +1,143 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserETag(t *testing.T) {
+	etag := UserETag(User{Name: "Minsik", Score: 100})
+	assert.Regexp(t, `^"[0-9a-f]{16}"$`, etag)
+	assert.NotEqual(t, etag, UserETag(User{Name: "Minsik", Score: 100, Segments: map[string]string{"country": "KR"}}))
+	assert.Equal(t, UserETag(User{Name: "Minsik", Score: 100, Segments: map[string]string{"country": "KR", "platform": "ios"}}),
+		UserETag(User{Name: "Minsik", Score: 100, Segments: map[string]string{"platform": "ios", "country": "KR"}}))
+	assert.NotEqual(t, etag, UserETag(User{Name: "Minsik", Score: 101}))
+	assert.NotEqual(t, etag, UserETag(User{Name: "Foo", Score: 100}))
+
+	assert.True(t, matchETag([]string{`"a"`, etag}, etag))
+	assert.True(t, matchETag([]string{"*"}, etag))
+	assert.False(t, matchETag([]string{}, etag))
+}
+
+func TestUpdateUserIfMatch(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+	etag := UserETag(User{Name: "Minsik", Score: 100})
+	expectCurrent := func(score float64) {
+		expectGet(mock, ZSetKeyName, "Minsik", score, 0)
+	}
+
+	// 읽은 score가 그대로일 때만 수정
+	expectCurrent(100)
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Minsik"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Minsik", float64(200), ZSetKeyName+":group:", GroupScoreSum, 0, float64(100), "{}").SetVal([]interface{}{int64(1), "200", int64(0), []interface{}{}})
+	userRank, err := lb.UpdateUserIfMatch(ctx, User{Name: "Minsik", Score: 200}, []string{etag})
+	if assert.NoError(t, err) {
+		assert.Equal(t, UserRank{User: User{Name: "Minsik", Score: 200}, Rank: 0}, *userRank)
+	}
+
+	// 다른 요청이 먼저 수정
+	expectCurrent(200)
+	_, err = lb.UpdateUserIfMatch(ctx, User{Name: "Minsik", Score: 300}, []string{etag})
+	assert.Equal(t, http.StatusPreconditionFailed, StatusCode(err))
+	assert.EqualError(t, err, "etag does not match: Minsik")
+
+	// 다른 요청이 segment만 수정
+	expectGet(mock, ZSetKeyName, "Minsik", 100, 0, "country", "KR")
+	_, err = lb.UpdateUserIfMatch(ctx, User{Name: "Minsik", Score: 300}, []string{etag})
+	assert.Equal(t, http.StatusPreconditionFailed, StatusCode(err))
+
+	// 읽은 뒤 redis에 기록하기 전에 수정
+	expectCurrent(100)
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Minsik"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Minsik", float64(300), ZSetKeyName+":group:", GroupScoreSum, 0, float64(100), "{}").SetVal([]interface{}{int64(-2)})
+	_, err = lb.UpdateUserIfMatch(ctx, User{Name: "Minsik", Score: 300}, []string{etag})
+	assert.Equal(t, http.StatusPreconditionFailed, StatusCode(err))
+
+	// 없는 user
+	mock.Regexp().ExpectEvalSha(scriptSHA, getKeys(ZSetKeyName, "Foo"), "Foo").RedisNil()
+	_, err = lb.UpdateUserIfMatch(ctx, User{Name: "Foo", Score: 300}, []string{"*"})
+	assert.Equal(t, http.StatusPreconditionFailed, StatusCode(err))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteUserIfMatch(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+	etag := UserETag(User{Name: "Minsik", Score: 100})
+	expectCurrent := func() {
+		expectGet(mock, ZSetKeyName, "Minsik", 100, 0)
+	}
+
+	expectCurrent()
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Minsik"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Minsik", ZSetKeyName+":group:", GroupScoreSum, 0, float64(100), "{}").SetVal(int64(1))
+	ok, err := lb.DeleteUserIfMatch(ctx, "Minsik", []string{etag})
+	if assert.NoError(t, err) {
+		assert.True(t, ok)
+	}
+
+	expectCurrent()
+	_, err = lb.DeleteUserIfMatch(ctx, "Minsik", []string{`"0000000000000000"`})
+	assert.Equal(t, http.StatusPreconditionFailed, StatusCode(err))
+
+	expectCurrent()
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Minsik"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Minsik", ZSetKeyName+":group:", GroupScoreSum, 0, float64(100), "{}").SetVal(int64(-2))
+	_, err = lb.DeleteUserIfMatch(ctx, "Minsik", []string{etag})
+	assert.Equal(t, http.StatusPreconditionFailed, StatusCode(err))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIfMatchSegments(t *testing.T) {
+	ctx := context.Background()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})),
+	}
+	userRank, err := lb.AddUserRank(ctx, User{Name: "Minsik", Score: 100, Segments: map[string]string{"country": "KR"}})
+	require.NoError(t, err)
+	etag := UserETag(userRank.User)
+	current, err := lb.GetUser(ctx, "Minsik")
+	require.NoError(t, err)
+	assert.Equal(t, etag, UserETag(current.User))
+
+	// segment만 바뀌어도 이전 ETag로는 수정/삭제할 수 없음
+	require.NoError(t, lb.UpdateUser(ctx, User{Name: "Minsik", Score: 100, Segments: map[string]string{"country": "US"}}))
+	_, err = lb.UpdateUserIfMatch(ctx, User{Name: "Minsik", Score: 200}, []string{etag})
+	assert.Equal(t, http.StatusPreconditionFailed, StatusCode(err))
+	_, err = lb.DeleteUserIfMatch(ctx, "Minsik", []string{etag})
+	assert.Equal(t, http.StatusPreconditionFailed, StatusCode(err))
+
+	// 읽은 뒤 redis에 기록하기 전에 segment가 바뀌어도 script에서 확인
+	_, _, _, _, err = lb.redisStorage.CompareAndUpdate(ctx, "Minsik", 100, map[string]string{"country": "KR"}, 200, nil, GroupScoreSum, 0)
+	assert.ErrorIs(t, err, redisstorage.ErrScoreChanged)
+	_, err = lb.redisStorage.CompareAndDelete(ctx, "Minsik", 100, map[string]string{"country": "KR"}, GroupScoreSum, 0)
+	assert.ErrorIs(t, err, redisstorage.ErrScoreChanged)
+
+	current, err = lb.GetUser(ctx, "Minsik")
+	require.NoError(t, err)
+	userRank, err = lb.UpdateUserIfMatch(ctx, User{Name: "Minsik", Score: 200, Segments: map[string]string{"platform": "ios"}}, []string{UserETag(current.User)})
+	if assert.NoError(t, err) {
+		assert.Equal(t, User{Name: "Minsik", Score: 200, Segments: map[string]string{"country": "US", "platform": "ios"}}, userRank.User)
+	}
+	ok, err := lb.DeleteUserIfMatch(ctx, "Minsik", []string{UserETag(userRank.User)})
+	if assert.NoError(t, err) {
+		assert.True(t, ok)
+	}
+}
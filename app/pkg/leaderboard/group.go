@@ -0,0 +1,135 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type GroupInterface interface {
+	JoinGroup(ctx context.Context, group string, name string) error
+	LeaveGroup(ctx context.Context, group string, name string) (bool, error)
+	GetGroup(ctx context.Context, group string) (*GroupRank, error)
+	GetGroupList(ctx context.Context, start int64, stop int64) ([]Group, error)
+	GetGroupMembers(ctx context.Context, group string) ([]User, error)
+	GetUserGroup(ctx context.Context, name string) (*GroupRank, error)
+}
+
+type Group struct {
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+type GroupRank struct {
+	Group
+	Rank        int64 `json:"rank"`
+	MemberCount int64 `json:"member_count"`
+}
+
+const (
+	GroupScoreSum = "sum"
+	GroupScoreAvg = "avg"
+	GroupScoreTop = "top"
+)
+
+// 그룹 점수 계산 방식. zero value는 멤버 점수의 합입니다.
+type GroupScore struct {
+	Mode string
+	TopN int
+}
+
+// "sum", "avg", "top5" 형식의 문자열을 해석합니다.
+func ParseGroupScore(s string) (GroupScore, error) {
+	switch {
+	case s == "" || s == GroupScoreSum:
+		return GroupScore{Mode: GroupScoreSum}, nil
+	case s == GroupScoreAvg:
+		return GroupScore{Mode: GroupScoreAvg}, nil
+	case strings.HasPrefix(s, GroupScoreTop):
+		n, err := strconv.Atoi(strings.TrimPrefix(s, GroupScoreTop))
+		if err != nil || n <= 0 {
+			return GroupScore{}, errors.New("invalid group score: " + s)
+		}
+		return GroupScore{Mode: GroupScoreTop, TopN: n}, nil
+	}
+	return GroupScore{}, errors.New("invalid group score: " + s)
+}
+
+func (gs GroupScore) mode() string {
+	if gs.Mode == "" {
+		return GroupScoreSum
+	}
+	return gs.Mode
+}
+
+func (lb *LeaderBoard) JoinGroup(ctx context.Context, group string, name string) error {
+	if _, err := lb.redisStorage.JoinGroup(ctx, group, name, lb.groupScore.mode(), lb.groupScore.TopN); err != nil {
+		return errors.Wrap(err, "lb.redisStorage.JoinGroup")
+	}
+	return nil
+}
+
+func (lb *LeaderBoard) LeaveGroup(ctx context.Context, group string, name string) (bool, error) {
+	ok, err := lb.redisStorage.LeaveGroup(ctx, group, name, lb.groupScore.mode(), lb.groupScore.TopN)
+	return ok, errors.Wrap(err, "lb.redisStorage.LeaveGroup")
+}
+
+func (lb *LeaderBoard) GetGroup(ctx context.Context, group string) (*GroupRank, error) {
+	exists, rank, score, count, err := lb.redisStorage.GetGroup(ctx, group)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.GetGroup")
+	} else if !exists {
+		return nil, ErrorWithStatusCode(errors.New("not exists group: "+group), http.StatusNotFound)
+	}
+	return &GroupRank{
+		Group: Group{
+			Name:  group,
+			Score: score,
+		},
+		Rank:        rank,
+		MemberCount: count,
+	}, nil
+}
+
+func (lb *LeaderBoard) GetGroupList(ctx context.Context, start int64, stop int64) ([]Group, error) {
+	groupList, err := lb.redisStorage.GroupRange(ctx, start, stop)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.GroupRange")
+	}
+	result := make([]Group, 0, len(groupList))
+	for _, group := range groupList {
+		result = append(result, Group{
+			Name:  group.Member.(string),
+			Score: group.Score,
+		})
+	}
+	return result, nil
+}
+
+func (lb *LeaderBoard) GetGroupMembers(ctx context.Context, group string) ([]User, error) {
+	members, err := lb.redisStorage.GroupMembers(ctx, group)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.GroupMembers")
+	}
+	result := make([]User, 0, len(members))
+	for _, member := range members {
+		result = append(result, User{
+			Name:  member.Member.(string),
+			Score: member.Score,
+		})
+	}
+	return result, nil
+}
+
+func (lb *LeaderBoard) GetUserGroup(ctx context.Context, name string) (*GroupRank, error) {
+	exists, group, err := lb.redisStorage.UserGroup(ctx, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.UserGroup")
+	} else if !exists {
+		return nil, ErrorWithStatusCode(errors.New("not in any group: "+name), http.StatusNotFound)
+	}
+	return lb.GetGroup(ctx, group)
+}
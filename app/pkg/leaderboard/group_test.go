@@ -0,0 +1,207 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGroupScore(t *testing.T) {
+	for input, expected := range map[string]GroupScore{
+		"":     {Mode: GroupScoreSum},
+		"sum":  {Mode: GroupScoreSum},
+		"avg":  {Mode: GroupScoreAvg},
+		"top3": {Mode: GroupScoreTop, TopN: 3},
+	} {
+		groupScore, err := ParseGroupScore(input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, expected, groupScore, input)
+		}
+	}
+	for _, input := range []string{"max", "top", "top0", "topX"} {
+		_, err := ParseGroupScore(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestJoinGroup(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+		groupScore:   GroupScore{Mode: GroupScoreTop, TopN: 3},
+	}
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, append(groupKeys, ZSetKeyName+":group:Gophers"), ZSetKeyName+":group:", "Minsik", "Gophers", GroupScoreTop, 3).SetVal(int64(1))
+	assert.NoError(t, lb.JoinGroup(ctx, "Gophers", "Minsik"))
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, append(groupKeys, ZSetKeyName+":group:Gophers"), ZSetKeyName+":group:", "Minsik", "Gophers", GroupScoreTop, 3).SetVal(int64(1))
+	ok, err := lb.LeaveGroup(ctx, "Gophers", "Minsik")
+	if assert.NoError(t, err) {
+		assert.True(t, ok)
+	}
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, append(groupKeys, ZSetKeyName+":group:Gophers"), ZSetKeyName+":group:", "Foo", "Gophers", GroupScoreTop, 3).SetVal(int64(0))
+	ok, err = lb.LeaveGroup(ctx, "Gophers", "Foo")
+	if assert.NoError(t, err) {
+		assert.False(t, ok)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteGroupMember(t *testing.T) {
+	ctx := context.Background()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})),
+	}
+	require.NoError(t, lb.AddUser(ctx, User{Name: "Minsik", Score: 100}))
+	require.NoError(t, lb.AddUser(ctx, User{Name: "Jimin", Score: 50}))
+	require.NoError(t, lb.JoinGroup(ctx, "Gophers", "Minsik"))
+	require.NoError(t, lb.JoinGroup(ctx, "Gophers", "Jimin"))
+
+	// 삭제한 user는 group에서 빠지고 group 점수도 다시 계산
+	ok, err := lb.DeleteUser(ctx, "Minsik")
+	require.True(t, ok)
+	require.NoError(t, err)
+	groupRank, err := lb.GetGroup(ctx, "Gophers")
+	if assert.NoError(t, err) {
+		assert.Equal(t, float64(50), groupRank.Score)
+		assert.Equal(t, int64(1), groupRank.MemberCount)
+	}
+	members, err := lb.GetGroupMembers(ctx, "Gophers")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []User{{Name: "Jimin", Score: 50}}, members)
+	}
+	_, err = lb.GetUserGroup(ctx, "Minsik")
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+
+	// 마지막 member가 삭제되면 group도 없어짐
+	ok, err = lb.DeleteUser(ctx, "Jimin")
+	require.True(t, ok)
+	require.NoError(t, err)
+	_, err = lb.GetGroup(ctx, "Gophers")
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+}
+
+func TestGetGroup(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+
+	mock.ExpectTxPipeline()
+	mock.ExpectZScore(ZSetKeyName+":groups", "Gophers").SetVal(1500)
+	mock.ExpectZRank(ZSetKeyName+":groups", "Gophers").SetVal(2)
+	mock.ExpectSCard(ZSetKeyName + ":group:Gophers").SetVal(3)
+	mock.ExpectTxPipelineExec()
+
+	groupRank, err := lb.GetGroup(ctx, "Gophers")
+	if assert.NoError(t, err) {
+		assert.Equal(t, GroupRank{
+			Group: Group{
+				Name:  "Gophers",
+				Score: 1500,
+			},
+			Rank:        2,
+			MemberCount: 3,
+		}, *groupRank)
+	}
+
+	mock.ExpectTxPipeline()
+	mock.ExpectZScore(ZSetKeyName+":groups", "Foo").RedisNil()
+	_, err = lb.GetGroup(ctx, "Foo")
+	var apiErr interface{ StatusCode() int }
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetUserGroup(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+
+	mock.ExpectHGet(ZSetKeyName+":user-groups", "Minsik").SetVal("Gophers")
+	mock.ExpectTxPipeline()
+	mock.ExpectZScore(ZSetKeyName+":groups", "Gophers").SetVal(1500)
+	mock.ExpectZRank(ZSetKeyName+":groups", "Gophers").SetVal(0)
+	mock.ExpectSCard(ZSetKeyName + ":group:Gophers").SetVal(1)
+	mock.ExpectTxPipelineExec()
+
+	groupRank, err := lb.GetUserGroup(ctx, "Minsik")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Gophers", groupRank.Name)
+		assert.Equal(t, int64(1), groupRank.MemberCount)
+	}
+
+	mock.ExpectHGet(ZSetKeyName+":user-groups", "Foo").RedisNil()
+	_, err = lb.GetUserGroup(ctx, "Foo")
+	var apiErr interface{ StatusCode() int }
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGroupList(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+
+	mock.ExpectZRangeWithScores(ZSetKeyName+":groups", 0, 1).SetVal([]redis.Z{
+		{Score: 300, Member: "Gophers"},
+		{Score: 100, Member: "Rustaceans"},
+	})
+	groups, err := lb.GetGroupList(ctx, 0, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Group{
+			{Name: "Gophers", Score: 300},
+			{Name: "Rustaceans", Score: 100},
+		}, groups)
+	}
+
+	mock.ExpectSMembers(ZSetKeyName + ":group:Gophers").SetVal([]string{"Minsik", "Foo", "Yumi"})
+	mock.ExpectZScore(ZSetKeyName, "Minsik").SetVal(200)
+	mock.ExpectZScore(ZSetKeyName, "Foo").SetVal(100)
+	mock.ExpectZScore(ZSetKeyName, "Yumi").RedisNil()
+	members, err := lb.GetGroupMembers(ctx, "Gophers")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []User{
+			{Name: "Yumi", Score: 0},
+			{Name: "Foo", Score: 100},
+			{Name: "Minsik", Score: 200},
+		}, members)
+	}
+
+	mock.ExpectSMembers(ZSetKeyName + ":group:Empty").SetVal([]string{})
+	members, err = lb.GetGroupMembers(ctx, "Empty")
+	if assert.NoError(t, err) {
+		assert.Empty(t, members)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
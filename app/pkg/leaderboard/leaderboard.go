@@ -3,8 +3,13 @@ package leaderboard
 import (
 	"context"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
 
+	"github.com/JeongMinSik/go-leaderboard/pkg/metrics"
 	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/JeongMinSik/go-leaderboard/pkg/tracing"
 	"github.com/pkg/errors"
 )
 
@@ -17,13 +22,29 @@ type Interface interface {
 	GetUserList(ctx context.Context, start int64, stop int64) ([]User, error)
 }
 
+// 기록한 시점의 score와 rank를 함께 반환합니다. 기록한 뒤에 GetUser로 조회하면 그 사이의 다른 기록이 반영될 수 있음
+type RankedWriteInterface interface {
+	AddUserRank(ctx context.Context, user User) (*UserRank, error)
+	UpdateUserRank(ctx context.Context, user User) (*UserRank, error)
+}
+
 type LeaderBoard struct {
 	redisStorage *redisstorage.RedisStorage
+	groupScore   GroupScore
+	segmentAttrs []string
+	// 남겨두는 snapshot 수. 0이면 지우지 않음
+	snapshotRetention int
+	// board별 점수 기록 규칙. 비어 있으면 확인하지 않음
+	scoreRules ScoreRules
+
+	changesMu sync.Mutex
+	changes   *changeHub
 }
 
 type User struct {
-	Name  string  `json:"name"`
-	Score float64 `json:"score"`
+	Name     string            `json:"name"`
+	Score    float64           `json:"score"`
+	Segments map[string]string `json:"segments,omitempty"`
 }
 
 type UserRank struct {
@@ -36,61 +57,132 @@ func New() (Interface, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "redisstorage.New()")
 	}
+	return NewWithStorage(db)
+}
+
+// API key, nonce 등 다른 저장소와 redis client를 함께 쓸 때 사용합니다.
+func NewWithStorage(db *redisstorage.RedisStorage) (Interface, error) {
 	if db == nil {
 		return nil, errors.New("redis nil")
 	}
+	if err := db.LoadScripts(context.Background()); err != nil {
+		return nil, errors.Wrap(err, "db.LoadScripts")
+	}
+	groupScore, err := ParseGroupScore(os.Getenv("GROUP_SCORE"))
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseGroupScore")
+	}
+	var segmentAttrs []string
+	if attrs := os.Getenv("SEGMENT_ATTRIBUTES"); attrs != "" {
+		segmentAttrs = strings.Split(attrs, ",")
+	}
+	snapshotRetention, err := ParseSnapshotRetention(os.Getenv("SNAPSHOT_RETENTION"))
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseSnapshotRetention")
+	}
+	var scoreRules ScoreRules
+	if path := os.Getenv("SCORE_RULES_FILE"); path != "" {
+		if scoreRules, err = LoadScoreRules(path); err != nil {
+			return nil, errors.Wrap(err, "LoadScoreRules")
+		}
+	}
 	return &LeaderBoard{
-		redisStorage: db,
+		redisStorage:      db,
+		groupScore:        groupScore,
+		segmentAttrs:      segmentAttrs,
+		snapshotRetention: snapshotRetention,
+		scoreRules:        scoreRules,
 	}, nil
 }
 
-func (lb *LeaderBoard) UserCount(ctx context.Context) (int64, error) {
-	count, err := lb.redisStorage.Count(ctx)
+func (lb *LeaderBoard) UserCount(ctx context.Context) (count int64, err error) {
+	ctx, span := startSpan(ctx, "UserCount", tracing.BoardKey.String(usersBoard))
+	defer func() { endSpan(ctx, span, err) }()
+	count, err = lb.redisStorage.Count(ctx)
 	return count, errors.Wrap(err, "lb.redisStorage.Count")
 }
 
 func (lb *LeaderBoard) AddUser(ctx context.Context, user User) error {
-	return errors.Wrap(lb.redisStorage.Add(ctx, user.Name, user.Score), "lb.redisStorage.Add")
+	_, err := lb.AddUserRank(ctx, user)
+	return err
 }
 
-func (lb *LeaderBoard) GetUser(ctx context.Context, name string) (*UserRank, error) {
-	exists, rank, score, err := lb.redisStorage.Get(ctx, name)
+func (lb *LeaderBoard) AddUserRank(ctx context.Context, user User) (userRank *UserRank, err error) {
+	defer func() { countSubmission(metrics.SubmissionCreated, err) }()
+	ctx, span := startSpan(ctx, "AddUserRank", tracing.BoardKey.String(usersBoard), tracing.UserKey.String(user.Name))
+	defer func() { endSpan(ctx, span, err) }()
+	if err := lb.validateSegments(user.Segments); err != nil {
+		return nil, err
+	}
+	if err := lb.checkScoreRules(ctx, user, false); err != nil {
+		return nil, err
+	}
+	rank, score, segments, err := lb.redisStorage.Add(ctx, user.Name, user.Score, user.Segments, lb.groupScore.mode(), lb.groupScore.TopN)
+	if err != nil {
+		return nil, errors.Wrap(bannedError(err, user.Name), "lb.redisStorage.Add")
+	}
+	return &UserRank{User: User{Name: user.Name, Score: score, Segments: segments}, Rank: rank}, nil
+}
+
+func (lb *LeaderBoard) GetUser(ctx context.Context, name string) (userRank *UserRank, err error) {
+	ctx, span := startSpan(ctx, "GetUser", tracing.BoardKey.String(usersBoard), tracing.UserKey.String(name))
+	defer func() { endSpan(ctx, span, err) }()
+	exists, rank, score, segments, err := lb.redisStorage.Get(ctx, name)
 	if err != nil {
 		return nil, errors.Wrap(err, "lb.redisStorage.Get")
 	} else if !exists {
-		return nil, ErrorWithStatusCode(errors.New("not exists user: "+name), http.StatusNotFound)
+		return lb.getBannedUser(ctx, name)
 	}
 	return &UserRank{
 		User: User{
-			Name:  name,
-			Score: score,
+			Name:     name,
+			Score:    score,
+			Segments: segments,
 		},
 		Rank: rank,
 	}, nil
 }
 
-func (lb *LeaderBoard) DeleteUser(ctx context.Context, name string) (bool, error) {
-	ok, err := lb.redisStorage.Delete(ctx, name)
+func (lb *LeaderBoard) DeleteUser(ctx context.Context, name string) (ok bool, err error) {
+	ctx, span := startSpan(ctx, "DeleteUser", tracing.BoardKey.String(usersBoard), tracing.UserKey.String(name))
+	defer func() { endSpan(ctx, span, err) }()
+	ok, err = lb.redisStorage.Delete(ctx, name, lb.groupScore.mode(), lb.groupScore.TopN)
 	return ok, errors.Wrap(err, "lb.redisStorage.Delete")
 }
 
 func (lb *LeaderBoard) UpdateUser(ctx context.Context, user User) error {
-	exists, err := lb.redisStorage.Update(ctx, user.Name, user.Score)
+	_, err := lb.UpdateUserRank(ctx, user)
+	return err
+}
+
+func (lb *LeaderBoard) UpdateUserRank(ctx context.Context, user User) (userRank *UserRank, err error) {
+	defer func() { countSubmission(metrics.SubmissionUpdated, err) }()
+	ctx, span := startSpan(ctx, "UpdateUserRank", tracing.BoardKey.String(usersBoard), tracing.UserKey.String(user.Name))
+	defer func() { endSpan(ctx, span, err) }()
+	if err := lb.validateSegments(user.Segments); err != nil {
+		return nil, err
+	}
+	if err := lb.checkScoreRules(ctx, user, true); err != nil {
+		return nil, err
+	}
+	exists, rank, score, segments, err := lb.redisStorage.Update(ctx, user.Name, user.Score, user.Segments, lb.groupScore.mode(), lb.groupScore.TopN)
 	if err != nil {
-		return errors.Wrap(err, "lb.redisStorage.Update")
+		return nil, errors.Wrap(bannedError(err, user.Name), "lb.redisStorage.Update")
 	}
 	if !exists {
-		return ErrorWithStatusCode(errors.New("not exists user:"+user.Name), http.StatusNotFound)
+		return nil, ErrorWithStatusCode(errors.New("not exists user:"+user.Name), http.StatusNotFound)
 	}
-	return nil
+	return &UserRank{User: User{Name: user.Name, Score: score, Segments: segments}, Rank: rank}, nil
 }
 
-func (lb *LeaderBoard) GetUserList(ctx context.Context, start int64, stop int64) ([]User, error) {
+func (lb *LeaderBoard) GetUserList(ctx context.Context, start int64, stop int64) (result []User, err error) {
+	ctx, span := startSpan(ctx, "GetUserList", tracing.BoardKey.String(usersBoard), startKey.Int64(start), stopKey.Int64(stop))
+	defer func() { endSpan(ctx, span, err) }()
 	userList, err := lb.redisStorage.Range(ctx, start, stop)
 	if err != nil {
 		return nil, errors.Wrap(err, "lb.redisStorage.Range")
 	}
-	result := make([]User, 0, len(userList))
+	result = make([]User, 0, len(userList))
 	for _, user := range userList {
 		result = append(result, User{
 			Name:  user.Member.(string),
@@ -123,3 +215,26 @@ func (e Error) Unwrap() error {
 func (e Error) StatusCode() int {
 	return e.statusCode
 }
+
+// 기록 결과를 metric에 더합니다. 검토 대기열에 넣었으면 quarantined, 4xx면 rejected. 5xx는 기록 결과가 아니므로 제외
+func countSubmission(outcome string, err error) {
+	if err == nil {
+		metrics.AddSubmission(outcome)
+		return
+	}
+	switch code := StatusCode(err); {
+	case code == http.StatusAccepted:
+		metrics.AddSubmission(metrics.SubmissionQuarantined)
+	case code < http.StatusInternalServerError:
+		metrics.AddSubmission(metrics.SubmissionRejected)
+	}
+}
+
+// err에 담긴 status code를 반환합니다. 없으면 500
+func StatusCode(err error) int {
+	var apiErr interface{ StatusCode() int }
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
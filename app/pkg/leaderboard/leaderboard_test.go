@@ -4,16 +4,42 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"testing"
 
+	"github.com/JeongMinSik/go-leaderboard/pkg/metrics"
 	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
 	"github.com/go-redis/redis/v8"
 	"github.com/go-redis/redismock/v8"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const ZSetKeyName = "scores"
 
+const scriptSHA = "^[0-9a-f]{40}$"
+
+var groupKeys = []string{ZSetKeyName, ZSetKeyName + ":user-groups", ZSetKeyName + ":groups"}
+
+func bannedUserKeys(name string) []string {
+	return []string{ZSetKeyName, ZSetKeyName + ":bans", ZSetKeyName + ":banned", ZSetKeyName + ":user-segments:" + name}
+}
+
+func userKeys(name string) []string {
+	return []string{ZSetKeyName, ZSetKeyName + ":user-segments:" + name, ZSetKeyName + ":bans", ZSetKeyName + ":banned",
+		ZSetKeyName + ":user-groups", ZSetKeyName + ":groups"}
+}
+
+// getScript의 KEYS. segment board도 user의 segment hash는 전체 board key로 만듦
+func getKeys(key string, name string) []string {
+	return []string{key, ZSetKeyName + ":user-segments:" + name}
+}
+
+// getScript는 {score, rank, segments}
+func expectGet(mock redismock.ClientMock, key string, name string, score float64, rank int64, segments ...interface{}) {
+	mock.Regexp().ExpectEvalSha(scriptSHA, getKeys(key, name), name).SetVal([]interface{}{strconv.FormatFloat(score, 'f', -1, 64), rank, append([]interface{}{}, segments...)})
+}
+
 func TestNew(t *testing.T) {
 	_, err := New()
 	assert.ErrorContains(t, err, "empty redis addr")
@@ -34,6 +60,46 @@ func TestErrorWithStatusCode(t *testing.T) {
 	}
 }
 
+func submissionCount(t *testing.T, outcome string) float64 {
+	families, err := metrics.Default.Registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "leaderboard_submissions_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if metric.GetLabel()[0].GetValue() == outcome {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestCountSubmission(t *testing.T) {
+	outcomes := []string{metrics.SubmissionCreated, metrics.SubmissionUpdated, metrics.SubmissionRejected, metrics.SubmissionQuarantined}
+	before := map[string]float64{}
+	for _, outcome := range outcomes {
+		before[outcome] = submissionCount(t, outcome)
+	}
+
+	countSubmission(metrics.SubmissionCreated, nil)
+	countSubmission(metrics.SubmissionUpdated, ErrorWithStatusCode(errors.New("rejected by users rule"), http.StatusBadRequest))
+	countSubmission(metrics.SubmissionCreated, ErrorWithStatusCode(errors.New("banned user"), http.StatusForbidden))
+	countSubmission(metrics.SubmissionCreated, ErrorWithStatusCode(errors.New("submission is under review"), http.StatusAccepted))
+	// redis error는 기록 결과가 아님
+	countSubmission(metrics.SubmissionUpdated, errors.New("redis down"))
+
+	for outcome, added := range map[string]float64{
+		metrics.SubmissionCreated:     1,
+		metrics.SubmissionUpdated:     0,
+		metrics.SubmissionRejected:    2,
+		metrics.SubmissionQuarantined: 1,
+	} {
+		assert.Equal(t, before[outcome]+added, submissionCount(t, outcome), outcome)
+	}
+}
+
 func TestUserCount(t *testing.T) {
 	ctx := context.Background()
 	db, mock := redismock.NewClientMock()
@@ -60,10 +126,7 @@ func TestAddUser(t *testing.T) {
 		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
 	}
 
-	mock.ExpectZAddNX(ZSetKeyName, &redis.Z{
-		Score:  100,
-		Member: "Minsik",
-	}).SetVal(1)
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Minsik"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Minsik", float64(100), ZSetKeyName+":group:", GroupScoreSum, 0).SetVal([]interface{}{int64(1), "100", int64(0), []interface{}{}})
 
 	err := lb.AddUser(ctx, User{
 		Name:  "Minsik",
@@ -71,6 +134,37 @@ func TestAddUser(t *testing.T) {
 	})
 	assert.NoError(t, err)
 
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Yumi"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Yumi", float64(200), ZSetKeyName+":group:", GroupScoreSum, 0,
+		"country", "KR", "platform", "ios").SetVal([]interface{}{int64(1), "200", int64(1), []interface{}{"country", "KR", "platform", "ios"}})
+
+	// 기록한 score와 rank, 저장된 segment를 함께 반환
+	userRank, err := lb.AddUserRank(ctx, User{
+		Name:     "Yumi",
+		Score:    200,
+		Segments: map[string]string{"platform": "ios", "country": "KR"},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, UserRank{User: User{Name: "Yumi", Score: 200, Segments: map[string]string{"platform": "ios", "country": "KR"}}, Rank: 1}, *userRank)
+	}
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Minsik"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Minsik", float64(300), ZSetKeyName+":group:", GroupScoreSum, 0).SetVal([]interface{}{int64(0)})
+	err = lb.AddUser(ctx, User{
+		Name:  "Minsik",
+		Score: 300,
+	})
+	assert.ErrorContains(t, err, "already exists user")
+
+	lb.segmentAttrs = []string{"country"}
+	err = lb.AddUser(ctx, User{
+		Name:     "Foo",
+		Score:    100,
+		Segments: map[string]string{"platform": "ios"},
+	})
+	var apiErr interface{ StatusCode() int }
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	}
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Error(err)
 	}
@@ -83,24 +177,21 @@ func TestGetUser(t *testing.T) {
 		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
 	}
 
-	mock.ExpectTxPipeline()
-	mock.ExpectZScore(ZSetKeyName, "Minsik").SetVal(999)
-	mock.ExpectZRank(ZSetKeyName, "Minsik").SetVal(4)
-	mock.ExpectTxPipelineExec()
+	expectGet(mock, ZSetKeyName, "Minsik", 999, 4, "country", "KR")
 
 	userRank, err := lb.GetUser(ctx, "Minsik")
 	if assert.NoError(t, err) {
 		assert.Equal(t, UserRank{
 			User: User{
-				Name:  "Minsik",
-				Score: 999,
+				Name:     "Minsik",
+				Score:    999,
+				Segments: map[string]string{"country": "KR"},
 			},
 			Rank: 4,
 		}, *userRank)
 	}
 
-	mock.ExpectTxPipeline()
-	mock.ExpectZScore(ZSetKeyName, "Foo").RedisNil()
+	mock.Regexp().ExpectEvalSha(scriptSHA, getKeys(ZSetKeyName, "Foo"), "Foo").RedisNil()
 	_, err = lb.GetUser(ctx, "Foo")
 	var apiErr interface{ StatusCode() int }
 	if assert.ErrorAs(t, err, &apiErr) {
@@ -119,7 +210,7 @@ func TestDeleteUser(t *testing.T) {
 		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
 	}
 
-	mock.ExpectZRem(ZSetKeyName, "Minsik").SetVal(1)
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Minsik"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Minsik", ZSetKeyName+":group:", GroupScoreSum, 0).SetVal(int64(1))
 
 	ok, err := lb.DeleteUser(ctx, "Minsik")
 
@@ -139,22 +230,17 @@ func TestUpdateUser(t *testing.T) {
 		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
 	}
 
-	mock.ExpectZAddXX(ZSetKeyName, &redis.Z{
-		Score:  100,
-		Member: "Minsik",
-	}).SetVal(1)
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Minsik"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Minsik", float64(100), ZSetKeyName+":group:", GroupScoreSum, 0).SetVal([]interface{}{int64(1), "100", int64(2), []interface{}{}})
 
-	err := lb.UpdateUser(ctx, User{
+	userRank, err := lb.UpdateUserRank(ctx, User{
 		Name:  "Minsik",
 		Score: 100,
 	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, UserRank{User: User{Name: "Minsik", Score: 100}, Rank: 2}, *userRank)
+	}
 
-	assert.NoError(t, err)
-
-	mock.ExpectZAddXX(ZSetKeyName, &redis.Z{
-		Score:  200,
-		Member: "Foo",
-	}).SetVal(0)
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Foo"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Foo", float64(200), ZSetKeyName+":group:", GroupScoreSum, 0).SetVal([]interface{}{int64(0)})
 
 	err = lb.UpdateUser(ctx, User{
 		Name:  "Foo",
@@ -0,0 +1,151 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/pkg/errors"
+)
+
+const DefaultMoversSize = 10
+
+// snapshot 이후 순위 변화
+type MoversInterface interface {
+	// 목록마다 최대 limit명을 반환합니다. limit은 MaxPageSize를 넘을 수 없습니다.
+	GetMovers(ctx context.Context, id string, limit int64) (*Movers, error)
+}
+
+type Movers struct {
+	Snapshot Snapshot `json:"snapshot"`
+	// 순위가 가장 많이 오른 user부터
+	Climbers []UserMove `json:"climbers"`
+	// 순위가 가장 많이 내려간 user부터
+	Fallers []UserMove `json:"fallers"`
+	// snapshot 이후 추가된 user. 현재 순위 순서
+	NewUsers     []UserRank `json:"new_users"`
+	NewUserCount int64      `json:"new_user_count"`
+	// snapshot 이후 삭제된 user. snapshot 순위 순서
+	DroppedUsers     []UserRank `json:"dropped_users"`
+	DroppedUserCount int64      `json:"dropped_user_count"`
+}
+
+// RankDelta는 올라간 순위 수(PrevRank - Rank), ScoreDelta는 Score - PrevScore
+type UserMove struct {
+	Name       string  `json:"name"`
+	Rank       int64   `json:"rank"`
+	PrevRank   int64   `json:"prev_rank"`
+	Score      float64 `json:"score"`
+	PrevScore  float64 `json:"prev_score"`
+	RankDelta  int64   `json:"rank_delta"`
+	ScoreDelta float64 `json:"score_delta"`
+}
+
+// storage의 user를 순위 순서대로 TransferBatchSize명씩 읽어 fn에 넘깁니다.
+func eachUserRank(ctx context.Context, storage *redisstorage.RedisStorage, fn func(UserRank)) error {
+	for start := int64(0); ; start += TransferBatchSize {
+		userList, err := storage.Range(ctx, start, start+TransferBatchSize-1)
+		if err != nil {
+			return errors.Wrap(err, "storage.Range")
+		}
+		for i, user := range userList {
+			fn(UserRank{
+				User: User{Name: user.Member.(string), Score: user.Score},
+				Rank: start + int64(i),
+			})
+		}
+		if len(userList) < TransferBatchSize {
+			return nil
+		}
+	}
+}
+
+// 두 board를 모두 읽어서 비교하므로 user 수만큼 메모리를 사용합니다.
+// 읽는 동안 바뀐 점수는 일부만 반영될 수 있습니다.
+func (lb *LeaderBoard) GetMovers(ctx context.Context, id string, limit int64) (*Movers, error) {
+	limit, err := pageSize(limit)
+	if err != nil {
+		return nil, err
+	}
+	exists, createdAt, count, err := lb.redisStorage.SnapshotInfo(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.SnapshotInfo")
+	}
+	if !exists {
+		return nil, ErrorWithStatusCode(errors.New("not exists snapshot: "+id), http.StatusNotFound)
+	}
+
+	prevRanks := map[string]UserRank{}
+	prevOrder := make([]string, 0, count)
+	err = eachUserRank(ctx, lb.redisStorage.SnapshotBoard(id), func(userRank UserRank) {
+		prevRanks[userRank.Name] = userRank
+		prevOrder = append(prevOrder, userRank.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	movers := &Movers{
+		Snapshot:     Snapshot{ID: id, CreatedAt: time.Unix(createdAt, 0).UTC(), Count: count},
+		Climbers:     []UserMove{},
+		Fallers:      []UserMove{},
+		NewUsers:     []UserRank{},
+		DroppedUsers: []UserRank{},
+	}
+	current := map[string]struct{}{}
+	err = eachUserRank(ctx, lb.redisStorage, func(userRank UserRank) {
+		current[userRank.Name] = struct{}{}
+		prev, ok := prevRanks[userRank.Name]
+		if !ok {
+			movers.NewUserCount++
+			if int64(len(movers.NewUsers)) < limit {
+				movers.NewUsers = append(movers.NewUsers, userRank)
+			}
+			return
+		}
+		move := UserMove{
+			Name:       userRank.Name,
+			Rank:       userRank.Rank,
+			PrevRank:   prev.Rank,
+			Score:      userRank.Score,
+			PrevScore:  prev.Score,
+			RankDelta:  prev.Rank - userRank.Rank,
+			ScoreDelta: userRank.Score - prev.Score,
+		}
+		if move.RankDelta > 0 {
+			movers.Climbers = append(movers.Climbers, move)
+		} else if move.RankDelta < 0 {
+			movers.Fallers = append(movers.Fallers, move)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range prevOrder {
+		if _, ok := current[name]; ok {
+			continue
+		}
+		movers.DroppedUserCount++
+		if int64(len(movers.DroppedUsers)) < limit {
+			movers.DroppedUsers = append(movers.DroppedUsers, prevRanks[name])
+		}
+	}
+
+	// 변화량이 같으면 현재 순위 순서
+	sort.SliceStable(movers.Climbers, func(i, j int) bool {
+		return movers.Climbers[i].RankDelta > movers.Climbers[j].RankDelta
+	})
+	sort.SliceStable(movers.Fallers, func(i, j int) bool {
+		return movers.Fallers[i].RankDelta < movers.Fallers[j].RankDelta
+	})
+	if int64(len(movers.Climbers)) > limit {
+		movers.Climbers = movers.Climbers[:limit]
+	}
+	if int64(len(movers.Fallers)) > limit {
+		movers.Fallers = movers.Fallers[:limit]
+	}
+	return movers, nil
+}
@@ -0,0 +1,89 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMovers(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+	const id = "20220701T120000Z"
+	created := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectZScore(ZSetKeyName+":snapshots", id).SetVal(float64(created.Unix()))
+	mock.ExpectZCard(ZSetKeyName + ":snapshot:" + id).SetVal(4)
+	mock.ExpectZRangeWithScores(ZSetKeyName+":snapshot:"+id, 0, TransferBatchSize-1).SetVal([]redis.Z{
+		{Score: 10, Member: "A"},
+		{Score: 20, Member: "B"},
+		{Score: 30, Member: "C"},
+		{Score: 40, Member: "D"},
+	})
+	mock.ExpectZRangeWithScores(ZSetKeyName, 0, TransferBatchSize-1).SetVal([]redis.Z{
+		{Score: 5, Member: "B"},
+		{Score: 15, Member: "A"},
+		{Score: 50, Member: "D"},
+		{Score: 60, Member: "E"},
+	})
+
+	movers, err := lb.GetMovers(ctx, id, 10)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Movers{
+			Snapshot: Snapshot{ID: id, CreatedAt: created, Count: 4},
+			Climbers: []UserMove{
+				{Name: "B", Rank: 0, PrevRank: 1, Score: 5, PrevScore: 20, RankDelta: 1, ScoreDelta: -15},
+				{Name: "D", Rank: 2, PrevRank: 3, Score: 50, PrevScore: 40, RankDelta: 1, ScoreDelta: 10},
+			},
+			Fallers: []UserMove{
+				{Name: "A", Rank: 1, PrevRank: 0, Score: 15, PrevScore: 10, RankDelta: -1, ScoreDelta: 5},
+			},
+			NewUsers:         []UserRank{{User: User{Name: "E", Score: 60}, Rank: 3}},
+			NewUserCount:     1,
+			DroppedUsers:     []UserRank{{User: User{Name: "C", Score: 30}, Rank: 2}},
+			DroppedUserCount: 1,
+		}, movers)
+	}
+
+	// limit보다 많으면 잘라냄
+	mock.ExpectZScore(ZSetKeyName+":snapshots", id).SetVal(float64(created.Unix()))
+	mock.ExpectZCard(ZSetKeyName + ":snapshot:" + id).SetVal(2)
+	mock.ExpectZRangeWithScores(ZSetKeyName+":snapshot:"+id, 0, TransferBatchSize-1).SetVal([]redis.Z{
+		{Score: 10, Member: "A"},
+		{Score: 20, Member: "B"},
+	})
+	mock.ExpectZRangeWithScores(ZSetKeyName, 0, TransferBatchSize-1).SetVal([]redis.Z{
+		{Score: 1, Member: "E"},
+		{Score: 2, Member: "F"},
+	})
+	movers, err = lb.GetMovers(ctx, id, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []UserRank{{User: User{Name: "E", Score: 1}, Rank: 0}}, movers.NewUsers)
+		assert.Equal(t, int64(2), movers.NewUserCount)
+		assert.Equal(t, []UserRank{{User: User{Name: "A", Score: 10}, Rank: 0}}, movers.DroppedUsers)
+		assert.Equal(t, int64(2), movers.DroppedUserCount)
+		assert.Empty(t, movers.Climbers)
+	}
+
+	// 없는 snapshot
+	mock.ExpectZScore(ZSetKeyName+":snapshots", id).RedisNil()
+	_, err = lb.GetMovers(ctx, id, 10)
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+
+	// 잘못된 limit
+	_, err = lb.GetMovers(ctx, id, 0)
+	assert.Equal(t, http.StatusBadRequest, StatusCode(err))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,117 @@
+package leaderboard
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/JeongMinSik/go-leaderboard/pkg/tracing"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type PageInterface interface {
+	GetUserPage(ctx context.Context, cursor string, limit int64) (*UserPage, error)
+}
+
+type UserPage struct {
+	Users      []User `json:"users"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// 마지막으로 받은 user의 위치. 점수가 바뀌어도 이미 받은 user가 다시 나오거나 건너뛰지 않도록
+// index 대신 (score, name)을 기준으로 다음 page를 찾습니다.
+type pageCursor struct {
+	Score float64 `json:"s"`
+	Name  string  `json:"n"`
+}
+
+func encodeCursor(user User) string {
+	data, _ := json.Marshal(pageCursor{Score: user.Score, Name: user.Name})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (*redis.Z, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	invalid := ErrorWithStatusCode(errors.New("invalid cursor"), http.StatusBadRequest)
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	c := pageCursor{}
+	if err := json.Unmarshal(data, &c); err != nil || c.Name == "" {
+		return nil, invalid
+	}
+	return &redis.Z{Score: c.Score, Member: c.Name}, nil
+}
+
+// limit은 MaxPageSize를 넘을 수 없습니다.
+func pageSize(limit int64) (int64, error) {
+	if limit <= 0 {
+		return 0, ErrorWithStatusCode(errors.New("invalid limit"), http.StatusBadRequest)
+	}
+	if limit > MaxPageSize {
+		return MaxPageSize, nil
+	}
+	return limit, nil
+}
+
+func userPage(ctx context.Context, storage *redisstorage.RedisStorage, cursor string, limit int64) (*UserPage, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit, err = pageSize(limit)
+	if err != nil {
+		return nil, err
+	}
+	total, err := storage.Count(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "storage.Count")
+	}
+	// 다음 page가 있는지 확인하기 위해 하나 더 읽음
+	userList, err := storage.RangeAfter(ctx, after, limit+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "storage.RangeAfter")
+	}
+	page := &UserPage{
+		Users: make([]User, 0, limit),
+		Total: total,
+	}
+	for i, user := range userList {
+		if int64(i) == limit {
+			page.NextCursor = encodeCursor(page.Users[i-1])
+			break
+		}
+		page.Users = append(page.Users, User{
+			Name:  user.Member.(string),
+			Score: user.Score,
+		})
+	}
+	return page, nil
+}
+
+func (lb *LeaderBoard) GetUserPage(ctx context.Context, cursor string, limit int64) (page *UserPage, err error) {
+	ctx, span := startSpan(ctx, "GetUserPage", tracing.BoardKey.String(usersBoard))
+	defer func() { endSpan(ctx, span, err) }()
+	return userPage(ctx, lb.redisStorage, cursor, limit)
+}
+
+func (lb *LeaderBoard) GetSegmentUserPage(ctx context.Context, segment Segment, cursor string, limit int64) (page *UserPage, err error) {
+	ctx, span := startSpan(ctx, "GetSegmentUserPage", segment.boardAttr())
+	defer func() { endSpan(ctx, span, err) }()
+	if err := lb.validateSegment(segment.Attr, segment.Value); err != nil {
+		return nil, err
+	}
+	return userPage(ctx, lb.redisStorage.Segment(segment.Attr, segment.Value), cursor, limit)
+}
@@ -0,0 +1,103 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	cursor := encodeCursor(User{Name: "Minsik", Score: 100.5})
+	after, err := decodeCursor(cursor)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &redis.Z{Score: 100.5, Member: "Minsik"}, after)
+	}
+
+	after, err = decodeCursor("")
+	if assert.NoError(t, err) {
+		assert.Nil(t, after)
+	}
+
+	for _, cursor := range []string{"!!", "bm90IGpzb24", "e30"} {
+		_, err = decodeCursor(cursor)
+		var apiErr interface{ StatusCode() int }
+		if assert.ErrorAs(t, err, &apiErr, cursor) {
+			assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		}
+	}
+}
+
+func TestGetUserPage(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+
+	// 첫 page
+	mock.ExpectZCount(ZSetKeyName, "-inf", "+inf").SetVal(4)
+	mock.ExpectZRangeByScoreWithScores(ZSetKeyName, &redis.ZRangeBy{
+		Min: "-inf", Max: "+inf", Offset: 0, Count: 3,
+	}).SetVal([]redis.Z{
+		{Score: 100, Member: "FooFoo"},
+		{Score: 200, Member: "Foo"},
+		{Score: 200, Member: "Minsik"},
+	})
+
+	page, err := lb.GetUserPage(ctx, "", 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []User{
+			{Name: "FooFoo", Score: 100},
+			{Name: "Foo", Score: 200},
+		}, page.Users)
+		assert.Equal(t, int64(4), page.Total)
+		assert.Equal(t, encodeCursor(User{Name: "Foo", Score: 200}), page.NextCursor)
+	}
+
+	// 다음 page: 같은 score의 Foo 이하는 건너뜀
+	mock.ExpectZCount(ZSetKeyName, "-inf", "+inf").SetVal(4)
+	mock.ExpectZRangeByScoreWithScores(ZSetKeyName, &redis.ZRangeBy{
+		Min: "200", Max: "+inf", Offset: 0, Count: 3,
+	}).SetVal([]redis.Z{
+		{Score: 200, Member: "Foo"},
+		{Score: 200, Member: "Minsik"},
+		{Score: 500, Member: "Yumi"},
+	})
+	mock.ExpectZRangeByScoreWithScores(ZSetKeyName, &redis.ZRangeBy{
+		Min: "200", Max: "+inf", Offset: 3, Count: 3,
+	}).SetVal([]redis.Z{})
+
+	page, err = lb.GetUserPage(ctx, page.NextCursor, 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []User{
+			{Name: "Minsik", Score: 200},
+			{Name: "Yumi", Score: 500},
+		}, page.Users)
+		assert.Empty(t, page.NextCursor)
+	}
+
+	// limit 확인
+	_, err = lb.GetUserPage(ctx, "", 0)
+	var apiErr interface{ StatusCode() int }
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	}
+
+	mock.ExpectZCount(ZSetKeyName, "-inf", "+inf").SetVal(0)
+	mock.ExpectZRangeByScoreWithScores(ZSetKeyName, &redis.ZRangeBy{
+		Min: "-inf", Max: "+inf", Offset: 0, Count: MaxPageSize + 1,
+	}).SetVal([]redis.Z{})
+	page, err = lb.GetUserPage(ctx, "", 1000)
+	if assert.NoError(t, err) {
+		assert.Empty(t, page.Users)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,283 @@
+package leaderboard
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 규칙을 어긴 점수 기록의 처리 방법
+type RuleAction string
+
+const (
+	// 기록하지 않고 400
+	RuleReject RuleAction = "reject"
+	// 기록하지 않고 검토 대기열에 넣은 뒤 202. 운영자가 승인하면 기록
+	RuleQuarantine RuleAction = "quarantine"
+)
+
+// user board는 "users", segment board는 "segment:<attr>:<value>"
+const usersBoard = "users"
+
+// board별 점수 기록 규칙. 0인 항목은 확인하지 않습니다.
+type ScoreRule struct {
+	// 점수 절대값의 최대값
+	MaxScore float64 `json:"max_score,omitempty"`
+	// 기존 user의 한 번 수정에서 바뀔 수 있는 점수의 최대값
+	MaxDelta float64 `json:"max_delta,omitempty"`
+	// user별 분당 최대 기록 횟수. 다른 규칙을 통과한 기록만 세며, 넘으면 action과 관계없이 429
+	MaxPerMinute int64 `json:"max_per_minute,omitempty"`
+	// 기본 reject
+	Action RuleAction `json:"action,omitempty"`
+}
+
+type ScoreRules map[string]ScoreRule
+
+// 검토 대기 중인 점수 기록
+type Review struct {
+	ID   string `json:"id"`
+	User User   `json:"user"`
+	// 기존 user 수정이면 true
+	Update    bool      `json:"update"`
+	Board     string    `json:"board"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReviewInterface interface {
+	// 오래된 기록부터 반환합니다.
+	Reviews(ctx context.Context) ([]Review, error)
+	// 규칙을 확인하지 않고 기록한 뒤 대기열에서 지웁니다.
+	ApproveReview(ctx context.Context, id string) error
+	DiscardReview(ctx context.Context, id string) (bool, error)
+}
+
+// 파일 형식: {"users": {"max_score": 1000000, "max_delta": 10000, "max_per_minute": 10, "action": "quarantine"}, "segment:country:KR": {...}}
+func LoadScoreRules(path string) (ScoreRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile")
+	}
+	rules := ScoreRules{}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	for board, rule := range rules {
+		if board != usersBoard {
+			if _, err := ParseSegment(strings.TrimPrefix(board, "segment:")); err != nil || !strings.HasPrefix(board, "segment:") {
+				return nil, errors.New("invalid rule board: " + board)
+			}
+		}
+		if rule.MaxScore < 0 || rule.MaxDelta < 0 || rule.MaxPerMinute < 0 {
+			return nil, errors.New("invalid rule: " + board)
+		}
+		switch rule.Action {
+		case "":
+			rule.Action = RuleReject
+			rules[board] = rule
+		case RuleReject, RuleQuarantine:
+		default:
+			return nil, errors.New("invalid rule action: " + string(rule.Action))
+		}
+	}
+	return rules, nil
+}
+
+// 어긴 점수 규칙의 설명. 어기지 않았으면 "". 기록 횟수는 checkSubmissionCount에서 확인
+func (r ScoreRule) violation(score float64, prevScore *float64) string {
+	if r.MaxScore > 0 && math.Abs(score) > r.MaxScore {
+		return "score exceeds " + strconv.FormatFloat(r.MaxScore, 'f', -1, 64)
+	}
+	if r.MaxDelta > 0 && prevScore != nil && math.Abs(score-*prevScore) > r.MaxDelta {
+		return "score delta exceeds " + strconv.FormatFloat(r.MaxDelta, 'f', -1, 64)
+	}
+	return ""
+}
+
+func (lb *LeaderBoard) hasScoreRule(board string) bool {
+	_, ok := lb.scoreRules[board]
+	return ok
+}
+
+// user가 기록되는 board 중 규칙이 있는 board. "users"가 먼저, segment board는 이름 순
+func (lb *LeaderBoard) ruleBoards(segments map[string]string) []string {
+	boards := []string{}
+	for attr, value := range segments {
+		if board := "segment:" + attr + ":" + value; lb.hasScoreRule(board) {
+			boards = append(boards, board)
+		}
+	}
+	sort.Strings(boards)
+	if lb.hasScoreRule(usersBoard) {
+		boards = append([]string{usersBoard}, boards...)
+	}
+	return boards
+}
+
+// 점수 규칙을 어기면 action에 따라 reject 에러를 반환하거나 검토 대기열에 넣고 202 에러를 반환합니다.
+func (lb *LeaderBoard) checkScoreRules(ctx context.Context, user User, update bool) error {
+	if len(lb.scoreRules) == 0 {
+		return nil
+	}
+	segments := user.Segments
+	var prevScore *float64
+	if update {
+		exists, _, score, _, err := lb.redisStorage.Get(ctx, user.Name)
+		if err != nil {
+			return errors.Wrap(err, "lb.redisStorage.Get")
+		}
+		if !exists {
+			return nil
+		}
+		prevScore = &score
+		// 수정할 때 보내지 않은 segment는 기존 값을 유지하므로 함께 확인
+		userSegments, err := lb.redisStorage.UserSegments(ctx, []string{user.Name})
+		if err != nil {
+			return errors.Wrap(err, "lb.redisStorage.UserSegments")
+		}
+		segments = map[string]string{}
+		for attr, value := range userSegments[0] {
+			segments[attr] = value
+		}
+		for attr, value := range user.Segments {
+			segments[attr] = value
+		}
+	}
+	boards := lb.ruleBoards(segments)
+	for _, board := range boards {
+		rule := lb.scoreRules[board]
+		reason := rule.violation(user.Score, prevScore)
+		if reason == "" {
+			continue
+		}
+		if rule.Action != RuleQuarantine {
+			return ErrorWithStatusCode(errors.New("rejected by "+board+" rule: "+reason), http.StatusBadRequest)
+		}
+		review := Review{User: user, Update: update, Board: board, Reason: reason, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+		data, err := json.Marshal(review)
+		if err != nil {
+			return errors.Wrap(err, "json.Marshal")
+		}
+		id, err := lb.redisStorage.AddReview(ctx, string(data))
+		if err != nil {
+			return errors.Wrap(err, "lb.redisStorage.AddReview")
+		}
+		return ErrorWithStatusCode(errors.New("submission is under review: "+id), http.StatusAccepted)
+	}
+	return lb.checkSubmissionCount(ctx, user.Name, boards)
+}
+
+// import는 운영 기능이므로 규칙을 어긴 record를 검토 대기열에 넣지 않고 거절합니다. 분당 기록 횟수도 세지 않음
+func (lb *LeaderBoard) checkImportRules(score float64, prevScore *float64, segments map[string]string) error {
+	if len(lb.scoreRules) == 0 {
+		return nil
+	}
+	for _, board := range lb.ruleBoards(segments) {
+		if reason := lb.scoreRules[board].violation(score, prevScore); reason != "" {
+			return errors.New("rejected by " + board + " rule: " + reason)
+		}
+	}
+	return nil
+}
+
+// 점수 규칙을 통과한 기록만 세고, 분당 기록 횟수를 넘으면 검토 대기열에 넣지 않고 429를 반환합니다.
+// 반복해서 보내는 client가 검토 대기열을 끝없이 늘리지 못하도록 quarantine 규칙이어도 거절
+func (lb *LeaderBoard) checkSubmissionCount(ctx context.Context, name string, boards []string) error {
+	var count int64
+	for _, board := range boards {
+		rule := lb.scoreRules[board]
+		if rule.MaxPerMinute <= 0 {
+			continue
+		}
+		if count == 0 {
+			var err error
+			if count, err = lb.redisStorage.CountSubmission(ctx, name, time.Now(), time.Minute); err != nil {
+				return errors.Wrap(err, "lb.redisStorage.CountSubmission")
+			}
+		}
+		if count > rule.MaxPerMinute {
+			reason := "more than " + strconv.FormatInt(rule.MaxPerMinute, 10) + " submissions per minute"
+			return ErrorWithStatusCode(errors.New("rejected by "+board+" rule: "+reason), http.StatusTooManyRequests)
+		}
+	}
+	return nil
+}
+
+func (lb *LeaderBoard) Reviews(ctx context.Context) ([]Review, error) {
+	reviews, err := lb.redisStorage.Reviews(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.Reviews")
+	}
+	result := make([]Review, 0, len(reviews))
+	for id, data := range reviews {
+		review := Review{}
+		if err := json.Unmarshal([]byte(data), &review); err != nil {
+			return nil, errors.Wrap(err, "json.Unmarshal")
+		}
+		review.ID = id
+		result = append(result, review)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, _ := strconv.ParseInt(result[i].ID, 10, 64)
+		b, _ := strconv.ParseInt(result[j].ID, 10, 64)
+		return a < b
+	})
+	return result, nil
+}
+
+// 대기열에서 먼저 지워서 같은 기록을 두 번 승인하지 않도록 합니다.
+// 추가 요청이었어도 그 사이 user가 생겼으면 수정하고, 수정 요청이었어도 user가 삭제됐으면 추가합니다.
+func (lb *LeaderBoard) ApproveReview(ctx context.Context, id string) error {
+	ok, data, err := lb.redisStorage.Review(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "lb.redisStorage.Review")
+	}
+	if !ok {
+		return ErrorWithStatusCode(errors.New("not exists review: "+id), http.StatusNotFound)
+	}
+	review := Review{}
+	if err := json.Unmarshal([]byte(data), &review); err != nil {
+		return errors.Wrap(err, "json.Unmarshal")
+	}
+	deleted, err := lb.redisStorage.DeleteReview(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "lb.redisStorage.DeleteReview")
+	}
+	if !deleted {
+		return ErrorWithStatusCode(errors.New("not exists review: "+id), http.StatusNotFound)
+	}
+	if err := lb.writeReview(ctx, review.User); err != nil {
+		// 기록하지 못했으면 대기열에 되돌림
+		if restoreErr := lb.redisStorage.SetReview(ctx, id, data); restoreErr != nil {
+			return errors.Wrap(restoreErr, "lb.redisStorage.SetReview")
+		}
+		return err
+	}
+	return nil
+}
+
+func (lb *LeaderBoard) writeReview(ctx context.Context, user User) error {
+	exists, _, _, _, err := lb.redisStorage.Update(ctx, user.Name, user.Score, user.Segments, lb.groupScore.mode(), lb.groupScore.TopN)
+	if err != nil {
+		return errors.Wrap(err, "lb.redisStorage.Update")
+	}
+	if !exists {
+		if _, _, _, err := lb.redisStorage.Add(ctx, user.Name, user.Score, user.Segments, lb.groupScore.mode(), lb.groupScore.TopN); err != nil {
+			return errors.Wrap(err, "lb.redisStorage.Add")
+		}
+	}
+	return nil
+}
+
+func (lb *LeaderBoard) DiscardReview(ctx context.Context, id string) (bool, error) {
+	ok, err := lb.redisStorage.DeleteReview(ctx, id)
+	return ok, errors.Wrap(err, "lb.redisStorage.DeleteReview")
+}
@@ -0,0 +1,216 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const submissionsKey = `^scores:submissions:Alice:\d+$`
+
+func TestLoadScoreRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	data := `{"users": {"max_score": 1000}, "segment:country:KR": {"max_delta": 10, "action": "quarantine"}}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	rules, err := LoadScoreRules(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, ScoreRules{
+			"users":              {MaxScore: 1000, Action: RuleReject},
+			"segment:country:KR": {MaxDelta: 10, Action: RuleQuarantine},
+		}, rules)
+	}
+
+	for _, invalid := range []string{
+		`{"groups": {"max_score": 1000}}`,
+		`{"segment:country": {"max_score": 1000}}`,
+		`{"users": {"max_score": -1}}`,
+		`{"users": {"action": "ban"}}`,
+		`[]`,
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(invalid), 0o600))
+		_, err := LoadScoreRules(path)
+		assert.Error(t, err, invalid)
+	}
+	_, err = LoadScoreRules("not-exists.json")
+	assert.Error(t, err)
+}
+
+func TestScoreRuleViolation(t *testing.T) {
+	rule := ScoreRule{MaxScore: 1000, MaxDelta: 100, MaxPerMinute: 3}
+	prevScore := 500.0
+	assert.Equal(t, "", rule.violation(550, &prevScore))
+	assert.Equal(t, "", rule.violation(-1000, nil))
+	assert.Equal(t, "score exceeds 1000", rule.violation(1e15, nil))
+	assert.Equal(t, "score delta exceeds 100", rule.violation(650, &prevScore))
+}
+
+func TestAddUserScoreRules(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+		scoreRules: ScoreRules{
+			"users":              {MaxScore: 1000, Action: RuleReject},
+			"segment:country:KR": {MaxScore: 100, MaxPerMinute: 2, Action: RuleQuarantine},
+		},
+	}
+
+	// users 규칙만 적용
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Alice"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Alice", float64(500), ZSetKeyName+":group:", GroupScoreSum, 0).SetVal([]interface{}{int64(1), "500", int64(0), []interface{}{}})
+	assert.NoError(t, lb.AddUser(ctx, User{Name: "Alice", Score: 500}))
+
+	err := lb.AddUser(ctx, User{Name: "Bob", Score: 1e15})
+	assert.Equal(t, http.StatusBadRequest, StatusCode(err))
+	assert.EqualError(t, err, "rejected by users rule: score exceeds 1000")
+
+	// segment:country:KR 규칙을 어기면 검토 대기열로. 규칙을 어긴 기록은 기록 횟수에 세지 않음
+	mock.ExpectIncr(ZSetKeyName + ":review-id").SetVal(7)
+	mock.CustomMatch(func(expected, actual []interface{}) error {
+		assert.Equal(t, "hset", actual[0])
+		assert.Equal(t, ZSetKeyName+":reviews", actual[1])
+		assert.Equal(t, "7", actual[2])
+		assert.Contains(t, actual[3], `"board":"segment:country:KR","reason":"score exceeds 100"`)
+		return nil
+	}).ExpectHSet(ZSetKeyName+":reviews", "7", "").SetVal(1)
+	err = lb.AddUser(ctx, User{Name: "Alice", Score: 500, Segments: map[string]string{"country": "KR"}})
+	assert.Equal(t, http.StatusAccepted, StatusCode(err))
+	assert.EqualError(t, err, "submission is under review: 7")
+
+	// 분당 기록 횟수를 넘으면 quarantine 규칙이어도 검토 대기열에 넣지 않고 429
+	expectCount := func(count int64) {
+		mock.ExpectTxPipeline()
+		mock.Regexp().ExpectIncr(submissionsKey).SetVal(count)
+		mock.Regexp().ExpectExpire(submissionsKey, time.Minute).SetVal(true)
+		mock.ExpectTxPipelineExec()
+	}
+	expectCount(2)
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Alice"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Alice", float64(50), ZSetKeyName+":group:", GroupScoreSum, 0, "country", "KR").SetVal([]interface{}{int64(1), "50", int64(0), []interface{}{}})
+	assert.NoError(t, lb.AddUser(ctx, User{Name: "Alice", Score: 50, Segments: map[string]string{"country": "KR"}}))
+	expectCount(3)
+	err = lb.AddUser(ctx, User{Name: "Alice", Score: 50, Segments: map[string]string{"country": "KR"}})
+	assert.Equal(t, http.StatusTooManyRequests, StatusCode(err))
+	assert.EqualError(t, err, "rejected by segment:country:KR rule: more than 2 submissions per minute")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpdateUserScoreRules(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+		scoreRules: ScoreRules{
+			"segment:country:KR": {MaxDelta: 100, MaxPerMinute: 2, Action: RuleReject},
+		},
+	}
+	expectCurrent := func() {
+		expectGet(mock, ZSetKeyName, "Alice", 500, 0)
+		// 기존 segment에 적용된 규칙도 확인
+		mock.ExpectHGetAll(ZSetKeyName + ":user-segments:Alice").SetVal(map[string]string{"country": "KR"})
+	}
+	expectCount := func(count int64) {
+		mock.ExpectTxPipeline()
+		mock.Regexp().ExpectIncr(submissionsKey).SetVal(count)
+		mock.Regexp().ExpectExpire(submissionsKey, time.Minute).SetVal(true)
+		mock.ExpectTxPipelineExec()
+	}
+
+	expectCurrent()
+	expectCount(1)
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Alice"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Alice", float64(550), ZSetKeyName+":group:", GroupScoreSum, 0).SetVal([]interface{}{int64(1), "550", int64(0), []interface{}{}})
+	assert.NoError(t, lb.UpdateUser(ctx, User{Name: "Alice", Score: 550}))
+
+	// 거절된 기록은 세지 않음
+	expectCurrent()
+	err := lb.UpdateUser(ctx, User{Name: "Alice", Score: 5000})
+	assert.Equal(t, http.StatusBadRequest, StatusCode(err))
+	assert.EqualError(t, err, "rejected by segment:country:KR rule: score delta exceeds 100")
+
+	expectCurrent()
+	expectCount(2)
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Alice"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Alice", float64(560), ZSetKeyName+":group:", GroupScoreSum, 0).SetVal([]interface{}{int64(1), "560", int64(0), []interface{}{}})
+	assert.NoError(t, lb.UpdateUser(ctx, User{Name: "Alice", Score: 560}))
+
+	expectCurrent()
+	expectCount(3)
+	err = lb.UpdateUser(ctx, User{Name: "Alice", Score: 570})
+	assert.Equal(t, http.StatusTooManyRequests, StatusCode(err))
+	assert.EqualError(t, err, "rejected by segment:country:KR rule: more than 2 submissions per minute")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReviews(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+	created := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectHGetAll(ZSetKeyName + ":reviews").SetVal(map[string]string{
+		"10": `{"user":{"name":"Bob","score":9999},"update":true,"board":"users","reason":"score delta exceeds 100","created_at":"2022-07-01T12:00:00Z"}`,
+		"9":  `{"user":{"name":"Alice","score":1e15},"update":false,"board":"users","reason":"score exceeds 1000","created_at":"2022-07-01T12:00:00Z"}`,
+	})
+	reviews, err := lb.Reviews(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Review{
+			{ID: "9", User: User{Name: "Alice", Score: 1e15}, Board: "users", Reason: "score exceeds 1000", CreatedAt: created},
+			{ID: "10", User: User{Name: "Bob", Score: 9999}, Update: true, Board: "users", Reason: "score delta exceeds 100", CreatedAt: created},
+		}, reviews)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApproveReview(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+		scoreRules:   ScoreRules{"users": {MaxScore: 1000, Action: RuleQuarantine}},
+	}
+	const review = `{"user":{"name":"Alice","score":5000},"update":true,"board":"users","reason":"score exceeds 1000","created_at":"2022-07-01T12:00:00Z"}`
+
+	// 규칙을 확인하지 않고 기록. 수정 요청이었지만 user가 삭제됐으면 추가
+	mock.ExpectHGet(ZSetKeyName+":reviews", "1").SetVal(review)
+	mock.ExpectHDel(ZSetKeyName+":reviews", "1").SetVal(1)
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Alice"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Alice", float64(5000), ZSetKeyName+":group:", GroupScoreSum, 0).SetVal([]interface{}{int64(0)})
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Alice"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Alice", float64(5000), ZSetKeyName+":group:", GroupScoreSum, 0).SetVal([]interface{}{int64(1), "5000", int64(0), []interface{}{}})
+	assert.NoError(t, lb.ApproveReview(ctx, "1"))
+
+	mock.ExpectHGet(ZSetKeyName+":reviews", "2").RedisNil()
+	err := lb.ApproveReview(ctx, "2")
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+
+	// 기록하지 못하면 대기열에 되돌림
+	mock.ExpectHGet(ZSetKeyName+":reviews", "3").SetVal(review)
+	mock.ExpectHDel(ZSetKeyName+":reviews", "3").SetVal(1)
+	mock.Regexp().ExpectEvalSha(scriptSHA, userKeys("Alice"), ZSetKeyName+":changes", ZSetKeyName+":segment:", "Alice", float64(5000), ZSetKeyName+":group:", GroupScoreSum, 0).SetErr(assert.AnError)
+	mock.ExpectHSet(ZSetKeyName+":reviews", "3", review).SetVal(1)
+	assert.Error(t, lb.ApproveReview(ctx, "3"))
+
+	mock.ExpectHDel(ZSetKeyName+":reviews", "4").SetVal(1)
+	ok, err := lb.DiscardReview(ctx, "4")
+	if assert.NoError(t, err) {
+		assert.True(t, ok)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,118 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/tracing"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type SegmentInterface interface {
+	SegmentUserCount(ctx context.Context, segment Segment) (int64, error)
+	GetSegmentUser(ctx context.Context, segment Segment, name string) (*UserRank, error)
+	GetSegmentUserList(ctx context.Context, segment Segment, start int64, stop int64) ([]User, error)
+	GetSegmentUserPage(ctx context.Context, segment Segment, cursor string, limit int64) (*UserPage, error)
+}
+
+// 국가, 플랫폼 등 user 속성별 board. "country:KR" 형식으로 표현합니다.
+type Segment struct {
+	Attr  string
+	Value string
+}
+
+func ParseSegment(s string) (Segment, error) {
+	attr, value, ok := strings.Cut(s, ":")
+	if !ok || attr == "" || value == "" {
+		return Segment{}, errors.New("invalid segment: " + s)
+	}
+	return Segment{Attr: attr, Value: value}, nil
+}
+
+func (s Segment) String() string {
+	return s.Attr + ":" + s.Value
+}
+
+// score 규칙과 같은 "segment:<attr>:<value>" 이름
+func (s Segment) boardAttr() attribute.KeyValue {
+	return tracing.BoardKey.String("segment:" + s.String())
+}
+
+// segmentAttrs가 비어있으면 모든 속성을 허용합니다.
+func (lb *LeaderBoard) validateSegment(attr string, value string) error {
+	if attr == "" || value == "" || strings.Contains(attr, ":") {
+		return ErrorWithStatusCode(errors.New("invalid segment: "+attr+":"+value), http.StatusBadRequest)
+	}
+	if len(lb.segmentAttrs) == 0 {
+		return nil
+	}
+	for _, allowed := range lb.segmentAttrs {
+		if attr == allowed {
+			return nil
+		}
+	}
+	return ErrorWithStatusCode(errors.New("not allowed segment: "+attr), http.StatusBadRequest)
+}
+
+func (lb *LeaderBoard) validateSegments(segments map[string]string) error {
+	for attr, value := range segments {
+		if err := lb.validateSegment(attr, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (lb *LeaderBoard) SegmentUserCount(ctx context.Context, segment Segment) (count int64, err error) {
+	ctx, span := startSpan(ctx, "SegmentUserCount", segment.boardAttr())
+	defer func() { endSpan(ctx, span, err) }()
+	if err := lb.validateSegment(segment.Attr, segment.Value); err != nil {
+		return 0, err
+	}
+	count, err = lb.redisStorage.Segment(segment.Attr, segment.Value).Count(ctx)
+	return count, errors.Wrap(err, "lb.redisStorage.Segment.Count")
+}
+
+func (lb *LeaderBoard) GetSegmentUser(ctx context.Context, segment Segment, name string) (userRank *UserRank, err error) {
+	ctx, span := startSpan(ctx, "GetSegmentUser", segment.boardAttr(), tracing.UserKey.String(name))
+	defer func() { endSpan(ctx, span, err) }()
+	if err := lb.validateSegment(segment.Attr, segment.Value); err != nil {
+		return nil, err
+	}
+	exists, rank, score, segments, err := lb.redisStorage.Segment(segment.Attr, segment.Value).Get(ctx, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.Segment.Get")
+	} else if !exists {
+		return nil, ErrorWithStatusCode(errors.New("not exists user: "+name+" in "+segment.String()), http.StatusNotFound)
+	}
+	return &UserRank{
+		User: User{
+			Name:     name,
+			Score:    score,
+			Segments: segments,
+		},
+		Rank: rank,
+	}, nil
+}
+
+func (lb *LeaderBoard) GetSegmentUserList(ctx context.Context, segment Segment, start int64, stop int64) (result []User, err error) {
+	ctx, span := startSpan(ctx, "GetSegmentUserList", segment.boardAttr(), startKey.Int64(start), stopKey.Int64(stop))
+	defer func() { endSpan(ctx, span, err) }()
+	if err := lb.validateSegment(segment.Attr, segment.Value); err != nil {
+		return nil, err
+	}
+	userList, err := lb.redisStorage.Segment(segment.Attr, segment.Value).Range(ctx, start, stop)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.Segment.Range")
+	}
+	result = make([]User, 0, len(userList))
+	for _, user := range userList {
+		result = append(result, User{
+			Name:  user.Member.(string),
+			Score: user.Score,
+		})
+	}
+	return result, nil
+}
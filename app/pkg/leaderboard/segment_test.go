@@ -0,0 +1,106 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+const KRSegmentKeyName = ZSetKeyName + ":segment:country:KR"
+
+func TestParseSegment(t *testing.T) {
+	segment, err := ParseSegment("country:KR")
+	if assert.NoError(t, err) {
+		assert.Equal(t, Segment{Attr: "country", Value: "KR"}, segment)
+		assert.Equal(t, "country:KR", segment.String())
+	}
+	for _, input := range []string{"", "country", "country:", ":KR"} {
+		_, err := ParseSegment(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestSegmentUserCount(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+		segmentAttrs: []string{"country", "platform"},
+	}
+
+	mock.ExpectZCount(KRSegmentKeyName, "-inf", "+inf").SetVal(2)
+	count, err := lb.SegmentUserCount(ctx, Segment{Attr: "country", Value: "KR"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), count)
+	}
+
+	_, err = lb.SegmentUserCount(ctx, Segment{Attr: "level", Value: "10"})
+	assert.ErrorContains(t, err, "not allowed segment: level")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetSegmentUser(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+	segment := Segment{Attr: "country", Value: "KR"}
+
+	expectGet(mock, KRSegmentKeyName, "Minsik", 999, 1, "country", "KR")
+
+	userRank, err := lb.GetSegmentUser(ctx, segment, "Minsik")
+	if assert.NoError(t, err) {
+		assert.Equal(t, UserRank{
+			User: User{
+				Name:     "Minsik",
+				Score:    999,
+				Segments: map[string]string{"country": "KR"},
+			},
+			Rank: 1,
+		}, *userRank)
+	}
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, getKeys(KRSegmentKeyName, "Foo"), "Foo").RedisNil()
+	_, err = lb.GetSegmentUser(ctx, segment, "Foo")
+	var apiErr interface{ StatusCode() int }
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSegmentUserList(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+
+	mock.ExpectZRangeWithScores(KRSegmentKeyName, 0, 1).SetVal([]redis.Z{
+		{Score: 1000, Member: "Minsik"},
+		{Score: 500, Member: "Foo"},
+	})
+	users, err := lb.GetSegmentUserList(ctx, Segment{Attr: "country", Value: "KR"}, 0, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []User{
+			{Name: "Minsik", Score: 1000},
+			{Name: "Foo", Score: 500},
+		}, users)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,108 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SNAPSHOT_RETENTION이 없을 때 남겨두는 snapshot 수
+const DefaultSnapshotRetention = 24
+
+// snapshot id는 생성 시각(UTC, 초 단위)
+const snapshotIDLayout = "20060102T150405Z"
+
+// 전체 board의 시점별 복사본
+type SnapshotInterface interface {
+	// 전체 board를 복사하고, 오래된 snapshot은 보관 개수만 남기고 지웁니다. 차단된 user는 포함하지 않음
+	CreateSnapshot(ctx context.Context) (*Snapshot, error)
+	// 최신 snapshot부터 반환합니다.
+	Snapshots(ctx context.Context) ([]Snapshot, error)
+	// 전체 board를 snapshot 시점의 점수로 되돌립니다.
+	RestoreSnapshot(ctx context.Context, id string) error
+	DeleteSnapshot(ctx context.Context, id string) (bool, error)
+}
+
+type Snapshot struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Count     int64     `json:"count"`
+}
+
+// 0이면 지우지 않음
+func ParseSnapshotRetention(value string) (int, error) {
+	if value == "" {
+		return DefaultSnapshotRetention, nil
+	}
+	retention, err := strconv.Atoi(value)
+	if err != nil || retention < 0 {
+		return 0, errors.New("invalid snapshot retention: " + value)
+	}
+	return retention, nil
+}
+
+func (lb *LeaderBoard) CreateSnapshot(ctx context.Context) (*Snapshot, error) {
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	id := createdAt.Format(snapshotIDLayout)
+	ok, count, err := lb.redisStorage.Snapshot(ctx, id, createdAt.Unix(), lb.snapshotRetention)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.Snapshot")
+	}
+	if !ok {
+		return nil, ErrorWithStatusCode(errors.New("already exists snapshot: "+id), http.StatusBadRequest)
+	}
+	return &Snapshot{ID: id, CreatedAt: createdAt, Count: count}, nil
+}
+
+func (lb *LeaderBoard) Snapshots(ctx context.Context) ([]Snapshot, error) {
+	ids, createdAts, counts, err := lb.redisStorage.Snapshots(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "lb.redisStorage.Snapshots")
+	}
+	result := make([]Snapshot, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		result = append(result, Snapshot{
+			ID:        ids[i],
+			CreatedAt: time.Unix(createdAts[i], 0).UTC(),
+			Count:     counts[i],
+		})
+	}
+	return result, nil
+}
+
+// group 소속과 segment 속성은 되돌리지 않고, 현재 소속 기준으로 점수만 다시 계산합니다.
+// snapshot 이후 삭제된 user는 group, segment 없이 돌아옵니다.
+func (lb *LeaderBoard) RestoreSnapshot(ctx context.Context, id string) error {
+	ok, err := lb.redisStorage.Restore(ctx, id, lb.groupScore.mode(), lb.groupScore.TopN)
+	if err != nil {
+		return errors.Wrap(err, "lb.redisStorage.Restore")
+	}
+	if !ok {
+		return ErrorWithStatusCode(errors.New("not exists snapshot: "+id), http.StatusNotFound)
+	}
+	return nil
+}
+
+func (lb *LeaderBoard) DeleteSnapshot(ctx context.Context, id string) (bool, error) {
+	ok, err := lb.redisStorage.DeleteSnapshot(ctx, id)
+	return ok, errors.Wrap(err, "lb.redisStorage.DeleteSnapshot")
+}
+
+// interval마다 snapshot을 만듭니다. ctx가 끝날 때까지 반환하지 않습니다.
+func ScheduleSnapshots(ctx context.Context, snapshots SnapshotInterface, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := snapshots.CreateSnapshot(ctx); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const snapshotID = `^\d{8}T\d{6}Z$`
+
+func TestParseSnapshotRetention(t *testing.T) {
+	for value, expected := range map[string]int{"": DefaultSnapshotRetention, "0": 0, "7": 7} {
+		retention, err := ParseSnapshotRetention(value)
+		if assert.NoError(t, err, value) {
+			assert.Equal(t, expected, retention, value)
+		}
+	}
+	for _, value := range []string{"-1", "abc"} {
+		_, err := ParseSnapshotRetention(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestCreateSnapshot(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage:      redisstorage.NewMock(ZSetKeyName, db),
+		snapshotRetention: 24,
+	}
+	snapshotKeys := []string{"^" + ZSetKeyName + "$", `^scores:snapshot:\d{8}T\d{6}Z$`, "^" + ZSetKeyName + ":snapshots$", "^" + ZSetKeyName + ":banned$", `^scores:snapshot:\d{8}T\d{6}Z:banned$`}
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, snapshotKeys, snapshotID, `^\d+$`, 24, ZSetKeyName+":snapshot:", ":banned").SetVal(int64(3))
+	snapshot, err := lb.CreateSnapshot(ctx)
+	if assert.NoError(t, err) {
+		assert.Regexp(t, snapshotID, snapshot.ID)
+		assert.Equal(t, snapshot.CreatedAt.Format(snapshotIDLayout), snapshot.ID)
+		assert.Equal(t, int64(3), snapshot.Count)
+	}
+
+	// 같은 초에 만든 snapshot
+	mock.Regexp().ExpectEvalSha(scriptSHA, snapshotKeys, snapshotID, `^\d+$`, 24, ZSetKeyName+":snapshot:", ":banned").SetVal(int64(-1))
+	_, err = lb.CreateSnapshot(ctx)
+	assert.Equal(t, http.StatusBadRequest, StatusCode(err))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+	created := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectZRangeWithScores(ZSetKeyName+":snapshots", 0, -1).SetVal([]redis.Z{
+		{Score: float64(created.Unix()), Member: "20220701T120000Z"},
+		{Score: float64(created.Add(time.Hour).Unix()), Member: "20220701T130000Z"},
+	})
+	mock.ExpectZCard(ZSetKeyName + ":snapshot:20220701T120000Z").SetVal(2)
+	mock.ExpectZCard(ZSetKeyName + ":snapshot:20220701T130000Z").SetVal(3)
+
+	snapshots, err := lb.Snapshots(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Snapshot{
+			{ID: "20220701T130000Z", CreatedAt: created.Add(time.Hour), Count: 3},
+			{ID: "20220701T120000Z", CreatedAt: created, Count: 2},
+		}, snapshots)
+	}
+
+	// snapshot이 없으면 빈 목록
+	mock.ExpectZRangeWithScores(ZSetKeyName+":snapshots", 0, -1).SetVal([]redis.Z{})
+	snapshots, err = lb.Snapshots(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Snapshot{}, snapshots)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+		// 설정하지 않으면 sum
+	}
+	const id = "20220701T120000Z"
+	restoreKeys := append(groupKeys, ZSetKeyName+":snapshots", ZSetKeyName+":snapshot:"+id, ZSetKeyName+":bans", ZSetKeyName+":banned", ZSetKeyName+":snapshot:"+id+":banned", ZSetKeyName+":restoring")
+	restoreArgs := []interface{}{ZSetKeyName + ":group:", id, GroupScoreSum, 0, ZSetKeyName + ":segment:", ZSetKeyName + ":changes"}
+
+	// board, segment board, group 점수와 변경 알림을 한 script에서 처리
+	mock.Regexp().ExpectEvalSha(scriptSHA, restoreKeys, restoreArgs...).SetVal(int64(1))
+	assert.NoError(t, lb.RestoreSnapshot(ctx, id))
+
+	// 없는 snapshot
+	mock.Regexp().ExpectEvalSha(scriptSHA, restoreKeys, restoreArgs...).SetVal(int64(0))
+	err := lb.RestoreSnapshot(ctx, id)
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// redis에서 script를 실행해서 board끼리 맞는지 확인
+func TestRestoreBans(t *testing.T) {
+	ctx := context.Background()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})),
+	}
+	kr := Segment{Attr: "country", Value: "KR"}
+	require.NoError(t, lb.AddUser(ctx, User{Name: "Minsik", Score: 100, Segments: map[string]string{"country": "KR"}}))
+	require.NoError(t, lb.AddUser(ctx, User{Name: "Cheater", Score: 50, Segments: map[string]string{"country": "KR"}}))
+	require.NoError(t, lb.AddUser(ctx, User{Name: "Yumi", Score: 30}))
+	_, err := lb.BanUser(ctx, "Cheater", "speed hack", false)
+	require.NoError(t, err)
+	snapshot, err := lb.CreateSnapshot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), snapshot.Count)
+
+	// snapshot 이후 Cheater는 차단이 풀리고 Yumi는 차단됨
+	ok, err := lb.UnbanUser(ctx, "Cheater")
+	require.True(t, ok)
+	require.NoError(t, err)
+	_, err = lb.BanUser(ctx, "Yumi", "bot", false)
+	require.NoError(t, err)
+	require.NoError(t, lb.UpdateUser(ctx, User{Name: "Minsik", Score: 200}))
+
+	require.NoError(t, lb.RestoreSnapshot(ctx, snapshot.ID))
+	users, err := lb.GetUserList(ctx, 0, 10)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []User{{Name: "Cheater", Score: 50}, {Name: "Minsik", Score: 100}}, users)
+	}
+	segmentUsers, err := lb.GetSegmentUserList(ctx, kr, 0, 10)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []User{{Name: "Cheater", Score: 50}, {Name: "Minsik", Score: 100}}, segmentUsers)
+	}
+	_, err = lb.GetUser(ctx, "Yumi")
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+
+	// 차단을 풀면 snapshot의 점수로 돌아옴
+	ok, err = lb.UnbanUser(ctx, "Yumi")
+	require.True(t, ok)
+	require.NoError(t, err)
+	userRank, err := lb.GetUser(ctx, "Yumi")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &UserRank{User: User{Name: "Yumi", Score: 30}, Rank: 0}, userRank)
+	}
+}
+
+func TestDeleteSnapshot(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+	const id = "20220701T120000Z"
+
+	mock.ExpectTxPipeline()
+	mock.ExpectZRem(ZSetKeyName+":snapshots", id).SetVal(1)
+	mock.ExpectDel(ZSetKeyName+":snapshot:"+id, ZSetKeyName+":snapshot:"+id+":banned").SetVal(1)
+	mock.ExpectTxPipelineExec()
+	ok, err := lb.DeleteSnapshot(ctx, id)
+	if assert.NoError(t, err) {
+		assert.True(t, ok)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,38 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/logger"
+	"github.com/JeongMinSik/go-leaderboard/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 목록 조회 범위
+var (
+	startKey = attribute.Key("leaderboard.start")
+	stopKey  = attribute.Key("leaderboard.stop")
+)
+
+type operationSpan struct {
+	trace.Span
+	name string
+}
+
+// "LeaderBoard.<name>" span을 시작합니다. endSpan으로 끝내야 합니다.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, operationSpan) {
+	ctx, span := tracing.Start(ctx, "LeaderBoard."+name, trace.WithAttributes(attrs...))
+	return ctx, operationSpan{Span: span, name: name}
+}
+
+// 없는 user, 규칙 위반 같은 4xx error는 기록만 하고 5xx error만 span을 실패로 표시합니다.
+// 5xx error는 요청 ID와 함께 log로도 남김
+func endSpan(ctx context.Context, span operationSpan, err error) {
+	failed := err != nil && StatusCode(err) >= http.StatusInternalServerError
+	if failed {
+		logger.FromContext(ctx).WithError(err).WithField("operation", "LeaderBoard."+span.name).Warn("leaderboard operation failed")
+	}
+	tracing.End(span, err, failed)
+}
@@ -0,0 +1,71 @@
+package leaderboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/logger"
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/JeongMinSik/go-leaderboard/pkg/tracing"
+	"github.com/go-redis/redismock/v8"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	log, err := logger.New(logger.Config{Sink: logger.SinkStdout})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	logger.SetDefault(log)
+	ctx := logger.WithRequestID(context.Background(), "request-1")
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+
+	expectGet(mock, ZSetKeyName, "Minsik", 999, 4)
+	_, err = lb.GetUser(ctx, "Minsik")
+	assert.NoError(t, err)
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, getKeys(ZSetKeyName, "Foo"), "Foo").RedisNil()
+	_, err = lb.GetUser(ctx, "Foo")
+	assert.Error(t, err)
+
+	mock.ExpectZCard(ZSetKeyName).SetErr(errors.New("redis down"))
+	_, err = lb.UserCount(ctx)
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "LeaderBoard.GetUser", spans[0].Name())
+		assert.Contains(t, spans[0].Attributes(), tracing.BoardKey.String(usersBoard))
+		assert.Contains(t, spans[0].Attributes(), tracing.UserKey.String("Minsik"))
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+		// 없는 user는 실패가 아님
+		assert.Len(t, spans[1].Events(), 1)
+		assert.Equal(t, codes.Unset, spans[1].Status().Code)
+
+		assert.Equal(t, "LeaderBoard.UserCount", spans[2].Name())
+		assert.Equal(t, codes.Error, spans[2].Status().Code)
+	}
+
+	// 5xx error만 요청 ID와 함께 log로 남김
+	var entry map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry)) {
+		assert.Equal(t, "request-1", entry[logger.RequestIDField])
+		assert.Equal(t, "LeaderBoard.UserCount", entry["operation"])
+	}
+}
@@ -0,0 +1,459 @@
+package leaderboard
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatJSON  = "json"
+)
+
+// export, import 시 한 번에 redis에서 읽고 쓰는 user 수
+const TransferBatchSize = 500
+
+// import 결과에 담는 최대 에러 수
+const maxImportErrors = 100
+
+type TransferInterface interface {
+	// 순위 순서대로 TransferBatchSize명씩 읽어 fn에 넘깁니다. 차단된 user는 포함하지 않음
+	ExportUsers(ctx context.Context, fn func([]User) error) error
+	// decoder에서 읽은 user를 TransferBatchSize명씩 추가 또는 수정하고, batch마다 변경 알림을 한 번 보냅니다.
+	// 잘못된 record, 차단된 user, 점수 규칙을 어긴 record는 건너뛰고 결과에 기록합니다. dryRun이면 검사만 합니다.
+	ImportUsers(ctx context.Context, decoder UserDecoder, dryRun bool) (*ImportResult, error)
+	// CSV column으로 쓸 segment 속성. 제한이 없으면 nil
+	SegmentAttributes() []string
+}
+
+type ImportResult struct {
+	DryRun  bool          `json:"dry_run"`
+	Total   int64         `json:"total"`
+	Added   int64         `json:"added"`
+	Updated int64         `json:"updated"`
+	Invalid int64         `json:"invalid"`
+	Errors  []ImportError `json:"errors,omitempty"`
+}
+
+// Record는 1부터 시작하는 record 순서 (CSV는 header 제외)
+type ImportError struct {
+	Record  int64  `json:"record"`
+	Message string `json:"message"`
+}
+
+func (r *ImportResult) addError(record int64, err error) {
+	r.Invalid++
+	if len(r.Errors) < maxImportErrors {
+		r.Errors = append(r.Errors, ImportError{Record: record, Message: err.Error()})
+	}
+}
+
+type UserEncoder interface {
+	Encode(user User) error
+	// 남은 내용을 씁니다. Encode를 한 번도 호출하지 않았어도 호출해야 합니다.
+	Close() error
+}
+
+// csv는 name, score와 attrs의 segment column을 씁니다.
+func NewUserEncoder(w io.Writer, format string, attrs []string) (UserEncoder, error) {
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{writer: csv.NewWriter(w), attrs: attrs}, nil
+	case FormatJSONL:
+		return &jsonlEncoder{encoder: json.NewEncoder(w)}, nil
+	case FormatJSON:
+		return &jsonEncoder{w: w}, nil
+	}
+	return nil, ErrorWithStatusCode(errors.New("invalid format: "+format), http.StatusBadRequest)
+}
+
+type csvEncoder struct {
+	writer        *csv.Writer
+	attrs         []string
+	headerWritten bool
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+	return errors.Wrap(e.writer.Write(append([]string{"name", "score"}, e.attrs...)), "e.writer.Write")
+}
+
+func (e *csvEncoder) Encode(user User) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	record := []string{user.Name, strconv.FormatFloat(user.Score, 'f', -1, 64)}
+	for _, attr := range e.attrs {
+		record = append(record, user.Segments[attr])
+	}
+	return errors.Wrap(e.writer.Write(record), "e.writer.Write")
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	return errors.Wrap(e.writer.Error(), "e.writer.Flush")
+}
+
+type jsonlEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *jsonlEncoder) Encode(user User) error {
+	return errors.Wrap(e.encoder.Encode(user), "e.encoder.Encode")
+}
+
+func (e *jsonlEncoder) Close() error {
+	return nil
+}
+
+// 전체를 메모리에 올리지 않도록 배열을 직접 씁니다.
+type jsonEncoder struct {
+	w       io.Writer
+	started bool
+}
+
+func (e *jsonEncoder) Encode(user User) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+	separator := ",\n"
+	if !e.started {
+		separator = "[\n"
+		e.started = true
+	}
+	_, err = io.WriteString(e.w, separator+string(data))
+	return errors.Wrap(err, "io.WriteString")
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "\n]\n"
+	if !e.started {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return errors.Wrap(err, "io.WriteString")
+}
+
+// 잘못된 record. decoder는 이 에러 이후에도 다음 record를 읽을 수 있습니다.
+type InvalidRecordError struct {
+	err error
+}
+
+func (e InvalidRecordError) Error() string {
+	return e.err.Error()
+}
+
+type UserDecoder interface {
+	// 더 읽을 것이 없으면 io.EOF, 잘못된 record는 InvalidRecordError를 반환합니다.
+	Decode() (User, error)
+}
+
+// csv는 header가 필요하며 name, score 외의 column은 segment 속성으로 읽습니다. 빈 값은 무시합니다.
+func NewUserDecoder(r io.Reader, format string) (UserDecoder, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		header, err := reader.Read()
+		if err != nil {
+			return nil, ErrorWithStatusCode(errors.Wrap(err, "invalid csv header"), http.StatusBadRequest)
+		}
+		decoder := &csvDecoder{reader: reader, header: header, nameIndex: -1, scoreIndex: -1}
+		for i, column := range header {
+			switch column {
+			case "name":
+				decoder.nameIndex = i
+			case "score":
+				decoder.scoreIndex = i
+			}
+		}
+		if decoder.nameIndex < 0 || decoder.scoreIndex < 0 {
+			return nil, ErrorWithStatusCode(errors.New("csv header must have name and score"), http.StatusBadRequest)
+		}
+		return decoder, nil
+	case FormatJSONL:
+		return &jsonlDecoder{decoder: json.NewDecoder(r)}, nil
+	case FormatJSON:
+		decoder := json.NewDecoder(r)
+		if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+			return nil, ErrorWithStatusCode(errors.New("json body must be an array"), http.StatusBadRequest)
+		}
+		return &jsonDecoder{decoder: decoder}, nil
+	}
+	return nil, ErrorWithStatusCode(errors.New("invalid format: "+format), http.StatusBadRequest)
+}
+
+type csvDecoder struct {
+	reader     *csv.Reader
+	header     []string
+	nameIndex  int
+	scoreIndex int
+}
+
+func (d *csvDecoder) Decode() (User, error) {
+	record, err := d.reader.Read()
+	if err == io.EOF {
+		return User{}, io.EOF
+	}
+	if err != nil {
+		return User{}, ErrorWithStatusCode(errors.Wrap(err, "invalid csv"), http.StatusBadRequest)
+	}
+	if len(record) != len(d.header) {
+		return User{}, InvalidRecordError{errors.New("wrong number of fields")}
+	}
+	score, err := strconv.ParseFloat(record[d.scoreIndex], 64)
+	if err != nil {
+		return User{}, InvalidRecordError{errors.New("invalid score: " + record[d.scoreIndex])}
+	}
+	user := User{Name: record[d.nameIndex], Score: score}
+	for i, value := range record {
+		if i == d.nameIndex || i == d.scoreIndex || value == "" {
+			continue
+		}
+		if user.Segments == nil {
+			user.Segments = map[string]string{}
+		}
+		user.Segments[d.header[i]] = value
+	}
+	return user, nil
+}
+
+type jsonlDecoder struct {
+	decoder *json.Decoder
+}
+
+func (d *jsonlDecoder) Decode() (User, error) {
+	raw := json.RawMessage{}
+	if err := d.decoder.Decode(&raw); err != nil {
+		if err == io.EOF {
+			return User{}, io.EOF
+		}
+		// 문법 에러 이후는 읽을 수 없음
+		return User{}, ErrorWithStatusCode(errors.Wrap(err, "invalid jsonl"), http.StatusBadRequest)
+	}
+	return decodeUser(raw)
+}
+
+type jsonDecoder struct {
+	decoder *json.Decoder
+}
+
+func (d *jsonDecoder) Decode() (User, error) {
+	if !d.decoder.More() {
+		if _, err := d.decoder.Token(); err != nil {
+			return User{}, ErrorWithStatusCode(errors.Wrap(err, "invalid json"), http.StatusBadRequest)
+		}
+		return User{}, io.EOF
+	}
+	raw := json.RawMessage{}
+	if err := d.decoder.Decode(&raw); err != nil {
+		return User{}, ErrorWithStatusCode(errors.Wrap(err, "invalid json"), http.StatusBadRequest)
+	}
+	return decodeUser(raw)
+}
+
+func decodeUser(raw json.RawMessage) (User, error) {
+	user := User{}
+	if err := json.Unmarshal(raw, &user); err != nil {
+		return User{}, InvalidRecordError{errors.New("invalid user: " + string(raw))}
+	}
+	return user, nil
+}
+
+func (lb *LeaderBoard) SegmentAttributes() []string {
+	return lb.segmentAttrs
+}
+
+func (lb *LeaderBoard) ExportUsers(ctx context.Context, fn func([]User) error) error {
+	// rank 대신 (score, name) 기준으로 이어서 읽으므로 점수가 바뀌지 않은 user는 중복되지 않음
+	// (읽는 도중 점수가 cursor를 넘어 바뀐 user는 누락되거나 두 번 나올 수 있음)
+	var after *redis.Z
+	for {
+		userList, err := lb.redisStorage.RangeAfter(ctx, after, TransferBatchSize)
+		if err != nil {
+			return errors.Wrap(err, "lb.redisStorage.RangeAfter")
+		}
+		if len(userList) == 0 {
+			return nil
+		}
+		names := make([]string, 0, len(userList))
+		for _, user := range userList {
+			names = append(names, user.Member.(string))
+		}
+		segments, err := lb.redisStorage.UserSegments(ctx, names)
+		if err != nil {
+			return errors.Wrap(err, "lb.redisStorage.UserSegments")
+		}
+		users := make([]User, 0, len(userList))
+		for i, user := range userList {
+			exported := User{Name: names[i], Score: user.Score}
+			if len(segments[i]) > 0 {
+				exported.Segments = segments[i]
+			}
+			users = append(users, exported)
+		}
+		if err := fn(users); err != nil {
+			return err
+		}
+		if len(userList) < TransferBatchSize {
+			return nil
+		}
+		after = &userList[len(userList)-1]
+	}
+}
+
+func (lb *LeaderBoard) validateUser(user User) error {
+	if user.Name == "" {
+		return errors.New("user name is empty")
+	}
+	if math.IsNaN(user.Score) || math.IsInf(user.Score, 0) {
+		return errors.New("invalid score")
+	}
+	return lb.validateSegments(user.Segments)
+}
+
+func (lb *LeaderBoard) ImportUsers(ctx context.Context, decoder UserDecoder, dryRun bool) (*ImportResult, error) {
+	result := &ImportResult{DryRun: dryRun}
+	batch := make([]redisstorage.Record, 0, TransferBatchSize)
+	// batch의 record 순서
+	lines := make([]int64, 0, TransferBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		defer func() {
+			batch = batch[:0]
+			lines = lines[:0]
+		}()
+		records, recordLines, err := lb.checkImportRecords(ctx, batch, lines, result)
+		if err != nil {
+			return err
+		}
+		if dryRun || len(records) == 0 {
+			return nil
+		}
+		written, err := lb.redisStorage.SetUsers(ctx, records, lb.groupScore.mode(), lb.groupScore.TopN)
+		if err != nil {
+			return errors.Wrap(err, "lb.redisStorage.SetUsers")
+		}
+		for i, status := range written {
+			switch status {
+			case 1:
+				result.Added++
+			case 0:
+				result.Updated++
+			default:
+				// 확인한 뒤에 차단된 user
+				result.addError(recordLines[i], errors.New("banned user: "+records[i].Name))
+			}
+		}
+		return nil
+	}
+
+	for {
+		user, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		result.Total++
+		var invalid InvalidRecordError
+		if errors.As(err, &invalid) {
+			result.addError(result.Total, err)
+			continue
+		} else if err != nil {
+			return result, err
+		}
+		if err := lb.validateUser(user); err != nil {
+			result.addError(result.Total, err)
+			continue
+		}
+		batch = append(batch, redisstorage.Record{Name: user.Name, Score: user.Score, Segments: user.Segments})
+		lines = append(lines, result.Total)
+		if len(batch) == TransferBatchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, flush()
+}
+
+// 차단된 user와 점수 규칙을 어긴 record를 결과에 기록하고 나머지 record와 순서를 반환합니다.
+// dry run에서 쓰도록 추가, 수정될 user 수도 셉니다. 같은 batch에 여러 번 나온 user는 앞의 record를 기록한 뒤의 상태로 확인
+func (lb *LeaderBoard) checkImportRecords(ctx context.Context, batch []redisstorage.Record, lines []int64, result *ImportResult) ([]redisstorage.Record, []int64, error) {
+	names := make([]string, 0, len(batch))
+	for _, record := range batch {
+		names = append(names, record.Name)
+	}
+	states, err := lb.redisStorage.RecordStates(ctx, names)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "lb.redisStorage.RecordStates")
+	}
+	// 규칙이 있을 때만 수정 전 segment로 규칙을 적용할 board를 정함
+	var storedSegments []map[string]string
+	if len(lb.scoreRules) > 0 {
+		if storedSegments, err = lb.redisStorage.UserSegments(ctx, names); err != nil {
+			return nil, nil, errors.Wrap(err, "lb.redisStorage.UserSegments")
+		}
+	}
+
+	seen := map[string]redisstorage.Record{}
+	records := make([]redisstorage.Record, 0, len(batch))
+	recordLines := make([]int64, 0, len(batch))
+	for i, record := range batch {
+		if states[i].Banned {
+			result.addError(lines[i], errors.New("banned user: "+record.Name))
+			continue
+		}
+		var prevScore *float64
+		segments := map[string]string{}
+		if prev, ok := seen[record.Name]; ok {
+			prevScore = &prev.Score
+			for attr, value := range prev.Segments {
+				segments[attr] = value
+			}
+		} else if states[i].Exists {
+			prevScore = &states[i].Score
+			if storedSegments != nil {
+				for attr, value := range storedSegments[i] {
+					segments[attr] = value
+				}
+			}
+		}
+		for attr, value := range record.Segments {
+			segments[attr] = value
+		}
+		if err := lb.checkImportRules(record.Score, prevScore, segments); err != nil {
+			result.addError(lines[i], err)
+			continue
+		}
+		if result.DryRun {
+			if prevScore == nil {
+				result.Added++
+			} else {
+				result.Updated++
+			}
+		}
+		seen[record.Name] = redisstorage.Record{Name: record.Name, Score: record.Score, Segments: segments}
+		records = append(records, record)
+		recordLines = append(recordLines, lines[i])
+	}
+	return records, recordLines, nil
+}
@@ -0,0 +1,290 @@
+package leaderboard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserEncoder(t *testing.T) {
+	users := []User{
+		{Name: "Minsik", Score: 100.5, Segments: map[string]string{"country": "KR"}},
+		{Name: "Yumi, Kim", Score: 200},
+	}
+	for format, expected := range map[string]string{
+		FormatCSV:   "name,score,country\nMinsik,100.5,KR\n\"Yumi, Kim\",200,\n",
+		FormatJSONL: "{\"name\":\"Minsik\",\"score\":100.5,\"segments\":{\"country\":\"KR\"}}\n{\"name\":\"Yumi, Kim\",\"score\":200}\n",
+		FormatJSON:  "[\n{\"name\":\"Minsik\",\"score\":100.5,\"segments\":{\"country\":\"KR\"}},\n{\"name\":\"Yumi, Kim\",\"score\":200}\n]\n",
+	} {
+		buf := &bytes.Buffer{}
+		encoder, err := NewUserEncoder(buf, format, []string{"country"})
+		require.NoError(t, err)
+		for _, user := range users {
+			require.NoError(t, encoder.Encode(user))
+		}
+		require.NoError(t, encoder.Close())
+		assert.Equal(t, expected, buf.String(), format)
+	}
+
+	// 빈 목록
+	buf := &bytes.Buffer{}
+	encoder, _ := NewUserEncoder(buf, FormatJSON, nil)
+	require.NoError(t, encoder.Close())
+	assert.Equal(t, "[]\n", buf.String())
+
+	_, err := NewUserEncoder(buf, "xml", nil)
+	assert.Error(t, err)
+}
+
+func decodeAll(t *testing.T, decoder UserDecoder) ([]User, []string) {
+	var users []User
+	var invalid []string
+	for {
+		user, err := decoder.Decode()
+		if err == io.EOF {
+			return users, invalid
+		}
+		var invalidErr InvalidRecordError
+		if errors.As(err, &invalidErr) {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+}
+
+func TestUserDecoder(t *testing.T) {
+	expected := []User{
+		{Name: "Minsik", Score: 100.5, Segments: map[string]string{"country": "KR"}},
+		{Name: "Yumi", Score: 200},
+	}
+	for format, input := range map[string]string{
+		FormatCSV:   "score,name,country\n100.5,Minsik,KR\n200,Yumi,\nabc,Foo,\n1,2\n",
+		FormatJSONL: "{\"name\":\"Minsik\",\"score\":100.5,\"segments\":{\"country\":\"KR\"}}\n{\"name\":\"Yumi\",\"score\":200}\n{\"name\":\"Foo\",\"score\":\"abc\"}\n[1, 2]\n",
+		FormatJSON:  "[{\"name\":\"Minsik\",\"score\":100.5,\"segments\":{\"country\":\"KR\"}}, {\"name\":\"Yumi\",\"score\":200}, {\"name\":\"Foo\",\"score\":\"abc\"}, [1, 2]]",
+	} {
+		decoder, err := NewUserDecoder(strings.NewReader(input), format)
+		require.NoError(t, err, format)
+		users, invalid := decodeAll(t, decoder)
+		assert.Equal(t, expected, users, format)
+		assert.Len(t, invalid, 2, format)
+	}
+
+	// 읽을 수 없는 입력
+	for format, input := range map[string]string{
+		FormatCSV:  "name\nMinsik\n",
+		FormatJSON: "{\"name\": \"Minsik\"}",
+		"xml":      "",
+	} {
+		_, err := NewUserDecoder(strings.NewReader(input), format)
+		var apiErr interface{ StatusCode() int }
+		if assert.ErrorAs(t, err, &apiErr, format) {
+			assert.Equal(t, 400, apiErr.StatusCode())
+		}
+	}
+	decoder, err := NewUserDecoder(strings.NewReader("{\"name\": \"Minsik\", "), FormatJSONL)
+	require.NoError(t, err)
+	_, err = decoder.Decode()
+	assert.Equal(t, 400, StatusCode(err))
+}
+
+func TestExportUsers(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+
+	mock.ExpectZRangeByScoreWithScores(ZSetKeyName, &redis.ZRangeBy{
+		Min: "-inf", Max: "+inf", Offset: 0, Count: TransferBatchSize,
+	}).SetVal([]redis.Z{
+		{Score: 100, Member: "Minsik"},
+		{Score: 200, Member: "Yumi"},
+	})
+	mock.ExpectHGetAll(ZSetKeyName + ":user-segments:Minsik").SetVal(map[string]string{"country": "KR"})
+	mock.ExpectHGetAll(ZSetKeyName + ":user-segments:Yumi").SetVal(map[string]string{})
+
+	var exported [][]User
+	err := lb.ExportUsers(ctx, func(users []User) error {
+		exported = append(exported, users)
+		return nil
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]User{{
+			{Name: "Minsik", Score: 100, Segments: map[string]string{"country": "KR"}},
+			{Name: "Yumi", Score: 200},
+		}}, exported)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportUsers(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+		// 설정하지 않으면 sum
+		segmentAttrs: []string{"country"},
+	}
+	const input = "name,score,country\nMinsik,100,KR\nYumi,200,\n,300,\nFoo,400,\nBar,abc,\n"
+	newDecoder := func() UserDecoder {
+		decoder, err := NewUserDecoder(strings.NewReader(input), FormatCSV)
+		require.NoError(t, err)
+		return decoder
+	}
+	expectedErrors := []ImportError{
+		{Record: 3, Message: "user name is empty"},
+		{Record: 5, Message: "invalid score: abc"},
+	}
+
+	expectStates := func() {
+		mock.Regexp().ExpectEvalSha(scriptSHA, []string{ZSetKeyName, ZSetKeyName + ":bans"}, "Minsik", "Yumi", "Foo").SetVal([]interface{}{
+			[]interface{}{"100", int64(0)},
+			[]interface{}{"200", int64(0)},
+			[]interface{}{"", int64(0)},
+		})
+	}
+
+	// dry run은 검사만 함
+	expectStates()
+	result, err := lb.ImportUsers(ctx, newDecoder(), true)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &ImportResult{
+			DryRun:  true,
+			Total:   5,
+			Added:   1,
+			Updated: 2,
+			Invalid: 2,
+			Errors:  expectedErrors,
+		}, result)
+	}
+
+	// 저장하고 batch마다 변경 알림을 한 번 보냄
+	expectStates()
+	for _, user := range []User{
+		{Name: "Minsik", Score: 100, Segments: map[string]string{"country": "KR"}},
+		{Name: "Yumi", Score: 200},
+		{Name: "Foo", Score: 400},
+	} {
+		args := []interface{}{ZSetKeyName + ":changes", ZSetKeyName + ":segment:", user.Name, user.Score, ZSetKeyName + ":group:", GroupScoreSum, 0}
+		for attr, value := range user.Segments {
+			args = append(args, attr, value)
+		}
+		added := int64(1)
+		if user.Name != "Foo" {
+			added = 0
+		}
+		mock.Regexp().ExpectEvalSha(scriptSHA, userKeys(user.Name), args...).SetVal(added)
+	}
+	mock.ExpectPublish(ZSetKeyName+":changes", []byte(`{"name":"Foo","score":400}`)).SetVal(1)
+	result, err = lb.ImportUsers(ctx, newDecoder(), false)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &ImportResult{
+			Total:   5,
+			Added:   1,
+			Updated: 2,
+			Invalid: 2,
+			Errors:  expectedErrors,
+		}, result)
+	}
+
+	// 허용하지 않는 segment
+	decoder, err := NewUserDecoder(strings.NewReader("name,score,platform\nMinsik,100,ios\n"), FormatCSV)
+	require.NoError(t, err)
+	result, err = lb.ImportUsers(ctx, decoder, false)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []ImportError{{Record: 1, Message: "not allowed segment: platform"}}, result.Errors)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportUsersChecks(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+		scoreRules:   ScoreRules{usersBoard: {MaxScore: 1000, MaxDelta: 500}},
+	}
+	const input = "name,score\nMinsik,100\nMinsik,200\nMinsik,900\nCheater,10\nBig,5000\nYumi,60\n"
+	newDecoder := func() UserDecoder {
+		decoder, err := NewUserDecoder(strings.NewReader(input), FormatCSV)
+		require.NoError(t, err)
+		return decoder
+	}
+	names := []interface{}{"Minsik", "Minsik", "Minsik", "Cheater", "Big", "Yumi"}
+	expectStates := func() {
+		mock.Regexp().ExpectEvalSha(scriptSHA, []string{ZSetKeyName, ZSetKeyName + ":bans"}, names...).SetVal([]interface{}{
+			[]interface{}{"", int64(0)},
+			[]interface{}{"", int64(0)},
+			[]interface{}{"", int64(0)},
+			[]interface{}{"", int64(1)},
+			[]interface{}{"", int64(0)},
+			[]interface{}{"50", int64(0)},
+		})
+		for _, name := range names {
+			mock.ExpectHGetAll(ZSetKeyName + ":user-segments:" + name.(string)).SetVal(map[string]string{})
+		}
+	}
+	expectedErrors := []ImportError{
+		// 같은 batch의 앞 record(200)와 비교
+		{Record: 3, Message: "rejected by users rule: score delta exceeds 500"},
+		{Record: 4, Message: "banned user: Cheater"},
+		{Record: 5, Message: "rejected by users rule: score exceeds 1000"},
+	}
+
+	// dry run도 같은 user는 처음 한 번만 추가로 셈
+	expectStates()
+	result, err := lb.ImportUsers(ctx, newDecoder(), true)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &ImportResult{
+			DryRun:  true,
+			Total:   6,
+			Added:   1,
+			Updated: 2,
+			Invalid: 3,
+			Errors:  expectedErrors,
+		}, result)
+	}
+
+	// 확인한 뒤에 차단된 user는 기록하지 않고, 마지막으로 기록한 user로 알림
+	expectStates()
+	for _, record := range []struct {
+		name   string
+		score  float64
+		result int64
+	}{{"Minsik", 100, 1}, {"Minsik", 200, 0}, {"Yumi", 60, -1}} {
+		args := []interface{}{ZSetKeyName + ":changes", ZSetKeyName + ":segment:", record.name, record.score, ZSetKeyName + ":group:", GroupScoreSum, 0}
+		mock.Regexp().ExpectEvalSha(scriptSHA, userKeys(record.name), args...).SetVal(record.result)
+	}
+	mock.ExpectPublish(ZSetKeyName+":changes", []byte(`{"name":"Minsik","score":200}`)).SetVal(1)
+	result, err = lb.ImportUsers(ctx, newDecoder(), false)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &ImportResult{
+			Total:   6,
+			Added:   1,
+			Updated: 1,
+			Invalid: 4,
+			Errors:  append(expectedErrors, ImportError{Record: 6, Message: "banned user: Yumi"}),
+		}, result)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,198 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// 한 watcher가 순위를 받을 수 있는 최대 user 수 (watch, around 각각)
+const MaxWatchedUsers = MaxPageSize
+
+// around는 user 앞뒤로 최대 MaxAroundSize명씩 보여줍니다.
+const MaxAroundSize = MaxPageSize / 2
+
+// top N과 관심 user들의 순위를 다시 읽어 이전 결과와 달라진 부분만 돌려줍니다.
+// top N은 GetUserList(0, N-1)이므로 redis zset 순서대로 점수가 낮은 N명입니다.
+// 변경 알림(Subscribe)을 받을 때마다 Poll을 호출하는 용도입니다.
+// 동시에 여러 goroutine에서 사용할 수 없습니다.
+type RankWatcher struct {
+	lb     Interface
+	top    int64
+	names  []string
+	around map[string]int64
+
+	topPolled  bool
+	lastTop    []User
+	lastRanks  map[string]UserRank
+	lastAround map[string][]UserRank
+}
+
+type RankUpdate struct {
+	// top이 바뀌지 않았으면 nil
+	Top []User `json:"top,omitempty"`
+	// 순위나 점수가 바뀐 user만 포함. 없는 user는 rank -1
+	Ranks []UserRank `json:"ranks,omitempty"`
+	// 주변 순위가 바뀐 user만 포함
+	Around []AroundRank `json:"around,omitempty"`
+}
+
+// Name 주변의 순위. Name이 없으면 Users는 비어있습니다.
+type AroundRank struct {
+	Name  string     `json:"name"`
+	Users []UserRank `json:"users"`
+}
+
+func NewRankWatcher(lb Interface, top int64, names []string) *RankWatcher {
+	return &RankWatcher{
+		lb:         lb,
+		top:        top,
+		names:      names,
+		around:     map[string]int64{},
+		lastRanks:  map[string]UserRank{},
+		lastAround: map[string][]UserRank{},
+	}
+}
+
+// top N을 바꿉니다. 0이면 top을 받지 않습니다. 다음 Poll은 top 전체를 반환합니다.
+func (w *RankWatcher) SetTop(top int64) error {
+	if top < 0 || top > MaxPageSize {
+		return ErrorWithStatusCode(errors.New("invalid top"), http.StatusBadRequest)
+	}
+	w.top = top
+	w.topPolled = false
+	w.lastTop = nil
+	return nil
+}
+
+func (w *RankWatcher) Watch(name string) error {
+	if name == "" {
+		return ErrorWithStatusCode(errors.New("invalid name"), http.StatusBadRequest)
+	}
+	for _, watched := range w.names {
+		if watched == name {
+			return nil
+		}
+	}
+	if len(w.names) >= MaxWatchedUsers {
+		return ErrorWithStatusCode(errors.New("too many watched users"), http.StatusBadRequest)
+	}
+	w.names = append(w.names, name)
+	return nil
+}
+
+func (w *RankWatcher) Unwatch(name string) {
+	for i, watched := range w.names {
+		if watched == name {
+			w.names = append(w.names[:i], w.names[i+1:]...)
+			break
+		}
+	}
+	delete(w.lastRanks, name)
+}
+
+// name 앞뒤로 size명씩의 순위를 받습니다. 이미 받고 있다면 size만 바꿉니다.
+func (w *RankWatcher) WatchAround(name string, size int64) error {
+	if name == "" {
+		return ErrorWithStatusCode(errors.New("invalid name"), http.StatusBadRequest)
+	}
+	if size <= 0 || size > MaxAroundSize {
+		return ErrorWithStatusCode(errors.New("invalid size"), http.StatusBadRequest)
+	}
+	if _, ok := w.around[name]; !ok && len(w.around) >= MaxWatchedUsers {
+		return ErrorWithStatusCode(errors.New("too many watched users"), http.StatusBadRequest)
+	}
+	w.around[name] = size
+	delete(w.lastAround, name)
+	return nil
+}
+
+func (w *RankWatcher) UnwatchAround(name string) {
+	delete(w.around, name)
+	delete(w.lastAround, name)
+}
+
+// 바뀐 것이 없으면 nil을 반환합니다. 첫 호출은 현재 상태 전체를 반환합니다.
+func (w *RankWatcher) Poll(ctx context.Context) (*RankUpdate, error) {
+	update := RankUpdate{}
+	if w.top > 0 {
+		top, err := w.lb.GetUserList(ctx, 0, w.top-1)
+		if err != nil {
+			return nil, errors.Wrap(err, "w.lb.GetUserList")
+		}
+		if !w.topPolled || !reflect.DeepEqual(top, w.lastTop) {
+			update.Top = top
+			w.lastTop = top
+			w.topPolled = true
+		}
+	}
+	for _, name := range w.names {
+		userRank, err := w.userRank(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if last, ok := w.lastRanks[name]; ok && last.Score == userRank.Score && last.Rank == userRank.Rank {
+			continue
+		}
+		w.lastRanks[name] = userRank
+		update.Ranks = append(update.Ranks, userRank)
+	}
+	aroundNames := make([]string, 0, len(w.around))
+	for name := range w.around {
+		aroundNames = append(aroundNames, name)
+	}
+	sort.Strings(aroundNames)
+	for _, name := range aroundNames {
+		users, err := w.aroundRanks(ctx, name, w.around[name])
+		if err != nil {
+			return nil, err
+		}
+		if last, ok := w.lastAround[name]; ok && reflect.DeepEqual(last, users) {
+			continue
+		}
+		w.lastAround[name] = users
+		update.Around = append(update.Around, AroundRank{Name: name, Users: users})
+	}
+
+	if update.Top == nil && len(update.Ranks) == 0 && len(update.Around) == 0 {
+		return nil, nil
+	}
+	return &update, nil
+}
+
+func (w *RankWatcher) userRank(ctx context.Context, name string) (UserRank, error) {
+	userRank, err := w.lb.GetUser(ctx, name)
+	if err == nil {
+		return *userRank, nil
+	}
+	if StatusCode(err) == http.StatusNotFound {
+		return UserRank{User: User{Name: name}, Rank: -1}, nil
+	}
+	return UserRank{}, errors.Wrap(err, "w.lb.GetUser")
+}
+
+func (w *RankWatcher) aroundRanks(ctx context.Context, name string, size int64) ([]UserRank, error) {
+	userRank, err := w.userRank(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if userRank.Rank < 0 {
+		return []UserRank{}, nil
+	}
+	start := userRank.Rank - size
+	if start < 0 {
+		start = 0
+	}
+	users, err := w.lb.GetUserList(ctx, start, userRank.Rank+size)
+	if err != nil {
+		return nil, errors.Wrap(err, "w.lb.GetUserList")
+	}
+	result := make([]UserRank, 0, len(users))
+	for i, user := range users {
+		result = append(result, UserRank{User: user, Rank: start + int64(i)})
+	}
+	return result, nil
+}
@@ -0,0 +1,134 @@
+package leaderboard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankWatcher(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+	watcher := NewRankWatcher(lb, 2, []string{"Minsik", "Foo"})
+
+	expectPoll := func(top []redis.Z, minsikRank int64, minsikScore float64) {
+		mock.ExpectZRangeWithScores(ZSetKeyName, 0, 1).SetVal(top)
+		expectGet(mock, ZSetKeyName, "Minsik", minsikScore, minsikRank)
+		mock.Regexp().ExpectEvalSha(scriptSHA, getKeys(ZSetKeyName, "Foo"), "Foo").RedisNil()
+	}
+
+	// 첫 Poll은 전체 상태
+	expectPoll([]redis.Z{{Score: 500, Member: "Yumi"}, {Score: 100, Member: "Minsik"}}, 1, 100)
+	update, err := watcher.Poll(ctx)
+	if assert.NoError(t, err) && assert.NotNil(t, update) {
+		assert.Equal(t, []User{{Name: "Yumi", Score: 500}, {Name: "Minsik", Score: 100}}, update.Top)
+		assert.Equal(t, []UserRank{
+			{User: User{Name: "Minsik", Score: 100}, Rank: 1},
+			{User: User{Name: "Foo"}, Rank: -1},
+		}, update.Ranks)
+	}
+
+	// 바뀐 것이 없음
+	expectPoll([]redis.Z{{Score: 500, Member: "Yumi"}, {Score: 100, Member: "Minsik"}}, 1, 100)
+	update, err = watcher.Poll(ctx)
+	if assert.NoError(t, err) {
+		assert.Nil(t, update)
+	}
+
+	// Minsik 순위만 바뀜
+	expectPoll([]redis.Z{{Score: 500, Member: "Yumi"}, {Score: 100, Member: "Minsik"}}, 2, 100)
+	update, err = watcher.Poll(ctx)
+	if assert.NoError(t, err) && assert.NotNil(t, update) {
+		assert.Nil(t, update.Top)
+		assert.Equal(t, []UserRank{{User: User{Name: "Minsik", Score: 100}, Rank: 2}}, update.Ranks)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRankWatcherAround(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	lb := &LeaderBoard{
+		redisStorage: redisstorage.NewMock(ZSetKeyName, db),
+	}
+	watcher := NewRankWatcher(lb, 0, nil)
+
+	// 입력값 확인
+	var apiErr interface{ StatusCode() int }
+	for _, err := range []error{
+		watcher.SetTop(MaxPageSize + 1),
+		watcher.Watch(""),
+		watcher.WatchAround("Minsik", 0),
+		watcher.WatchAround("Minsik", MaxAroundSize+1),
+	} {
+		if assert.ErrorAs(t, err, &apiErr) {
+			assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		}
+	}
+	assert.NoError(t, watcher.WatchAround("Minsik", 1))
+
+	expectPoll := func(minsikRank int64, around []redis.Z) {
+		expectGet(mock, ZSetKeyName, "Minsik", 100, minsikRank)
+		start := minsikRank - 1
+		if start < 0 {
+			start = 0
+		}
+		mock.ExpectZRangeWithScores(ZSetKeyName, start, minsikRank+1).SetVal(around)
+	}
+
+	// 1등이면 뒤쪽만
+	expectPoll(0, []redis.Z{{Score: 100, Member: "Minsik"}, {Score: 200, Member: "Yumi"}})
+	update, err := watcher.Poll(ctx)
+	if assert.NoError(t, err) && assert.NotNil(t, update) {
+		assert.Equal(t, []AroundRank{{
+			Name: "Minsik",
+			Users: []UserRank{
+				{User: User{Name: "Minsik", Score: 100}, Rank: 0},
+				{User: User{Name: "Yumi", Score: 200}, Rank: 1},
+			},
+		}}, update.Around)
+	}
+
+	// 바뀐 것이 없음
+	expectPoll(0, []redis.Z{{Score: 100, Member: "Minsik"}, {Score: 200, Member: "Yumi"}})
+	update, err = watcher.Poll(ctx)
+	if assert.NoError(t, err) {
+		assert.Nil(t, update)
+	}
+
+	// 순위가 내려감
+	expectPoll(1, []redis.Z{{Score: 50, Member: "Foo"}, {Score: 100, Member: "Minsik"}, {Score: 200, Member: "Yumi"}})
+	update, err = watcher.Poll(ctx)
+	if assert.NoError(t, err) && assert.NotNil(t, update) {
+		assert.Equal(t, []AroundRank{{
+			Name: "Minsik",
+			Users: []UserRank{
+				{User: User{Name: "Foo", Score: 50}, Rank: 0},
+				{User: User{Name: "Minsik", Score: 100}, Rank: 1},
+				{User: User{Name: "Yumi", Score: 200}, Rank: 2},
+			},
+		}}, update.Around)
+	}
+
+	// 구독 해제
+	watcher.UnwatchAround("Minsik")
+	update, err = watcher.Poll(ctx)
+	if assert.NoError(t, err) {
+		assert.Nil(t, update)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// log entry에서 요청 ID를 기록하는 field
+const RequestIDField = "requestID"
+
+type requestIDKey struct{}
+
+// FromContext가 사용하는 logger. 시작할 때 SetDefault로 한 번만 바꿉니다.
+var std = logrus.StandardLogger()
+
+func SetDefault(log *Logger) {
+	std = log.Logger
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// ctx에 요청 ID가 없으면 빈 문자열
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ctx의 요청 ID를 붙여서 기록하는 entry
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(std).WithContext(ctx)
+	if id := RequestID(ctx); id != "" {
+		entry = entry.WithField(RequestIDField, id)
+	}
+	return entry
+}
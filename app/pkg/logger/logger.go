@@ -1,48 +1,145 @@
 package logger
 
 import (
+	"io"
 	"net/http"
 	"os"
-	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/olivere/elastic/v7"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/sohlich/elogrus.v7"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// log를 보낼 곳
+const (
+	SinkNone          = "none"
+	SinkStdout        = "stdout"
+	SinkFile          = "file"
+	SinkElasticsearch = "elasticsearch"
+)
+
+const (
+	defaultFileMaxSize    = 100 // MB
+	defaultFileMaxBackups = 5
+)
+
+type Config struct {
+	// 비어 있으면 ElasticsearchURL이 있을 때 elasticsearch, 없으면 stdout
+	Sink string
+	// elasticsearch의 날짜별 index 이름 앞부분, file sink의 기본 file 이름
+	Name string
+	// file sink의 경로. 비어 있으면 <Name>.log
+	File string
+	// file이 이 크기(MB)를 넘으면 새 file로 바꿈. 0이면 100MB
+	FileMaxSize int
+	// elasticsearch sink의 주소
+	ElasticsearchURL string
+	// elasticsearch를 사용할 수 없는 동안 log를 쌓아두는 file. 비어 있으면 <Name>.spool
+	SpoolFile string
+}
+
 type Logger struct {
 	*logrus.Logger
+	sink    string
+	shipper *Shipper
 }
 
-func New() *Logger {
-	return &Logger{
+// cfg.Sink로 log를 보내는 Logger를 만듭니다.
+// elasticsearch에 연결하지 못하면 process를 멈추지 않고 stdout으로 보내며, 이유를 warning으로 남김
+func New(cfg Config) (*Logger, error) {
+	log := &Logger{
 		Logger: logrus.New(),
+		sink:   cfg.Sink,
 	}
+	if log.sink == "" {
+		log.sink = SinkStdout
+		if cfg.ElasticsearchURL != "" {
+			log.sink = SinkElasticsearch
+		}
+	}
+
+	switch log.sink {
+	case SinkNone:
+		log.SetOutput(io.Discard)
+	case SinkStdout:
+		log.useStdout()
+	case SinkFile:
+		log.useFile(cfg)
+	case SinkElasticsearch:
+		if err := log.addElasticHook(cfg); err != nil {
+			log.useStdout()
+			log.WithError(err).Warn("elasticsearch를 사용할 수 없어 stdout으로 log를 보냅니다.")
+		}
+	default:
+		return nil, errors.New("invalid log sink: " + cfg.Sink)
+	}
+	return log, nil
 }
 
-func (log *Logger) AddElasticHook(e *echo.Echo, logname string) error {
-	elasticsearchURL := os.Getenv("ELASTICSEARCH_URL")
-	client, err := elastic.NewClient(elastic.SetURL(elasticsearchURL))
-	if err != nil {
-		return errors.Wrap(err, "elastic.NewClient")
+// 실제로 log를 보내는 곳. elasticsearch에 연결하지 못했으면 stdout
+func (log *Logger) Sink() string {
+	return log.sink
+}
+
+// elasticsearch sink가 아니면 nil
+func (log *Logger) Shipper() *Shipper {
+	return log.shipper
+}
+
+// 아직 elasticsearch로 보내지 못한 log를 보내고 멈춥니다. 보내지 못한 log는 spool file에 남김
+func (log *Logger) Close() {
+	if log.shipper != nil {
+		log.shipper.Close()
 	}
-	hostname, err := os.Hostname()
-	if err != nil {
-		return errors.Wrap(err, "os.Hostname")
+}
+
+func (log *Logger) useStdout() {
+	log.sink = SinkStdout
+	log.SetOutput(os.Stdout)
+	log.SetFormatter(&logrus.JSONFormatter{})
+}
+
+func (log *Logger) useFile(cfg Config) {
+	file := cfg.File
+	if file == "" {
+		file = cfg.Name + ".log"
+	}
+	maxSize := cfg.FileMaxSize
+	if maxSize <= 0 {
+		maxSize = defaultFileMaxSize
 	}
+	log.SetOutput(&lumberjack.Logger{
+		Filename:   file,
+		MaxSize:    maxSize,
+		MaxBackups: defaultFileMaxBackups,
+	})
+	log.SetFormatter(&logrus.JSONFormatter{})
+}
 
-	hook, err := elogrus.NewAsyncElasticHookWithFunc(client, hostname, logrus.DebugLevel, func() string {
-		return logname + "-" + time.Now().Format("2006-01-02")
+// 시작할 때 연결하지 못하면 error. 그 뒤에 연결이 끊긴 동안에는 spool file에 쌓았다가 다시 보냄
+func (log *Logger) addElasticHook(cfg Config) error {
+	client, err := elastic.NewClient(elastic.SetURL(cfg.ElasticsearchURL))
+	if err != nil {
+		return errors.Wrap(err, "elastic.NewClient")
+	}
+	shipper, err := NewShipper(client, ShipperConfig{
+		Name:      cfg.Name,
+		SpoolFile: cfg.SpoolFile,
 	})
 	if err != nil {
-		return errors.Wrap(err, "elogrus.NewAsyncElasticHook")
+		return err
 	}
-	log.Hooks.Add(hook)
+	log.Hooks.Add(shipper)
+	log.shipper = shipper
+	return nil
+}
 
-	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+// 요청마다 한 줄씩 log를 남기는 middleware. 5xx는 warning
+func (log *Logger) RequestLogger() echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 		LogLatency:      true,
 		LogRemoteIP:     true,
 		LogHost:         true,
@@ -55,6 +152,7 @@ func (log *Logger) AddElasticHook(e *echo.Echo, logname string) error {
 		LogResponseSize: true,
 		LogValuesFunc: func(c echo.Context, values middleware.RequestLoggerValues) error {
 			entry := log.WithFields(logrus.Fields{
+				RequestIDField: RequestID(c.Request().Context()),
 				"latency(ms)":  values.Latency.Milliseconds(),
 				"remoteIP":     values.RemoteIP,
 				"host":         values.Host,
@@ -73,6 +171,5 @@ func (log *Logger) AddElasticHook(e *echo.Echo, logname string) error {
 			}
 			return nil
 		},
-	}))
-	return nil
+	})
 }
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	log, err := New(Config{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, SinkStdout, log.Sink())
+	}
+	log, err = New(Config{Sink: SinkNone})
+	if assert.NoError(t, err) {
+		assert.Equal(t, SinkNone, log.Sink())
+	}
+	_, err = New(Config{Sink: "kafka"})
+	assert.ErrorContains(t, err, "invalid log sink: kafka")
+}
+
+func TestElasticsearchFallback(t *testing.T) {
+	// 연결할 수 없으면 process를 멈추지 않고 stdout으로 보냄
+	log, err := New(Config{Name: "api-log", ElasticsearchURL: "http://127.0.0.1:1"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, SinkStdout, log.Sink())
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "api.log")
+	log, err := New(Config{Sink: SinkFile, Name: "api-log", File: file})
+	if !assert.NoError(t, err) {
+		return
+	}
+	log.Info("hello")
+
+	body, err := os.ReadFile(file)
+	if assert.NoError(t, err) {
+		var entry map[string]interface{}
+		assert.NoError(t, json.Unmarshal(body, &entry))
+		assert.Equal(t, "hello", entry["msg"])
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	log, err := New(Config{Sink: SinkStdout})
+	if !assert.NoError(t, err) {
+		return
+	}
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+
+	e := echo.New()
+	e.Use(log.RequestLogger())
+	e.GET("/users/:start/to/:stop", func(c echo.Context) error {
+		return c.NoContent(http.StatusInternalServerError)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/users/0/to/1", nil)
+	e.ServeHTTP(httptest.NewRecorder(), req.WithContext(WithRequestID(req.Context(), "request-1")))
+
+	var entry map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry)) {
+		assert.Equal(t, "request-1", entry[RequestIDField])
+		assert.Equal(t, "warning", entry["level"])
+		assert.Equal(t, "/users/:start/to/:stop", entry["routePath"])
+		assert.Equal(t, float64(http.StatusInternalServerError), entry["status"])
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	log, err := New(Config{Sink: SinkStdout})
+	if !assert.NoError(t, err) {
+		return
+	}
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	SetDefault(log)
+
+	ctx := context.Background()
+	assert.Equal(t, "", RequestID(ctx))
+	FromContext(ctx).Info("no request")
+	assert.NotContains(t, buf.String(), RequestIDField)
+
+	buf.Reset()
+	ctx = WithRequestID(ctx, "request-1")
+	assert.Equal(t, "request-1", RequestID(ctx))
+	FromContext(ctx).Error("failed")
+	var entry map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry)) {
+		assert.Equal(t, "request-1", entry[RequestIDField])
+		assert.Equal(t, "failed", entry["msg"])
+	}
+}
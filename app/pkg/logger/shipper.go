@@ -0,0 +1,408 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBatchSize     = 500
+	defaultFlushInterval = time.Second
+	defaultQueueSize     = 10000
+	defaultSpoolMaxSize  = 64 << 20 // 64MB
+	defaultMinBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff    = 30 * time.Second
+	bulkTimeout          = 10 * time.Second
+)
+
+type ShipperConfig struct {
+	// 날짜별 index 이름 앞부분 (<Name>-YYYY-mm-dd)
+	Name string
+	// 한 번에 bulk로 보내는 최대 log 수. 0이면 500
+	BatchSize int
+	// 모인 log를 보내는 주기. 0이면 1초
+	FlushInterval time.Duration
+	// 보내기 전에 memory에 쌓아두는 최대 log 수. 넘으면 버림. 0이면 10000
+	QueueSize int
+	// elasticsearch를 사용할 수 없는 동안 log를 쌓아두는 file. 비어 있으면 <Name>.spool
+	SpoolFile string
+	// spool file의 최대 크기(byte). 넘으면 버림. 0이면 64MB
+	SpoolMaxSize int64
+	// 실패하면 MinBackoff부터 두 배씩 MaxBackoff까지 기다렸다가 다시 보냄. 0이면 0.5초, 30초
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+type ShipperStats struct {
+	// 보내기를 기다리는 log 수
+	Queued int64
+	// spool file에 쌓인 log 수
+	Spooled int64
+	// queue나 spool이 가득 찼거나 elasticsearch가 거부해서 버린 log 수
+	Dropped int64
+	// elasticsearch에 기록한 log 수
+	Shipped int64
+}
+
+// spool file에 한 줄씩 JSON으로 기록
+type document struct {
+	Index  string          `json:"index"`
+	Source json.RawMessage `json:"source"`
+}
+
+// elogrus와 같은 형식으로 기록해서 기존 index, kibana 설정을 그대로 사용
+type message struct {
+	Host      string        `json:"Host"`
+	Timestamp string        `json:"@timestamp"`
+	Message   string        `json:"Message"`
+	Data      logrus.Fields `json:"Data"`
+	Level     string        `json:"Level"`
+}
+
+// log를 모아서 elasticsearch에 bulk로 기록하는 logrus hook
+// elasticsearch가 느리거나 멈춰도 log를 남기는 쪽을 막지 않고, 보내지 못한 log는 spool file에 쌓았다가 다시 보냅니다.
+type Shipper struct {
+	client *elastic.Client
+	cfg    ShipperConfig
+	host   string
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan document
+	done   chan struct{}
+
+	// run goroutine에서만 사용
+	spoolSize int64
+	backoff   time.Duration
+	retryAt   time.Time
+
+	spooled int64
+	dropped int64
+	shipped int64
+}
+
+func NewShipper(client *elastic.Client, cfg ShipperConfig) (*Shipper, error) {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.SpoolFile == "" {
+		cfg.SpoolFile = cfg.Name + ".spool"
+	}
+	if cfg.SpoolMaxSize <= 0 {
+		cfg.SpoolMaxSize = defaultSpoolMaxSize
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = defaultMinBackoff
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = defaultMaxBackoff
+		if cfg.MaxBackoff < cfg.MinBackoff {
+			cfg.MaxBackoff = cfg.MinBackoff
+		}
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "os.Hostname")
+	}
+
+	s := &Shipper{
+		client:  client,
+		cfg:     cfg,
+		host:    host,
+		queue:   make(chan document, cfg.QueueSize),
+		done:    make(chan struct{}),
+		backoff: cfg.MinBackoff,
+	}
+	// 이전에 보내지 못하고 종료했으면 이어서 보냄
+	if err := s.loadSpool(); err != nil {
+		return nil, err
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *Shipper) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// queue가 가득 차면 기다리지 않고 버립니다.
+func (s *Shipper) Fire(entry *logrus.Entry) error {
+	data := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[k] = v
+	}
+	source, err := json.Marshal(message{
+		Host:      s.host,
+		Timestamp: entry.Time.UTC().Format(time.RFC3339Nano),
+		Message:   entry.Message,
+		Data:      data,
+		Level:     strings.ToUpper(entry.Level.String()),
+	})
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+	doc := document{
+		Index:  s.cfg.Name + "-" + entry.Time.Format("2006-01-02"),
+		Source: source,
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		atomic.AddInt64(&s.dropped, 1)
+		return nil
+	}
+	select {
+	case s.queue <- doc:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+	return nil
+}
+
+func (s *Shipper) Stats() ShipperStats {
+	return ShipperStats{
+		Queued:  int64(len(s.queue)),
+		Spooled: atomic.LoadInt64(&s.spooled),
+		Dropped: atomic.LoadInt64(&s.dropped),
+		Shipped: atomic.LoadInt64(&s.shipped),
+	}
+}
+
+// 남은 log를 한 번 더 보내고 멈춥니다. 보내지 못한 log는 spool file에 남김
+func (s *Shipper) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+}
+
+func (s *Shipper) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]document, 0, s.cfg.BatchSize)
+	for {
+		select {
+		case doc, ok := <-s.queue:
+			if !ok {
+				s.retryAt = time.Time{}
+				s.flush(batch)
+				return
+			}
+			batch = append(batch, doc)
+			if len(batch) >= s.cfg.BatchSize {
+				s.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			s.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// 기다리는 중이면 spool file에 쌓고, 아니면 spool file에 쌓인 log부터 보냅니다.
+func (s *Shipper) flush(batch []document) {
+	if time.Now().Before(s.retryAt) {
+		s.spool(batch)
+		return
+	}
+	if err := s.drainSpool(); err != nil {
+		s.fail()
+		s.spool(batch)
+		return
+	}
+	retry, err := s.send(batch)
+	if err != nil {
+		s.fail()
+		s.spool(batch)
+		return
+	}
+	if len(retry) > 0 {
+		s.fail()
+		s.spool(retry)
+		return
+	}
+	s.backoff = s.cfg.MinBackoff
+	s.retryAt = time.Time{}
+}
+
+func (s *Shipper) fail() {
+	s.retryAt = time.Now().Add(s.backoff)
+	s.backoff *= 2
+	if s.backoff > s.cfg.MaxBackoff {
+		s.backoff = s.cfg.MaxBackoff
+	}
+}
+
+// 다시 보내야 하는 log(429, 5xx)를 반환합니다. 그 밖의 이유로 거부한 log는 버림
+func (s *Shipper) send(docs []document) ([]document, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	bulk := s.client.Bulk()
+	for _, doc := range docs {
+		bulk.Add(elastic.NewBulkIndexRequest().Index(doc.Index).Doc(doc.Source))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), bulkTimeout)
+	defer cancel()
+	res, err := bulk.Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "bulk.Do")
+	}
+
+	var retry []document
+	var rejected int64
+	for i, item := range res.Items {
+		if i >= len(docs) {
+			break
+		}
+		for _, result := range item {
+			switch {
+			case result.Status == http.StatusTooManyRequests || result.Status >= http.StatusInternalServerError:
+				retry = append(retry, docs[i])
+			case result.Status >= http.StatusMultipleChoices:
+				rejected++
+			}
+		}
+	}
+	atomic.AddInt64(&s.dropped, rejected)
+	atomic.AddInt64(&s.shipped, int64(len(docs)-len(retry))-rejected)
+	return retry, nil
+}
+
+// spool file 크기를 넘는 log는 버립니다.
+func (s *Shipper) spool(docs []document) {
+	if len(docs) == 0 {
+		return
+	}
+	file, err := os.OpenFile(s.cfg.SpoolFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		atomic.AddInt64(&s.dropped, int64(len(docs)))
+		return
+	}
+	defer file.Close()
+
+	for _, doc := range docs {
+		line, err := json.Marshal(doc)
+		if err != nil || s.spoolSize+int64(len(line))+1 > s.cfg.SpoolMaxSize {
+			atomic.AddInt64(&s.dropped, 1)
+			continue
+		}
+		if _, err := file.Write(append(line, '\n')); err != nil {
+			atomic.AddInt64(&s.dropped, 1)
+			continue
+		}
+		s.spoolSize += int64(len(line)) + 1
+		atomic.AddInt64(&s.spooled, 1)
+	}
+}
+
+// spool file의 log를 BatchSize씩 보냅니다. 실패하면 보내지 못한 log만 spool file에 남김
+func (s *Shipper) drainSpool() error {
+	if atomic.LoadInt64(&s.spooled) == 0 {
+		return nil
+	}
+	docs, err := s.readSpool()
+	if err != nil {
+		return err
+	}
+
+	var pending []document
+	var sendErr error
+	for start := 0; start < len(docs); start += s.cfg.BatchSize {
+		stop := start + s.cfg.BatchSize
+		if stop > len(docs) {
+			stop = len(docs)
+		}
+		retry, err := s.send(docs[start:stop])
+		if err != nil {
+			pending = append(pending, docs[start:]...)
+			sendErr = err
+			break
+		}
+		pending = append(pending, retry...)
+	}
+
+	if err := os.Truncate(s.cfg.SpoolFile, 0); err != nil {
+		return errors.Wrap(err, "os.Truncate")
+	}
+	s.spoolSize = 0
+	atomic.StoreInt64(&s.spooled, 0)
+	s.spool(pending)
+	if sendErr != nil {
+		return sendErr
+	}
+	if len(pending) > 0 {
+		return errors.New("elasticsearch rejected spooled logs")
+	}
+	return nil
+}
+
+func (s *Shipper) readSpool() ([]document, error) {
+	file, err := os.Open(s.cfg.SpoolFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.Open")
+	}
+	defer file.Close()
+
+	var docs []document
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, int(s.cfg.SpoolMaxSize))
+	for scanner.Scan() {
+		var doc document
+		// 종료 중에 잘린 줄은 건너뜀
+		if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			atomic.AddInt64(&s.dropped, 1)
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	return docs, errors.Wrap(scanner.Err(), "scanner.Scan")
+}
+
+func (s *Shipper) loadSpool() error {
+	file, err := os.Open(s.cfg.SpoolFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "os.Open")
+	}
+	defer file.Close()
+
+	var lines int64
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, int(s.cfg.SpoolMaxSize))
+	for scanner.Scan() {
+		s.spoolSize += int64(len(scanner.Bytes())) + 1
+		lines++
+	}
+	atomic.StoreInt64(&s.spooled, lines)
+	return errors.Wrap(scanner.Err(), "scanner.Scan")
+}
@@ -0,0 +1,222 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// _bulk 요청만 처리하는 elasticsearch
+type fakeElasticsearch struct {
+	mu      sync.Mutex
+	down    bool
+	status  int
+	indexed []map[string]interface{}
+	block   chan struct{}
+}
+
+func (es *fakeElasticsearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if es.block != nil {
+		<-es.block
+	}
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	status := es.status
+	if status == 0 {
+		status = http.StatusCreated
+	}
+
+	var items []string
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var action map[string]map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || !scanner.Scan() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var source map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &source); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		source["_index"] = action["index"]["_index"]
+		if status < http.StatusMultipleChoices {
+			es.indexed = append(es.indexed, source)
+		}
+		items = append(items, fmt.Sprintf(`{"index":{"_index":%q,"status":%d}}`, source["_index"], status))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"took":1,"errors":%t,"items":[%s]}`, status >= http.StatusMultipleChoices, strings.Join(items, ","))
+}
+
+func (es *fakeElasticsearch) setDown(down bool) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.down = down
+}
+
+func (es *fakeElasticsearch) count() int {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	return len(es.indexed)
+}
+
+func newTestShipper(t *testing.T, es *fakeElasticsearch, cfg ShipperConfig) *Shipper {
+	server := httptest.NewServer(es)
+	t.Cleanup(server.Close)
+	client, err := elastic.NewClient(elastic.SetURL(server.URL), elastic.SetSniff(false), elastic.SetHealthcheck(false))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	cfg.Name = "api-log"
+	if cfg.SpoolFile == "" {
+		cfg.SpoolFile = filepath.Join(t.TempDir(), "api-log.spool")
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = 10 * time.Millisecond
+	}
+	if cfg.MinBackoff == 0 {
+		cfg.MinBackoff = 10 * time.Millisecond
+	}
+	shipper, err := NewShipper(client, cfg)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return shipper
+}
+
+func newTestLogger(shipper *Shipper) *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(&strings.Builder{})
+	log.Hooks.Add(shipper)
+	return log
+}
+
+func TestShipper(t *testing.T) {
+	es := &fakeElasticsearch{}
+	shipper := newTestShipper(t, es, ShipperConfig{BatchSize: 2})
+	log := newTestLogger(shipper)
+
+	for i := 0; i < 5; i++ {
+		log.WithField("status", 200).Info("request")
+	}
+	shipper.Close()
+
+	assert.Equal(t, 5, es.count())
+	assert.Equal(t, ShipperStats{Shipped: 5}, shipper.Stats())
+	doc := es.indexed[0]
+	assert.Equal(t, "api-log-"+time.Now().Format("2006-01-02"), doc["_index"])
+	assert.Equal(t, "request", doc["Message"])
+	assert.Equal(t, "INFO", doc["Level"])
+	assert.Equal(t, map[string]interface{}{"status": float64(200)}, doc["Data"])
+}
+
+func TestLoggerClose(t *testing.T) {
+	// 종료할 때 queue에 남은 log를 보냄
+	es := &fakeElasticsearch{}
+	shipper := newTestShipper(t, es, ShipperConfig{FlushInterval: time.Hour})
+	log := &Logger{Logger: newTestLogger(shipper), sink: SinkElasticsearch, shipper: shipper}
+	log.Info("before shutdown")
+	log.Close()
+	assert.Equal(t, 1, es.count())
+
+	// elasticsearch sink가 아니면 아무것도 하지 않음
+	log, err := New(Config{Sink: SinkNone})
+	if assert.NoError(t, err) {
+		log.Close()
+	}
+}
+
+func TestShipperSpool(t *testing.T) {
+	es := &fakeElasticsearch{down: true}
+	spoolFile := filepath.Join(t.TempDir(), "api-log.spool")
+	shipper := newTestShipper(t, es, ShipperConfig{SpoolFile: spoolFile})
+	log := newTestLogger(shipper)
+
+	// 사용할 수 없는 동안 spool file에 쌓음
+	for i := 0; i < 3; i++ {
+		log.Info("request")
+	}
+	assert.Eventually(t, func() bool { return shipper.Stats().Spooled == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 0, es.count())
+
+	// 다시 사용할 수 있으면 spool file의 log부터 보냄
+	es.setDown(false)
+	log.Info("recovered")
+	assert.Eventually(t, func() bool { return es.count() == 4 }, time.Second, 5*time.Millisecond)
+	shipper.Close()
+	assert.Equal(t, ShipperStats{Shipped: 4}, shipper.Stats())
+	assert.Equal(t, "recovered", es.indexed[3]["Message"])
+}
+
+func TestShipperReloadSpool(t *testing.T) {
+	es := &fakeElasticsearch{down: true}
+	spoolFile := filepath.Join(t.TempDir(), "api-log.spool")
+	shipper := newTestShipper(t, es, ShipperConfig{SpoolFile: spoolFile})
+	log := newTestLogger(shipper)
+	log.Info("before restart")
+	shipper.Close()
+	assert.Equal(t, int64(1), shipper.Stats().Spooled)
+
+	// 다시 시작하면 이전에 보내지 못한 log를 이어서 보냄
+	es.setDown(false)
+	shipper = newTestShipper(t, es, ShipperConfig{SpoolFile: spoolFile})
+	assert.Equal(t, int64(1), shipper.Stats().Spooled)
+	assert.Eventually(t, func() bool { return es.count() == 1 }, time.Second, 5*time.Millisecond)
+	shipper.Close()
+	assert.Equal(t, ShipperStats{Shipped: 1}, shipper.Stats())
+}
+
+func TestShipperDrop(t *testing.T) {
+	// spool file이 가득 차면 버림
+	es := &fakeElasticsearch{down: true}
+	shipper := newTestShipper(t, es, ShipperConfig{SpoolMaxSize: 400})
+	log := newTestLogger(shipper)
+	for i := 0; i < 5; i++ {
+		log.Info("request")
+	}
+	shipper.Close()
+	stats := shipper.Stats()
+	assert.Greater(t, stats.Spooled, int64(0))
+	assert.Greater(t, stats.Dropped, int64(0))
+	assert.Equal(t, int64(5), stats.Spooled+stats.Dropped)
+
+	// 다시 보내도 받아들이지 않는 log는 버림
+	es = &fakeElasticsearch{status: http.StatusBadRequest}
+	shipper = newTestShipper(t, es, ShipperConfig{})
+	newTestLogger(shipper).Info("invalid")
+	shipper.Close()
+	assert.Equal(t, ShipperStats{Dropped: 1}, shipper.Stats())
+}
+
+func TestShipperQueueFull(t *testing.T) {
+	// elasticsearch가 응답하지 않아도 log를 남기는 쪽은 기다리지 않음
+	es := &fakeElasticsearch{block: make(chan struct{})}
+	shipper := newTestShipper(t, es, ShipperConfig{BatchSize: 1, QueueSize: 1})
+	log := newTestLogger(shipper)
+	for i := 0; i < 10; i++ {
+		log.Info("request")
+	}
+	stats := shipper.Stats()
+	assert.GreaterOrEqual(t, stats.Dropped, int64(8))
+	assert.LessOrEqual(t, stats.Queued, int64(1))
+	close(es.block)
+	shipper.Close()
+	stats = shipper.Stats()
+	assert.Equal(t, int64(10), stats.Shipped+stats.Dropped)
+}
@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "leaderboard"
+
+// 점수 기록 결과
+const (
+	SubmissionCreated     = "created"
+	SubmissionUpdated     = "updated"
+	SubmissionRejected    = "rejected"
+	SubmissionQuarantined = "quarantined"
+)
+
+// scrape할 때 board별 user 수를 읽는 시간 제한
+const boardSizesTimeout = 5 * time.Second
+
+// /metrics에서 노출하는 metric. 기본 registry 대신 각자의 registry를 사용해서 테스트와 다른 package의 metric이 섞이지 않게 합니다.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	redisDuration   *prometheus.HistogramVec
+	redisErrors     *prometheus.CounterVec
+	submissions     *prometheus.CounterVec
+}
+
+// server에서 사용하는 Metrics. ObserveRequest, ObserveRedisCommand, AddSubmission은 여기에 기록합니다.
+var Default = New()
+
+// 새 registry에 metric을 등록합니다.
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "route, status별 HTTP 요청 수",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "route, status별 HTTP 요청 처리 시간",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		redisDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "redis_command_duration_seconds",
+			Help:      "redis 명령별 처리 시간. pipeline은 한 번에 기록",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"command"}),
+		redisErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redis_command_errors_total",
+			Help:      "redis 명령별 error 수. 결과가 없는 경우(redis.Nil)는 제외",
+		}, []string{"command"}),
+		submissions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "submissions_total",
+			Help:      "점수 기록 결과별 수 (created, updated, rejected, quarantined)",
+		}, []string{"outcome"}),
+	}
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.requestDuration, m.redisDuration, m.redisErrors, m.submissions,
+	)
+	for _, outcome := range []string{SubmissionCreated, SubmissionUpdated, SubmissionRejected, SubmissionQuarantined} {
+		m.submissions.WithLabelValues(outcome)
+	}
+	return m
+}
+
+// board 크기를 읽지 못해도 나머지 metric은 보냅니다.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// route는 등록한 path(예: /users/:start/to/:stop)이므로 요청마다 label이 늘어나지 않습니다.
+func (m *Metrics) ObserveRequest(method string, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.requestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveRedisCommand(command string, duration time.Duration, failed bool) {
+	m.redisDuration.WithLabelValues(command).Observe(duration.Seconds())
+	if failed {
+		m.redisErrors.WithLabelValues(command).Inc()
+	}
+}
+
+func (m *Metrics) AddSubmission(outcome string) {
+	m.submissions.WithLabelValues(outcome).Inc()
+}
+
+// Default에 기록합니다.
+func ObserveRedisCommand(command string, duration time.Duration, failed bool) {
+	Default.ObserveRedisCommand(command, duration, failed)
+}
+
+// Default에 기록합니다.
+func AddSubmission(outcome string) {
+	Default.AddSubmission(outcome)
+}
+
+// board 이름별 user 수를 반환합니다.
+type BoardSizes func(ctx context.Context) (map[string]int64, error)
+
+type boardCollector struct {
+	sizes BoardSizes
+	desc  *prometheus.Desc
+}
+
+func (c *boardCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *boardCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), boardSizesTimeout)
+	defer cancel()
+	sizes, err := c.sizes(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for board, size := range sizes {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(size), board)
+	}
+}
+
+// scrape할 때마다 sizes로 board별 user 수를 읽습니다.
+func (m *Metrics) RegisterBoards(sizes BoardSizes) error {
+	err := m.Registry.Register(&boardCollector{
+		sizes: sizes,
+		desc:  prometheus.NewDesc(namespace+"_board_users", "board별 user 수", []string{"board"}, nil),
+	})
+	return errors.Wrap(err, "m.Registry.Register")
+}
+
+// elasticsearch로 보내는 log의 상태
+type LogShipperStats struct {
+	Queued  int64
+	Spooled int64
+	Dropped int64
+	Shipped int64
+}
+
+type logShipperCollector struct {
+	stats                             func() LogShipperStats
+	queued, spooled, dropped, shipped *prometheus.Desc
+}
+
+func (c *logShipperCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.queued
+	ch <- c.spooled
+	ch <- c.dropped
+	ch <- c.shipped
+}
+
+func (c *logShipperCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.queued, prometheus.GaugeValue, float64(stats.Queued))
+	ch <- prometheus.MustNewConstMetric(c.spooled, prometheus.GaugeValue, float64(stats.Spooled))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.shipped, prometheus.CounterValue, float64(stats.Shipped))
+}
+
+// scrape할 때마다 stats로 log 전송 상태를 읽습니다.
+func (m *Metrics) RegisterLogShipper(stats func() LogShipperStats) error {
+	err := m.Registry.Register(&logShipperCollector{
+		stats:   stats,
+		queued:  prometheus.NewDesc(namespace+"_log_queue_depth", "elasticsearch로 보내기를 기다리는 log 수", nil, nil),
+		spooled: prometheus.NewDesc(namespace+"_log_spooled", "elasticsearch를 사용할 수 없어 spool file에 쌓인 log 수", nil, nil),
+		dropped: prometheus.NewDesc(namespace+"_log_dropped_total", "queue나 spool이 가득 찼거나 elasticsearch가 거부해서 버린 log 수", nil, nil),
+		shipped: prometheus.NewDesc(namespace+"_log_shipped_total", "elasticsearch에 기록한 log 수", nil, nil),
+	})
+	return errors.Wrap(err, "m.Registry.Register")
+}
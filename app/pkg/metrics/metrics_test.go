@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestObserve(t *testing.T) {
+	m := New()
+	m.ObserveRequest(http.MethodGet, "/users/:start/to/:stop", http.StatusOK, 30*time.Millisecond)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "/users/:start/to/:stop", "200")))
+
+	m.ObserveRedisCommand("evalsha", time.Millisecond, false)
+	m.ObserveRedisCommand("evalsha", time.Millisecond, true)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.redisErrors.WithLabelValues("evalsha")))
+
+	m.AddSubmission(SubmissionCreated)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.submissions.WithLabelValues(SubmissionCreated)))
+	// 아직 없는 결과도 0으로 노출
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.submissions.WithLabelValues(SubmissionQuarantined)))
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `leaderboard_http_request_duration_seconds_count{method="GET",route="/users/:start/to/:stop",status="200"} 1`)
+	assert.Contains(t, body, `leaderboard_redis_command_duration_seconds_count{command="evalsha"} 2`)
+	assert.Contains(t, body, `leaderboard_submissions_total{outcome="rejected"} 0`)
+}
+
+func TestRegisterBoards(t *testing.T) {
+	m := New()
+	var sizesErr error
+	err := m.RegisterBoards(func(_ context.Context) (map[string]int64, error) {
+		return map[string]int64{"users": 3, "segment:country:KR": 1}, sizesErr
+	})
+	assert.NoError(t, err)
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `leaderboard_board_users{board="users"} 3`)
+	assert.Contains(t, body, `leaderboard_board_users{board="segment:country:KR"} 1`)
+
+	// 읽지 못해도 나머지 metric은 보냄
+	sizesErr = errors.New("redis down")
+	body = scrape(t, m)
+	assert.NotContains(t, body, "leaderboard_board_users{")
+	assert.Contains(t, body, "leaderboard_submissions_total")
+}
+
+func TestRegisterLogShipper(t *testing.T) {
+	m := New()
+	err := m.RegisterLogShipper(func() LogShipperStats {
+		return LogShipperStats{Queued: 3, Spooled: 10, Dropped: 2, Shipped: 100}
+	})
+	assert.NoError(t, err)
+
+	body := scrape(t, m)
+	assert.Contains(t, body, "leaderboard_log_queue_depth 3")
+	assert.Contains(t, body, "leaderboard_log_spooled 10")
+	assert.Contains(t, body, "leaderboard_log_dropped_total 2")
+	assert.Contains(t, body, "leaderboard_log_shipped_total 100")
+}
@@ -0,0 +1,380 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: leaderboard.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// LeaderboardClient is the client API for Leaderboard service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LeaderboardClient interface {
+	UserCount(ctx context.Context, in *UserCountRequest, opts ...grpc.CallOption) (*UserCountResponse, error)
+	AddUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*AddUserResponse, error)
+	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UserRank, error)
+	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*DeleteUserResponse, error)
+	UpdateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*UpdateUserResponse, error)
+	// start ~ stop 순위의 user를 순서대로 보냅니다. 한 번에 최대 100명까지 받을 수 있습니다.
+	GetUserList(ctx context.Context, in *GetUserListRequest, opts ...grpc.CallOption) (Leaderboard_GetUserListClient, error)
+	// 구독 직후 현재 상태를 보내고, 이후에는 바뀐 부분만 보냅니다.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Leaderboard_SubscribeClient, error)
+}
+
+type leaderboardClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLeaderboardClient(cc grpc.ClientConnInterface) LeaderboardClient {
+	return &leaderboardClient{cc}
+}
+
+func (c *leaderboardClient) UserCount(ctx context.Context, in *UserCountRequest, opts ...grpc.CallOption) (*UserCountResponse, error) {
+	out := new(UserCountResponse)
+	err := c.cc.Invoke(ctx, "/leaderboard.Leaderboard/UserCount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leaderboardClient) AddUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*AddUserResponse, error) {
+	out := new(AddUserResponse)
+	err := c.cc.Invoke(ctx, "/leaderboard.Leaderboard/AddUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leaderboardClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UserRank, error) {
+	out := new(UserRank)
+	err := c.cc.Invoke(ctx, "/leaderboard.Leaderboard/GetUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leaderboardClient) DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*DeleteUserResponse, error) {
+	out := new(DeleteUserResponse)
+	err := c.cc.Invoke(ctx, "/leaderboard.Leaderboard/DeleteUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leaderboardClient) UpdateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*UpdateUserResponse, error) {
+	out := new(UpdateUserResponse)
+	err := c.cc.Invoke(ctx, "/leaderboard.Leaderboard/UpdateUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *leaderboardClient) GetUserList(ctx context.Context, in *GetUserListRequest, opts ...grpc.CallOption) (Leaderboard_GetUserListClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Leaderboard_ServiceDesc.Streams[0], "/leaderboard.Leaderboard/GetUserList", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &leaderboardGetUserListClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Leaderboard_GetUserListClient interface {
+	Recv() (*User, error)
+	grpc.ClientStream
+}
+
+type leaderboardGetUserListClient struct {
+	grpc.ClientStream
+}
+
+func (x *leaderboardGetUserListClient) Recv() (*User, error) {
+	m := new(User)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *leaderboardClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Leaderboard_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Leaderboard_ServiceDesc.Streams[1], "/leaderboard.Leaderboard/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &leaderboardSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Leaderboard_SubscribeClient interface {
+	Recv() (*RankUpdate, error)
+	grpc.ClientStream
+}
+
+type leaderboardSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *leaderboardSubscribeClient) Recv() (*RankUpdate, error) {
+	m := new(RankUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LeaderboardServer is the server API for Leaderboard service.
+// All implementations must embed UnimplementedLeaderboardServer
+// for forward compatibility
+type LeaderboardServer interface {
+	UserCount(context.Context, *UserCountRequest) (*UserCountResponse, error)
+	AddUser(context.Context, *User) (*AddUserResponse, error)
+	GetUser(context.Context, *GetUserRequest) (*UserRank, error)
+	DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error)
+	UpdateUser(context.Context, *User) (*UpdateUserResponse, error)
+	// start ~ stop 순위의 user를 순서대로 보냅니다. 한 번에 최대 100명까지 받을 수 있습니다.
+	GetUserList(*GetUserListRequest, Leaderboard_GetUserListServer) error
+	// 구독 직후 현재 상태를 보내고, 이후에는 바뀐 부분만 보냅니다.
+	Subscribe(*SubscribeRequest, Leaderboard_SubscribeServer) error
+	mustEmbedUnimplementedLeaderboardServer()
+}
+
+// UnimplementedLeaderboardServer must be embedded to have forward compatible implementations.
+type UnimplementedLeaderboardServer struct {
+}
+
+func (UnimplementedLeaderboardServer) UserCount(context.Context, *UserCountRequest) (*UserCountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UserCount not implemented")
+}
+func (UnimplementedLeaderboardServer) AddUser(context.Context, *User) (*AddUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddUser not implemented")
+}
+func (UnimplementedLeaderboardServer) GetUser(context.Context, *GetUserRequest) (*UserRank, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedLeaderboardServer) DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
+}
+func (UnimplementedLeaderboardServer) UpdateUser(context.Context, *User) (*UpdateUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUser not implemented")
+}
+func (UnimplementedLeaderboardServer) GetUserList(*GetUserListRequest, Leaderboard_GetUserListServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetUserList not implemented")
+}
+func (UnimplementedLeaderboardServer) Subscribe(*SubscribeRequest, Leaderboard_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedLeaderboardServer) mustEmbedUnimplementedLeaderboardServer() {}
+
+// UnsafeLeaderboardServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LeaderboardServer will
+// result in compilation errors.
+type UnsafeLeaderboardServer interface {
+	mustEmbedUnimplementedLeaderboardServer()
+}
+
+func RegisterLeaderboardServer(s grpc.ServiceRegistrar, srv LeaderboardServer) {
+	s.RegisterService(&Leaderboard_ServiceDesc, srv)
+}
+
+func _Leaderboard_UserCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserCountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderboardServer).UserCount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leaderboard.Leaderboard/UserCount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderboardServer).UserCount(ctx, req.(*UserCountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Leaderboard_AddUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderboardServer).AddUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leaderboard.Leaderboard/AddUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderboardServer).AddUser(ctx, req.(*User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Leaderboard_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderboardServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leaderboard.Leaderboard/GetUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderboardServer).GetUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Leaderboard_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderboardServer).DeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leaderboard.Leaderboard/DeleteUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderboardServer).DeleteUser(ctx, req.(*DeleteUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Leaderboard_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeaderboardServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leaderboard.Leaderboard/UpdateUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeaderboardServer).UpdateUser(ctx, req.(*User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Leaderboard_GetUserList_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetUserListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LeaderboardServer).GetUserList(m, &leaderboardGetUserListServer{stream})
+}
+
+type Leaderboard_GetUserListServer interface {
+	Send(*User) error
+	grpc.ServerStream
+}
+
+type leaderboardGetUserListServer struct {
+	grpc.ServerStream
+}
+
+func (x *leaderboardGetUserListServer) Send(m *User) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Leaderboard_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LeaderboardServer).Subscribe(m, &leaderboardSubscribeServer{stream})
+}
+
+type Leaderboard_SubscribeServer interface {
+	Send(*RankUpdate) error
+	grpc.ServerStream
+}
+
+type leaderboardSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *leaderboardSubscribeServer) Send(m *RankUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Leaderboard_ServiceDesc is the grpc.ServiceDesc for Leaderboard service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Leaderboard_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "leaderboard.Leaderboard",
+	HandlerType: (*LeaderboardServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "UserCount",
+			Handler:    _Leaderboard_UserCount_Handler,
+		},
+		{
+			MethodName: "AddUser",
+			Handler:    _Leaderboard_AddUser_Handler,
+		},
+		{
+			MethodName: "GetUser",
+			Handler:    _Leaderboard_GetUser_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _Leaderboard_DeleteUser_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _Leaderboard_UpdateUser_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetUserList",
+			Handler:       _Leaderboard_GetUserList_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Leaderboard_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "leaderboard.proto",
+}
@@ -0,0 +1,104 @@
+// ratelimit는 route별 요청 수를 API key, IP 또는 user 단위로 제한합니다.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 요청을 구분하는 기준
+type KeyBy string
+
+const (
+	// 인증한 API key. API key가 없으면 IP
+	KeyByAPIKey KeyBy = "api_key"
+	KeyByIP     KeyBy = "ip"
+	// player token의 user. player token이 없으면 API key, 그것도 없으면 IP
+	KeyByUser KeyBy = "user"
+)
+
+// window 동안 Limit번까지 허용합니다. 한꺼번에 Limit번을 쓰면 window/Limit마다 한 번씩 다시 허용
+type Rule struct {
+	Limit  int64
+	Window time.Duration
+	Key    KeyBy
+}
+
+// 설정 파일의 규칙. window는 time.ParseDuration 형식 (예: 1s, 1m)
+type fileRule struct {
+	Limit  int64  `json:"limit"`
+	Window string `json:"window"`
+	Key    KeyBy  `json:"key"`
+}
+
+type Store interface {
+	// key의 bucket에서 token 하나를 꺼냅니다. token이 없으면 false와 다음 token까지 기다릴 시간을 반환
+	TakeToken(ctx context.Context, key string, limit int64, window time.Duration, now time.Time) (bool, time.Duration, error)
+}
+
+// 여러 서버가 같은 Store(redis)를 쓰면 서버 수와 관계없이 제한됩니다.
+type Limiter struct {
+	// "POST /users"처럼 method와 echo route path
+	rules map[string]Rule
+	store Store
+	now   func() time.Time
+}
+
+func NewLimiter(rules map[string]Rule, store Store) (*Limiter, error) {
+	for route, rule := range rules {
+		if rule.Limit <= 0 || rule.Window <= 0 {
+			return nil, errors.New("invalid rate limit: " + route)
+		}
+		switch rule.Key {
+		case KeyByAPIKey, KeyByIP, KeyByUser:
+		default:
+			return nil, errors.New("invalid rate limit key: " + string(rule.Key))
+		}
+	}
+	return &Limiter{rules: rules, store: store, now: time.Now}, nil
+}
+
+// 파일 형식: {"POST /users": {"limit": 30, "window": "1m", "key": "user"}}. key를 생략하면 ip
+func LoadLimiter(path string, store Store) (*Limiter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile")
+	}
+	fileRules := map[string]fileRule{}
+	if err := json.Unmarshal(data, &fileRules); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	rules := map[string]Rule{}
+	for route, fileRule := range fileRules {
+		window, err := time.ParseDuration(fileRule.Window)
+		if err != nil {
+			return nil, errors.New("invalid rate limit window: " + route)
+		}
+		rule := Rule{Limit: fileRule.Limit, Window: window, Key: fileRule.Key}
+		if rule.Key == "" {
+			rule.Key = KeyByIP
+		}
+		rules[route] = rule
+	}
+	return NewLimiter(rules, store)
+}
+
+func (l *Limiter) Rule(route string) (Rule, bool) {
+	rule, ok := l.rules[route]
+	return rule, ok
+}
+
+// identity는 rule.Key로 구분한 요청자 (예: ip:127.0.0.1). 규칙이 없는 route는 항상 허용합니다.
+// 허용하지 않으면 다시 요청할 수 있을 때까지의 시간을 반환
+func (l *Limiter) Allow(ctx context.Context, route string, identity string) (bool, time.Duration, error) {
+	rule, ok := l.rules[route]
+	if !ok {
+		return true, 0, nil
+	}
+	allowed, retryAfter, err := l.store.TakeToken(ctx, route+":"+identity, rule.Limit, rule.Window, l.now())
+	return allowed, retryAfter, errors.Wrap(err, "l.store.TakeToken")
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/redisstorage"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const scriptSHA = "^[0-9a-f]{40}$"
+
+func TestLoadLimiter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimits.json")
+	data := `{"POST /users": {"limit": 30, "window": "1m", "key": "user"}, "GET /users/:start/to/:stop": {"limit": 10, "window": "1s"}}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	limiter, err := LoadLimiter(path, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]Rule{
+			"POST /users":                {Limit: 30, Window: time.Minute, Key: KeyByUser},
+			"GET /users/:start/to/:stop": {Limit: 10, Window: time.Second, Key: KeyByIP},
+		}, limiter.rules)
+	}
+
+	for _, invalid := range []string{
+		`{"POST /users": {"limit": 0, "window": "1m"}}`,
+		`{"POST /users": {"limit": 30, "window": "soon"}}`,
+		`{"POST /users": {"limit": 30, "window": "-1m"}}`,
+		`{"POST /users": {"limit": 30, "window": "1m", "key": "country"}}`,
+		`[]`,
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(invalid), 0o600))
+		_, err := LoadLimiter(path, nil)
+		assert.Error(t, err, invalid)
+	}
+	_, err = LoadLimiter("not-exists.json", nil)
+	assert.Error(t, err)
+}
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	limiter, err := NewLimiter(map[string]Rule{
+		"POST /users": {Limit: 2, Window: time.Minute, Key: KeyByIP},
+	}, redisstorage.NewMock("scores", db))
+	require.NoError(t, err)
+	now := time.Unix(1656676800, 0)
+	limiter.now = func() time.Time { return now }
+
+	// 규칙이 없는 route는 redis를 사용하지 않음
+	allowed, _, err := limiter.Allow(ctx, "GET /users", "ip:127.0.0.1")
+	if assert.NoError(t, err) {
+		assert.True(t, allowed)
+	}
+
+	const key = "scores:ratelimit:POST /users:ip:127.0.0.1"
+	mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, int64(2), int64(60000), now.UnixMilli()).SetVal([]interface{}{int64(1), int64(0)})
+	allowed, _, err = limiter.Allow(ctx, "POST /users", "ip:127.0.0.1")
+	if assert.NoError(t, err) {
+		assert.True(t, allowed)
+	}
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, int64(2), int64(60000), now.UnixMilli()).SetVal([]interface{}{int64(0), int64(12500)})
+	allowed, retryAfter, err := limiter.Allow(ctx, "POST /users", "ip:127.0.0.1")
+	if assert.NoError(t, err) {
+		assert.False(t, allowed)
+		assert.Equal(t, 12500*time.Millisecond, retryAfter)
+	}
+
+	mock.Regexp().ExpectEvalSha(scriptSHA, []string{key}, int64(2), int64(60000), now.UnixMilli()).SetErr(assert.AnError)
+	_, _, err = limiter.Allow(ctx, "POST /users", "ip:127.0.0.1")
+	assert.Error(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
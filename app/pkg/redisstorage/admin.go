@@ -0,0 +1,139 @@
+package redisstorage
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+const scanCount = 1000
+
+func (r *RedisStorage) archivePrefix() string {
+	return r.zsetKey + ":archive:"
+}
+
+func (r *RedisStorage) scanKeys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	iter := r.client.Scan(ctx, 0, prefix+"*", scanCount).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, errors.Wrap(iter.Err(), "iter.Err")
+}
+
+// Boards가 scan한 board key를 다시 scan하지 않고 쓰는 시간. /metrics를 scrape할 때마다 전체 keyspace를 scan하지 않기 위함
+const boardKeysTTL = time.Minute
+
+type boardKeyCache struct {
+	mu        sync.Mutex
+	keys      []string
+	scannedAt time.Time
+}
+
+func (c *boardKeyCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = nil
+}
+
+// 전체 board, group board와 scan한 segment board, archive board의 key. scan 결과는 boardKeysTTL 동안 다시 사용합니다.
+func (r *RedisStorage) boardKeys(ctx context.Context) ([]string, error) {
+	r.boardKeyCache.mu.Lock()
+	defer r.boardKeyCache.mu.Unlock()
+	if r.boardKeyCache.keys != nil && time.Since(r.boardKeyCache.scannedAt) < boardKeysTTL {
+		return r.boardKeyCache.keys, nil
+	}
+	keys := []string{r.zsetKey, r.groupScoresKey()}
+	for _, prefix := range []string{r.segmentPrefix(), r.archivePrefix()} {
+		scanned, err := r.scanKeys(ctx, prefix)
+		if err != nil {
+			return nil, errors.Wrap(err, "r.scanKeys")
+		}
+		keys = append(keys, scanned...)
+	}
+	r.boardKeyCache.keys = keys
+	r.boardKeyCache.scannedAt = time.Now()
+	return keys, nil
+}
+
+// 전체 board, group board, segment board, archive board의 이름(zsetKey: 이후)과 user 수를 반환합니다.
+// 전체 board의 이름은 빈 문자열입니다.
+// board 목록은 boardKeysTTL마다 scan하므로 새 segment board는 늦게 나타날 수 있고, 그 사이 비워진 segment board는 제외합니다.
+func (r *RedisStorage) Boards(ctx context.Context) ([]string, []int64, error) {
+	keys, err := r.boardKeys(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "r.boardKeys")
+	}
+
+	pipe := r.client.Pipeline()
+	countCmds := make([]*redis.IntCmd, 0, len(keys))
+	for _, key := range keys {
+		countCmds = append(countCmds, pipe.ZCard(ctx, key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, nil, errors.Wrap(err, "pipe.Exec")
+	}
+	names := make([]string, 0, len(keys))
+	counts := make([]int64, 0, len(keys))
+	for i, key := range keys {
+		// 빈 zset은 key가 지워지므로 scan한 board가 0명이면 더는 없는 board
+		if i >= 2 && countCmds[i].Val() == 0 {
+			continue
+		}
+		names = append(names, strings.TrimPrefix(strings.TrimPrefix(key, r.zsetKey), ":"))
+		counts = append(counts, countCmds[i].Val())
+	}
+	return names, counts, nil
+}
+
+// 전체 board와 group, segment 정보, 차단된 user의 점수(banned board)를 모두 지웁니다.
+// archive와 차단 목록은 남겨둡니다.
+// 여러 key를 나눠서 지우므로 reset 중에 들어온 기록은 일부 남을 수 있습니다.
+func (r *RedisStorage) Reset(ctx context.Context) error {
+	keys := []string{r.zsetKey, r.bannedKey(), r.userGroupsKey(), r.groupScoresKey()}
+	for _, prefix := range []string{r.groupMembersPrefix(), r.segmentPrefix(), r.zsetKey + ":user-segments:"} {
+		scanned, err := r.scanKeys(ctx, prefix)
+		if err != nil {
+			return errors.Wrap(err, "r.scanKeys")
+		}
+		keys = append(keys, scanned...)
+	}
+	for start := 0; start < len(keys); start += scanCount {
+		stop := start + scanCount
+		if stop > len(keys) {
+			stop = len(keys)
+		}
+		if err := r.client.Del(ctx, keys[start:stop]...).Err(); err != nil {
+			return errors.Wrap(err, "r.client.Del")
+		}
+	}
+	return errors.Wrap(r.client.Publish(ctx, r.ChangesChannel(), `{"deleted":true}`).Err(), "r.client.Publish")
+}
+
+// 이미 있으면 -1. 없으면 전체 board를 복사하고 복사한 user 수를 반환합니다.
+// 빈 board는 ZUNIONSTORE가 key를 만들지 않으므로 0
+var archiveScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return -1
+end
+return redis.call('ZUNIONSTORE', KEYS[2], 1, KEYS[1])
+`)
+
+// 전체 board를 archive board로 복사하고 복사한 user 수를 반환합니다. 차단된 user는 전체 board에 없으므로 포함되지 않음
+// 같은 이름의 archive가 있으면 false를 반환합니다. 빈 board는 archive를 만들지 않고 0명을 반환
+func (r *RedisStorage) Archive(ctx context.Context, name string) (bool, int64, error) {
+	keys := []string{r.zsetKey, r.archivePrefix() + name}
+	count, err := archiveScript.Run(ctx, r.client, keys).Int64()
+	if err != nil {
+		return false, 0, errors.Wrap(err, "archiveScript.Run")
+	}
+	if count > 0 {
+		// 새 archive board가 다음 Boards에 바로 나타나도록 다시 scan
+		r.boardKeyCache.invalidate()
+	}
+	return count >= 0, count, nil
+}
@@ -0,0 +1,54 @@
+package redisstorage
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// API key는 key의 hash를 field로, key 정보(JSON)를 value로 저장합니다.
+func (r *RedisStorage) apiKeysKey() string {
+	return r.zsetKey + ":api-keys"
+}
+
+// 없는 key면 false
+func (r *RedisStorage) APIKey(ctx context.Context, hash string) (bool, string, error) {
+	data, err := r.client.HGet(ctx, r.apiKeysKey(), hash).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", errors.Wrap(err, "r.client.HGet")
+	}
+	return true, data, nil
+}
+
+// hash를 key로 하는 모든 key 정보
+func (r *RedisStorage) APIKeys(ctx context.Context) (map[string]string, error) {
+	keys, err := r.client.HGetAll(ctx, r.apiKeysKey()).Result()
+	return keys, errors.Wrap(err, "r.client.HGetAll")
+}
+
+// 같은 id의 key가 있으면 0. id 확인과 저장 사이에 다른 요청이 끼어들지 않도록 한 번에 실행합니다.
+var addAPIKeyScript = redis.NewScript(`
+for _, data in ipairs(redis.call('HVALS', KEYS[1])) do
+	local ok, key = pcall(cjson.decode, data)
+	if ok and type(key) == 'table' and key['id'] == ARGV[1] then
+		return 0
+	end
+end
+redis.call('HSET', KEYS[1], ARGV[2], ARGV[3])
+return 1
+`)
+
+// 같은 id의 key가 이미 있으면 저장하지 않고 false
+func (r *RedisStorage) AddAPIKey(ctx context.Context, id string, hash string, data string) (bool, error) {
+	added, err := addAPIKeyScript.Run(ctx, r.client, []string{r.apiKeysKey()}, id, hash, data).Int()
+	return added == 1, errors.Wrap(err, "addAPIKeyScript.Run")
+}
+
+func (r *RedisStorage) DeleteAPIKey(ctx context.Context, hash string) (bool, error) {
+	deleted, err := r.client.HDel(ctx, r.apiKeysKey(), hash).Result()
+	return deleted == 1, errors.Wrap(err, "r.client.HDel")
+}
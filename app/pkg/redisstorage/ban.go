@@ -0,0 +1,134 @@
+package redisstorage
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// 차단 목록에 추가하고, 전체 board에 있으면 banned board로 옮긴 뒤 segment board에서 제거하고 group 점수를 다시 계산합니다.
+// KEYS는 addScript와 같음
+// ARGV[1]: 변경 알림 channel, ARGV[2]: segment board prefix, ARGV[3]: name, ARGV[4]: 차단 정보(JSON),
+// ARGV[5]: group member set prefix, ARGV[6]: group 점수 mode, ARGV[7]: top n
+var banScript = redis.NewScript(groupScoreLua + `
+local groupArgs = 5
+redis.call('HSET', KEYS[3], ARGV[3], ARGV[4])
+local score = redis.call('ZSCORE', KEYS[1], ARGV[3])
+if not score then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[3])
+redis.call('ZADD', KEYS[4], score, ARGV[3])
+local segments = redis.call('HGETALL', KEYS[2])
+for i = 1, #segments, 2 do
+	redis.call('ZREM', ARGV[2] .. segments[i] .. ':' .. segments[i + 1], ARGV[3])
+end
+redis.call('PUBLISH', ARGV[1], '{"name":' .. cjson.encode(ARGV[3]) .. ',"deleted":true}')
+` + refreshUserGroupLua + `
+return 1
+`)
+
+// 차단 목록에서 지우고, banned board에 있으면 전체 board와 segment board로 되돌린 뒤 group 점수를 다시 계산합니다.
+// ARGV[4...]: group member set prefix, mode, top n
+var unbanScript = redis.NewScript(groupScoreLua + `
+local groupArgs = 4
+if redis.call('HDEL', KEYS[3], ARGV[3]) == 0 then
+	return 0
+end
+local score = redis.call('ZSCORE', KEYS[4], ARGV[3])
+if score then
+	redis.call('ZREM', KEYS[4], ARGV[3])
+	redis.call('ZADD', KEYS[1], score, ARGV[3])
+	local segments = redis.call('HGETALL', KEYS[2])
+	for i = 1, #segments, 2 do
+		redis.call('ZADD', ARGV[2] .. segments[i] .. ':' .. segments[i + 1], score, ARGV[3])
+	end
+	redis.call('PUBLISH', ARGV[1], '{"name":' .. cjson.encode(ARGV[3]) .. ',"score":' .. score .. '}')
+end
+` + refreshUserGroupLua + `
+return 1
+`)
+
+// 차단 정보와 banned board의 점수, 전체 board에 있었다면 받았을 rank, user의 segment를 반환합니다.
+// 차단되지 않았으면 nil, 점수가 없으면 차단 정보만 반환
+// KEYS[1]: 전체 board, KEYS[2]: 차단 목록, KEYS[3]: banned board, KEYS[4]: user의 segment hash
+var bannedUserScript = redis.NewScript(`
+local ban = redis.call('HGET', KEYS[2], ARGV[1])
+if not ban then
+	return nil
+end
+local score = redis.call('ZSCORE', KEYS[3], ARGV[1])
+if not score then
+	return {ban}
+end
+return {ban, score, redis.call('ZCOUNT', KEYS[1], '-inf', '(' .. score), redis.call('HGETALL', KEYS[4])}
+`)
+
+func (r *RedisStorage) bansKey() string {
+	return r.zsetKey + ":bans"
+}
+
+// 차단된 user의 점수를 옮겨두는 board
+func (r *RedisStorage) bannedKey() string {
+	return r.zsetKey + ":banned"
+}
+
+// 전체 board에 있던 user를 옮겼으면 true
+func (r *RedisStorage) Ban(ctx context.Context, name string, data string, groupMode string, groupTopN int) (bool, error) {
+	args := append([]interface{}{r.ChangesChannel(), r.segmentPrefix(), name, data}, r.groupArgs(groupMode, groupTopN)...)
+	moved, err := banScript.Run(ctx, r.client, r.userKeys(name), args...).Int()
+	return moved == 1, errors.Wrap(err, "banScript.Run")
+}
+
+// 차단 목록에 없었으면 false
+func (r *RedisStorage) Unban(ctx context.Context, name string, groupMode string, groupTopN int) (bool, error) {
+	args := append([]interface{}{r.ChangesChannel(), r.segmentPrefix(), name}, r.groupArgs(groupMode, groupTopN)...)
+	unbanned, err := unbanScript.Run(ctx, r.client, r.userKeys(name), args...).Int()
+	return unbanned == 1, errors.Wrap(err, "unbanScript.Run")
+}
+
+// name을 key로 하는 모든 차단 정보
+func (r *RedisStorage) Bans(ctx context.Context) (map[string]string, error) {
+	bans, err := r.client.HGetAll(ctx, r.bansKey()).Result()
+	return bans, errors.Wrap(err, "r.client.HGetAll")
+}
+
+// 차단된 user의 banned board 기록
+type BannedUser struct {
+	// 차단 정보(JSON)
+	Data string
+	// banned board에 점수가 있으면 true
+	Exists bool
+	Score  float64
+	// 전체 board에 있었다면 받았을 rank
+	Rank     int64
+	Segments map[string]string
+}
+
+// 차단되지 않은 user면 nil
+func (r *RedisStorage) BannedUser(ctx context.Context, name string) (*BannedUser, error) {
+	result, err := bannedUserScript.Run(ctx, r.client, []string{r.zsetKey, r.bansKey(), r.bannedKey(), r.userSegmentsKey(name)}, name).Slice()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "bannedUserScript.Run")
+	}
+	data, _ := result[0].(string)
+	user := &BannedUser{Data: data}
+	if len(result) < 4 {
+		return user, nil
+	}
+	scoreValue, _ := result[1].(string)
+	if user.Score, err = strconv.ParseFloat(scoreValue, 64); err != nil {
+		return nil, errors.Wrap(err, "strconv.ParseFloat")
+	}
+	user.Exists = true
+	user.Rank, _ = result[2].(int64)
+	if user.Segments, err = parseSegments(result[3]); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
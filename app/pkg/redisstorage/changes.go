@@ -0,0 +1,24 @@
+package redisstorage
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// Add, Update, Delete가 성공하면 이 channel로 변경된 user를 publish 합니다.
+// 여러 서버 인스턴스가 같은 channel을 구독하므로 어느 인스턴스에서 기록하든 모두 알림을 받습니다.
+func (r *RedisStorage) ChangesChannel() string {
+	return r.zsetKey + ":changes"
+}
+
+// 변경 알림 channel을 구독합니다. 사용이 끝나면 Close를 호출해야 합니다.
+func (r *RedisStorage) SubscribeChanges(ctx context.Context) (*redis.PubSub, error) {
+	pubsub := r.client.Subscribe(ctx, r.ChangesChannel())
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, errors.Wrap(err, "pubsub.Receive")
+	}
+	return pubsub, nil
+}
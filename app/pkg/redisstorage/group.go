@@ -0,0 +1,186 @@
+package redisstorage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// 그룹 점수 계산: mode는 sum, avg, top(상위 n명의 합)
+const groupScoreLua = `
+local function groupScore(scoresKey, membersKey, mode, n)
+	local scores = {}
+	for _, member in ipairs(redis.call('SMEMBERS', membersKey)) do
+		local score = redis.call('ZSCORE', scoresKey, member)
+		if score then
+			table.insert(scores, tonumber(score))
+		end
+	end
+	if mode == 'top' then
+		table.sort(scores, function(a, b) return a > b end)
+		while #scores > n do
+			table.remove(scores)
+		end
+	end
+	local sum = 0
+	for _, score in ipairs(scores) do
+		sum = sum + score
+	end
+	if mode == 'avg' and #scores > 0 then
+		return sum / #scores
+	end
+	return sum
+end
+
+local function refreshGroup(scoresKey, groupScoresKey, prefix, group, mode, n)
+	local membersKey = prefix .. group
+	if redis.call('SCARD', membersKey) == 0 then
+		redis.call('ZREM', groupScoresKey, group)
+		return
+	end
+	local score = groupScore(scoresKey, membersKey, mode, n)
+	redis.call('ZADD', groupScoresKey, string.format('%.17g', score), group)
+end
+`
+
+// KEYS[1]: 전체 board, KEYS[2]: user의 group hash, KEYS[3]: group 점수 board, KEYS[4]: group member set
+// ARGV[1]: group member set prefix, ARGV[2]: name, ARGV[3]: group, ARGV[4]: group 점수 mode, ARGV[5]: top n
+// 이전 group의 member set은 user의 group hash에서 읽은 이름으로 만듦
+var joinGroupScript = redis.NewScript(groupScoreLua + `
+local old = redis.call('HGET', KEYS[2], ARGV[2])
+if old == ARGV[3] then
+	return 0
+end
+if old then
+	redis.call('SREM', ARGV[1] .. old, ARGV[2])
+	refreshGroup(KEYS[1], KEYS[3], ARGV[1], old, ARGV[4], tonumber(ARGV[5]))
+end
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[3])
+redis.call('SADD', KEYS[4], ARGV[2])
+refreshGroup(KEYS[1], KEYS[3], ARGV[1], ARGV[3], ARGV[4], tonumber(ARGV[5]))
+return 1
+`)
+
+// KEYS, ARGV는 joinGroupScript와 같음
+var leaveGroupScript = redis.NewScript(groupScoreLua + `
+if redis.call('HGET', KEYS[2], ARGV[2]) ~= ARGV[3] then
+	return 0
+end
+redis.call('HDEL', KEYS[2], ARGV[2])
+redis.call('SREM', KEYS[4], ARGV[2])
+refreshGroup(KEYS[1], KEYS[3], ARGV[1], ARGV[3], ARGV[4], tonumber(ARGV[5]))
+return 1
+`)
+
+func (r *RedisStorage) userGroupsKey() string {
+	return r.zsetKey + ":user-groups"
+}
+
+func (r *RedisStorage) groupScoresKey() string {
+	return r.zsetKey + ":groups"
+}
+
+func (r *RedisStorage) groupKeys() []string {
+	return []string{r.zsetKey, r.userGroupsKey(), r.groupScoresKey()}
+}
+
+func (r *RedisStorage) groupMembersKey(group string) string {
+	return r.groupMembersPrefix() + group
+}
+
+func (r *RedisStorage) groupMembersPrefix() string {
+	return r.zsetKey + ":group:"
+}
+
+func (r *RedisStorage) JoinGroup(ctx context.Context, group string, name string, mode string, topN int) (bool, error) {
+	keys := append(r.groupKeys(), r.groupMembersKey(group))
+	joined, err := joinGroupScript.Run(ctx, r.client, keys, r.groupMembersPrefix(), name, group, mode, topN).Int()
+	return joined == 1, errors.Wrap(err, "joinGroupScript.Run")
+}
+
+func (r *RedisStorage) LeaveGroup(ctx context.Context, group string, name string, mode string, topN int) (bool, error) {
+	keys := append(r.groupKeys(), r.groupMembersKey(group))
+	left, err := leaveGroupScript.Run(ctx, r.client, keys, r.groupMembersPrefix(), name, group, mode, topN).Int()
+	return left == 1, errors.Wrap(err, "leaveGroupScript.Run")
+}
+
+func (r *RedisStorage) GetGroup(ctx context.Context, group string) (bool, int64, float64, int64, error) {
+	pipe := r.client.TxPipeline()
+	scoreCmd := pipe.ZScore(ctx, r.groupScoresKey(), group)
+	rankCmd := pipe.ZRank(ctx, r.groupScoresKey(), group)
+	countCmd := pipe.SCard(ctx, r.groupMembersKey(group))
+	if _, err := pipe.Exec(ctx); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, -1, 0.0, 0, nil
+		}
+		return false, -1, 0.0, 0, errors.Wrap(err, "pipe.Exec")
+	}
+	score, err := scoreCmd.Result()
+	if err != nil {
+		return false, -1, 0.0, 0, errors.Wrap(err, "scoreCmd.Result")
+	}
+	rank, err := rankCmd.Result()
+	if err != nil {
+		return false, -1, 0.0, 0, errors.Wrap(err, "rankCmd.Result")
+	}
+	count, err := countCmd.Result()
+	if err != nil {
+		return false, -1, 0.0, 0, errors.Wrap(err, "countCmd.Result")
+	}
+	return true, rank, score, count, nil
+}
+
+func (r *RedisStorage) GroupRange(ctx context.Context, start int64, stop int64) ([]redis.Z, error) {
+	groupList, err := r.client.ZRangeWithScores(ctx, r.groupScoresKey(), start, stop).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "r.client.ZRange")
+	}
+	return groupList, nil
+}
+
+// 점수가 없는 멤버는 0점으로 반환하며, 점수 오름차순(zset 순서)으로 정렬합니다.
+func (r *RedisStorage) GroupMembers(ctx context.Context, group string) ([]redis.Z, error) {
+	members, err := r.client.SMembers(ctx, r.groupMembersKey(group)).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "r.client.SMembers")
+	}
+	if len(members) == 0 {
+		return []redis.Z{}, nil
+	}
+	pipe := r.client.Pipeline()
+	scoreCmds := make([]*redis.FloatCmd, 0, len(members))
+	for _, member := range members {
+		scoreCmds = append(scoreCmds, pipe.ZScore(ctx, r.zsetKey, member))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, errors.Wrap(err, "pipe.Exec")
+	}
+	result := make([]redis.Z, 0, len(members))
+	for i, member := range members {
+		score, err := scoreCmds[i].Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, errors.Wrap(err, "scoreCmd.Result")
+		}
+		result = append(result, redis.Z{Score: score, Member: member})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score < result[j].Score
+		}
+		return result[i].Member.(string) < result[j].Member.(string)
+	})
+	return result, nil
+}
+
+func (r *RedisStorage) UserGroup(ctx context.Context, name string) (bool, string, error) {
+	group, err := r.client.HGet(ctx, r.userGroupsKey(), name).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, "", nil
+		}
+		return false, "", errors.Wrap(err, "r.client.HGet")
+	}
+	return true, group, nil
+}
@@ -0,0 +1,33 @@
+package redisstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func (r *RedisStorage) idempotencyKey(key string) string {
+	return r.zsetKey + ":idempotency:" + key
+}
+
+// 처음 사용한 key면 data를 ttl 동안 저장하고 true, 이미 있으면 false와 저장된 data를 반환합니다.
+func (r *RedisStorage) ReserveIdempotencyKey(ctx context.Context, key string, data string, ttl time.Duration) (bool, string, error) {
+	pipe := r.client.TxPipeline()
+	setCmd := pipe.SetNX(ctx, r.idempotencyKey(key), data, ttl)
+	getCmd := pipe.Get(ctx, r.idempotencyKey(key))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, "", errors.Wrap(err, "pipe.Exec")
+	}
+	return setCmd.Val(), getCmd.Val(), nil
+}
+
+func (r *RedisStorage) SaveIdempotencyKey(ctx context.Context, key string, data string, ttl time.Duration) error {
+	err := r.client.Set(ctx, r.idempotencyKey(key), data, ttl).Err()
+	return errors.Wrap(err, "r.client.Set")
+}
+
+func (r *RedisStorage) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	err := r.client.Del(ctx, r.idempotencyKey(key)).Err()
+	return errors.Wrap(err, "r.client.Del")
+}
@@ -0,0 +1,54 @@
+package redisstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/metrics"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+type startTimeKey struct{}
+
+// 명령별 처리 시간과 error 수를 기록합니다. pipeline(MULTI 포함)은 "pipeline"으로 한 번에 기록
+type metricsHook struct{}
+
+func (metricsHook) BeforeProcess(ctx context.Context, _ redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startTimeKey{}, time.Now()), nil
+}
+
+func (metricsHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	metrics.ObserveRedisCommand(cmd.Name(), elapsed(ctx), failed(cmd))
+	return nil
+}
+
+func (metricsHook) BeforeProcessPipeline(ctx context.Context, _ []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startTimeKey{}, time.Now()), nil
+}
+
+func (metricsHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	pipelineFailed := false
+	for _, cmd := range cmds {
+		if failed(cmd) {
+			pipelineFailed = true
+			break
+		}
+	}
+	metrics.ObserveRedisCommand("pipeline", elapsed(ctx), pipelineFailed)
+	return nil
+}
+
+func elapsed(ctx context.Context) time.Duration {
+	start, ok := ctx.Value(startTimeKey{}).(time.Time)
+	if !ok {
+		return 0
+	}
+	return time.Since(start)
+}
+
+// 결과가 없는 경우(redis.Nil)는 error가 아님
+func failed(cmd redis.Cmder) bool {
+	err := cmd.Err()
+	return err != nil && !errors.Is(err, redis.Nil)
+}
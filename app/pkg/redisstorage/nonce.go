@@ -0,0 +1,18 @@
+package redisstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func (r *RedisStorage) nonceKey(game string, nonce string) string {
+	return r.zsetKey + ":nonce:" + game + ":" + nonce
+}
+
+// 처음 사용한 nonce면 ttl 동안 저장하고 true, 이미 사용한 nonce면 false
+func (r *RedisStorage) UseNonce(ctx context.Context, game string, nonce string, ttl time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(ctx, r.nonceKey(game, nonce), 1, ttl).Result()
+	return ok, errors.Wrap(err, "r.client.SetNX")
+}
@@ -0,0 +1,48 @@
+package redisstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// token bucket. window 동안 limit개의 token이 일정하게 다시 채워지고, 최대 limit개까지 모아둘 수 있습니다.
+// 여러 서버가 같은 bucket을 쓰도록 시각은 요청한 서버가 ms 단위로 넘깁니다.
+var takeTokenScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or limit
+local ts = tonumber(bucket[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - ts) * limit / window)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * window / limit)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, retry}
+`)
+
+func (r *RedisStorage) rateLimitKey(key string) string {
+	return r.zsetKey + ":ratelimit:" + key
+}
+
+// key의 bucket에서 token 하나를 꺼냅니다. token이 없으면 false와 다음 token까지 기다릴 시간을 반환
+func (r *RedisStorage) TakeToken(ctx context.Context, key string, limit int64, window time.Duration, now time.Time) (bool, time.Duration, error) {
+	result, err := takeTokenScript.Run(ctx, r.client, []string{r.rateLimitKey(key)}, limit, window.Milliseconds(), now.UnixMilli()).Int64Slice()
+	if err != nil {
+		return false, 0, errors.Wrap(err, "takeTokenScript.Run")
+	}
+	if len(result) != 2 {
+		return false, 0, errors.New("invalid rate limit result")
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
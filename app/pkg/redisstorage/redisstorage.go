@@ -3,14 +3,27 @@ package redisstorage
 import (
 	"context"
 	"os"
+	"strconv"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/pkg/errors"
 )
 
+// shadow ban이 아닌 차단된 user에 기록하면 반환합니다.
+var ErrBanned = errors.New("banned user")
+
+// CompareAndUpdate, CompareAndDelete에서 기존 score나 segment가 다르면 반환합니다.
+var ErrScoreChanged = errors.New("score changed")
+
+// script는 미리 알 수 있는 key를 KEYS로 받지만, segment board나 group member set처럼 저장된 값으로 이름이 정해지는 key는 script 안에서 만듭니다.
+// 그래서 한 script가 쓰는 key가 여러 slot에 흩어질 수 있는 Redis Cluster가 아니라 단일 node에서만 사용
 type RedisStorage struct {
 	zsetKey string
-	client  *redis.Client
+	// segment board면 전체 board key. user의 segment hash는 전체 board key로 만듦
+	usersKey string
+	client   *redis.Client
+	// Boards가 scan한 board key
+	boardKeyCache boardKeyCache
 }
 
 func New() (*RedisStorage, error) {
@@ -23,6 +36,8 @@ func New() (*RedisStorage, error) {
 	if db == nil {
 		return nil, errors.New("redis client is nil")
 	}
+	db.AddHook(metricsHook{})
+	db.AddHook(tracingHook{})
 
 	return &RedisStorage{
 		zsetKey: zsetKey,
@@ -37,15 +52,24 @@ func NewMock(zsetKey string, db *redis.Client) *RedisStorage {
 	}
 }
 
-func (r *RedisStorage) Add(ctx context.Context, name string, score float64) error {
-	addCount, err := r.client.ZAddNX(ctx, r.zsetKey, &redis.Z{Score: score, Member: name}).Result()
+// 추가한 score와 rank, 저장된 segment를 반환합니다.
+func (r *RedisStorage) Add(ctx context.Context, name string, score float64, segments map[string]string, groupMode string, groupTopN int) (int64, float64, map[string]string, error) {
+	keys := r.userKeys(name)
+	result, err := addScript.Run(ctx, r.client, keys, r.writeArgs(name, score, segments, groupMode, groupTopN)...).Slice()
+	if err != nil {
+		return -1, 0.0, nil, errors.Wrap(err, "addScript.Run")
+	}
+	status, rank, written, stored, err := writeResult(result)
 	if err != nil {
-		return errors.Wrap(err, "r.client.ZAddNX")
+		return -1, 0.0, nil, err
 	}
-	if addCount != 1 {
-		return errors.New("already exists user:" + name)
+	switch status {
+	case -1:
+		return -1, 0.0, nil, ErrBanned
+	case 0:
+		return -1, 0.0, nil, errors.New("already exists user:" + name)
 	}
-	return nil
+	return rank, written, stored, nil
 }
 
 func (r *RedisStorage) Count(ctx context.Context) (int64, error) {
@@ -53,40 +77,82 @@ func (r *RedisStorage) Count(ctx context.Context) (int64, error) {
 	return count, errors.Wrap(err, "ZCount")
 }
 
-func (r *RedisStorage) Get(ctx context.Context, name string) (bool, int64, float64, error) {
-	pipe := r.client.TxPipeline()
-	scoreCmd := pipe.ZScore(ctx, r.zsetKey, name)
-	rankCmd := pipe.ZRank(ctx, r.zsetKey, name)
-	if _, err := pipe.Exec(ctx); err != nil {
-		if errors.Is(err, redis.Nil) {
-			return false, -1, 0.0, nil
-		}
-		return false, -1, 0.0, errors.Wrap(err, "pipe.Exec")
+// score, rank와 user의 segment를 반환합니다.
+func (r *RedisStorage) Get(ctx context.Context, name string) (bool, int64, float64, map[string]string, error) {
+	result, err := getScript.Run(ctx, r.client, []string{r.zsetKey, r.userSegmentsKey(name)}, name).Slice()
+	if errors.Is(err, redis.Nil) {
+		return false, -1, 0.0, nil, nil
+	} else if err != nil {
+		return false, -1, 0.0, nil, errors.Wrap(err, "getScript.Run")
+	}
+	if len(result) != 3 {
+		return false, -1, 0.0, nil, errors.New("invalid get result")
 	}
-	score, err := scoreCmd.Result()
+	score, err := parseScore(result[0])
 	if err != nil {
-		return false, -1, 0.0, errors.Wrap(err, "scoreCmd.Result")
+		return false, -1, 0.0, nil, err
 	}
-
-	rank, err := rankCmd.Result()
+	rank, ok := result[1].(int64)
+	if !ok {
+		return false, -1, 0.0, nil, errors.New("invalid rank")
+	}
+	segments, err := parseSegments(result[2])
 	if err != nil {
-		return false, -1, 0.0, errors.Wrap(err, "rankCmd.Result")
+		return false, -1, 0.0, nil, err
 	}
+	return true, rank, score, segments, nil
+}
 
-	return true, rank, score, nil
+func (r *RedisStorage) Delete(ctx context.Context, name string, groupMode string, groupTopN int) (bool, error) {
+	keys := r.userKeys(name)
+	args := append([]interface{}{r.ChangesChannel(), r.segmentPrefix(), name}, r.groupArgs(groupMode, groupTopN)...)
+	remCount, err := deleteScript.Run(ctx, r.client, keys, args...).Int()
+	return remCount == 1, errors.Wrap(err, "deleteScript.Run")
 }
 
-func (r *RedisStorage) Delete(ctx context.Context, name string) (bool, error) {
-	remCount, err := r.client.ZRem(ctx, r.zsetKey, name).Result()
-	return remCount == 1, errors.Wrap(err, "ZRem")
+// 수정한 score와 rank, 저장된 segment를 반환합니다. 없으면 false
+func (r *RedisStorage) Update(ctx context.Context, name string, score float64, segments map[string]string, groupMode string, groupTopN int) (bool, int64, float64, map[string]string, error) {
+	keys := r.userKeys(name)
+	result, err := updateScript.Run(ctx, r.client, keys, r.writeArgs(name, score, segments, groupMode, groupTopN)...).Slice()
+	if err != nil {
+		return false, -1, 0.0, nil, errors.Wrap(err, "updateScript.Run")
+	}
+	return updateResult(result)
 }
 
-func (r *RedisStorage) Update(ctx context.Context, name string, score float64) (bool, error) {
-	updateCount, err := r.client.ZAddXX(ctx, r.zsetKey, &redis.Z{Score: score, Member: name}).Result()
+// 기존 score와 segment가 expected, expectedSegments일 때만 수정합니다. 다르면 ErrScoreChanged
+func (r *RedisStorage) CompareAndUpdate(ctx context.Context, name string, expected float64, expectedSegments map[string]string, score float64, segments map[string]string, groupMode string, groupTopN int) (bool, int64, float64, map[string]string, error) {
+	expectedData, err := segmentsJSON(expectedSegments)
 	if err != nil {
-		return false, errors.Wrap(err, "r.client.ZAddXX")
+		return false, -1, 0.0, nil, err
 	}
-	return updateCount == 1, nil
+	keys := r.userKeys(name)
+	writeArgs := r.writeArgs(name, score, segments, groupMode, groupTopN)
+	args := make([]interface{}, 0, len(writeArgs)+2)
+	args = append(append(append(args, writeArgs[:7]...), expected, expectedData), writeArgs[7:]...)
+	result, err := compareAndUpdateScript.Run(ctx, r.client, keys, args...).Slice()
+	if err != nil {
+		return false, -1, 0.0, nil, errors.Wrap(err, "compareAndUpdateScript.Run")
+	}
+	return updateResult(result)
+}
+
+// 기존 score와 segment가 expected, expectedSegments일 때만 삭제합니다. 다르면 ErrScoreChanged
+func (r *RedisStorage) CompareAndDelete(ctx context.Context, name string, expected float64, expectedSegments map[string]string, groupMode string, groupTopN int) (bool, error) {
+	expectedData, err := segmentsJSON(expectedSegments)
+	if err != nil {
+		return false, err
+	}
+	keys := r.userKeys(name)
+	args := append([]interface{}{r.ChangesChannel(), r.segmentPrefix(), name}, r.groupArgs(groupMode, groupTopN)...)
+	remCount, err := compareAndDeleteScript.Run(ctx, r.client, keys, append(args, expected, expectedData)...).Int()
+	if err != nil {
+		return false, errors.Wrap(err, "compareAndDeleteScript.Run")
+	}
+	if remCount == -2 {
+		return false, ErrScoreChanged
+	}
+	return remCount == 1, nil
 }
 
 func (r *RedisStorage) Range(ctx context.Context, start int64, stop int64) ([]redis.Z, error) {
@@ -97,3 +163,37 @@ func (r *RedisStorage) Range(ctx context.Context, start int64, stop int64) ([]re
 
 	return userList, nil
 }
+
+// zset 순서(score, 같은 score는 member 사전순)에서 after 다음 항목들을 count개 반환합니다.
+// after가 nil이면 처음부터 반환합니다.
+func (r *RedisStorage) RangeAfter(ctx context.Context, after *redis.Z, count int64) ([]redis.Z, error) {
+	minScore := "-inf"
+	if after != nil {
+		minScore = strconv.FormatFloat(after.Score, 'f', -1, 64)
+	}
+	result := make([]redis.Z, 0, count)
+	for offset := int64(0); int64(len(result)) < count; offset += count {
+		userList, err := r.client.ZRangeByScoreWithScores(ctx, r.zsetKey, &redis.ZRangeBy{
+			Min:    minScore,
+			Max:    "+inf",
+			Offset: offset,
+			Count:  count,
+		}).Result()
+		if err != nil {
+			return nil, errors.Wrap(err, "r.client.ZRangeByScore")
+		}
+		for _, user := range userList {
+			// 같은 score 중 after 이전 member는 건너뜀
+			if after != nil && user.Score == after.Score && user.Member.(string) <= after.Member.(string) {
+				continue
+			}
+			if int64(len(result)) < count {
+				result = append(result, user)
+			}
+		}
+		if int64(len(userList)) < count {
+			break
+		}
+	}
+	return result, nil
+}
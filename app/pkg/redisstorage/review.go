@@ -0,0 +1,72 @@
+package redisstorage
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// 검토 대기 중인 점수 기록. id를 field로, 기록 정보(JSON)를 value로 저장합니다.
+func (r *RedisStorage) reviewsKey() string {
+	return r.zsetKey + ":reviews"
+}
+
+func (r *RedisStorage) reviewIDKey() string {
+	return r.zsetKey + ":review-id"
+}
+
+// window 단위로 user의 점수 기록 횟수를 세고, 이번 기록을 포함한 횟수를 반환합니다.
+func (r *RedisStorage) CountSubmission(ctx context.Context, name string, now time.Time, window time.Duration) (int64, error) {
+	bucket := now.UnixNano() / int64(window)
+	key := r.zsetKey + ":submissions:" + name + ":" + strconv.FormatInt(bucket, 10)
+	pipe := r.client.TxPipeline()
+	countCmd := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, errors.Wrap(err, "pipe.Exec")
+	}
+	return countCmd.Val(), nil
+}
+
+// 새 id(증가하는 정수)로 저장하고 id를 반환합니다.
+func (r *RedisStorage) AddReview(ctx context.Context, data string) (string, error) {
+	id, err := r.client.Incr(ctx, r.reviewIDKey()).Result()
+	if err != nil {
+		return "", errors.Wrap(err, "r.client.Incr")
+	}
+	reviewID := strconv.FormatInt(id, 10)
+	if err := r.client.HSet(ctx, r.reviewsKey(), reviewID, data).Err(); err != nil {
+		return "", errors.Wrap(err, "r.client.HSet")
+	}
+	return reviewID, nil
+}
+
+// id를 key로 하는 모든 검토 대기 기록
+func (r *RedisStorage) Reviews(ctx context.Context) (map[string]string, error) {
+	reviews, err := r.client.HGetAll(ctx, r.reviewsKey()).Result()
+	return reviews, errors.Wrap(err, "r.client.HGetAll")
+}
+
+// 없는 id면 false
+func (r *RedisStorage) Review(ctx context.Context, id string) (bool, string, error) {
+	data, err := r.client.HGet(ctx, r.reviewsKey(), id).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", errors.Wrap(err, "r.client.HGet")
+	}
+	return true, data, nil
+}
+
+func (r *RedisStorage) SetReview(ctx context.Context, id string, data string) error {
+	return errors.Wrap(r.client.HSet(ctx, r.reviewsKey(), id, data).Err(), "r.client.HSet")
+}
+
+func (r *RedisStorage) DeleteReview(ctx context.Context, id string) (bool, error) {
+	deleted, err := r.client.HDel(ctx, r.reviewsKey(), id).Result()
+	return deleted == 1, errors.Wrap(err, "r.client.HDel")
+}
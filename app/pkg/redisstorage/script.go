@@ -0,0 +1,107 @@
+package redisstorage
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// score, rank와 user의 segment를 MULTI 없이 한 번에 읽습니다. 없으면 nil
+// KEYS[1]: board, KEYS[2]: user의 segment hash
+var getScript = redis.NewScript(`
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score then
+	return false
+end
+return {score, redis.call('ZRANK', KEYS[1], ARGV[1]), redis.call('HGETALL', KEYS[2])}
+`)
+
+// 시작할 때 미리 load하는 script. 모든 script는 EVALSHA로 실행하고, redis가 다시 시작해서 NOSCRIPT면 EVAL로 다시 실행합니다.
+var scripts = []*redis.Script{
+	getScript, addScript, updateScript, compareAndUpdateScript, setScript, deleteScript, compareAndDeleteScript,
+	joinGroupScript, leaveGroupScript,
+	snapshotScript, restoreScript, archiveScript,
+	banScript, unbanScript, bannedUserScript,
+	takeTokenScript, addAPIKeyScript, recordStatesScript,
+}
+
+func (r *RedisStorage) LoadScripts(ctx context.Context) error {
+	pipe := r.client.Pipeline()
+	for _, script := range scripts {
+		script.Load(ctx, pipe)
+	}
+	_, err := pipe.Exec(ctx)
+	return errors.Wrap(err, "pipe.Exec")
+}
+
+// addScript, updateScript의 결과 {status, score, rank, segments}에서 status, rank, score, segments를 반환합니다. 실패한 결과는 {status}
+func writeResult(result []interface{}) (int64, int64, float64, map[string]string, error) {
+	if len(result) == 0 {
+		return 0, -1, 0.0, nil, errors.New("invalid write result")
+	}
+	status, ok := result[0].(int64)
+	if !ok {
+		return 0, -1, 0.0, nil, errors.New("invalid write status")
+	}
+	if status != 1 {
+		return status, -1, 0.0, nil, nil
+	}
+	if len(result) != 4 {
+		return 0, -1, 0.0, nil, errors.New("invalid write result")
+	}
+	score, err := parseScore(result[1])
+	if err != nil {
+		return 0, -1, 0.0, nil, err
+	}
+	rank, ok := result[2].(int64)
+	if !ok {
+		return 0, -1, 0.0, nil, errors.New("invalid rank")
+	}
+	segments, err := parseSegments(result[3])
+	if err != nil {
+		return 0, -1, 0.0, nil, err
+	}
+	return status, rank, score, segments, nil
+}
+
+func updateResult(result []interface{}) (bool, int64, float64, map[string]string, error) {
+	status, rank, score, segments, err := writeResult(result)
+	if err != nil {
+		return false, -1, 0.0, nil, err
+	}
+	switch status {
+	case -1:
+		return false, -1, 0.0, nil, ErrBanned
+	case -2:
+		return false, -1, 0.0, nil, ErrScoreChanged
+	}
+	return status == 1, rank, score, segments, nil
+}
+
+// HGETALL 결과를 map으로 바꿉니다. segment가 없으면 nil
+func parseSegments(value interface{}) (map[string]string, error) {
+	values, ok := value.([]interface{})
+	if !ok || len(values)%2 != 0 {
+		return nil, errors.New("invalid segments")
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	segments := make(map[string]string, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		attr, _ := values[i].(string)
+		segments[attr], _ = values[i+1].(string)
+	}
+	return segments, nil
+}
+
+func parseScore(value interface{}) (float64, error) {
+	text, ok := value.(string)
+	if !ok {
+		return 0.0, errors.New("invalid score")
+	}
+	score, err := strconv.ParseFloat(text, 64)
+	return score, errors.Wrap(err, "strconv.ParseFloat")
+}
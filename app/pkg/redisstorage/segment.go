@@ -0,0 +1,249 @@
+package redisstorage
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// 차단된 user(ban)는 전체 board 대신 banned board에 기록하고, segment board와 변경 알림에서 제외합니다.
+const banLua = `
+local ban = redis.call('HGET', KEYS[3], ARGV[3])
+local board = KEYS[1]
+if ban then
+	board = KEYS[4]
+end
+`
+
+// shadow ban이 아닌 차단된 user는 기록할 수 없음
+const rejectBannedLua = `
+if ban and not cjson.decode(ban).shadow then
+	return {-1}
+end
+`
+
+// 기록한 score와 전체 board의 rank, 저장된 segment를 함께 반환해서, 기록 뒤의 다른 기록이 섞이지 않게 합니다.
+// 차단된 user는 전체 board에 있었다면 받았을 rank
+const writeResultLua = `
+local written = redis.call('ZSCORE', board, ARGV[3])
+local rank
+if ban then
+	rank = redis.call('ZCOUNT', KEYS[1], '-inf', '(' .. written)
+else
+	rank = redis.call('ZRANK', KEYS[1], ARGV[3])
+end
+return {1, written, rank, redis.call('HGETALL', KEYS[2])}
+`
+
+// user가 속한 group의 점수를 같은 script에서 다시 계산해서, group 점수가 user 점수와 함께 바뀌게 합니다.
+// group member set prefix, mode, n은 ARGV[groupArgs]부터
+const refreshUserGroupLua = `
+local group = redis.call('HGET', KEYS[5], ARGV[3])
+if group then
+	refreshGroup(KEYS[1], KEYS[6], ARGV[groupArgs], group, ARGV[groupArgs + 1], tonumber(ARGV[groupArgs + 2]))
+end
+`
+
+// 전체 board와 segment board들에 한 번에 기록하고 변경 알림을 publish 합니다.
+// KEYS[1]: 전체 board, KEYS[2]: user의 segment hash, KEYS[3]: 차단 목록, KEYS[4]: banned board,
+// KEYS[5]: user의 group hash, KEYS[6]: group 점수 board
+// ARGV[1]: 변경 알림 channel, ARGV[2]: segment board prefix, ARGV[3]: name, ARGV[4]: score,
+// ARGV[5]: group member set prefix, ARGV[6]: group 점수 mode, ARGV[7]: top n, ARGV[8...]: attr, value 쌍
+// {1, score, rank, segments}. 이미 있으면 {0}, 차단된 user면 {-1}
+var addScript = redis.NewScript(groupScoreLua + banLua + rejectBannedLua + `
+if redis.call('ZSCORE', KEYS[1], ARGV[3]) or redis.call('ZSCORE', KEYS[4], ARGV[3]) then
+	return {0}
+end
+redis.call('ZADD', board, ARGV[4], ARGV[3])
+local groupArgs = 5
+for i = 8, #ARGV, 2 do
+	redis.call('HSET', KEYS[2], ARGV[i], ARGV[i + 1])
+	if not ban then
+		redis.call('ZADD', ARGV[2] .. ARGV[i] .. ':' .. ARGV[i + 1], ARGV[4], ARGV[3])
+	end
+end
+if not ban then
+	redis.call('PUBLISH', ARGV[1], '{"name":' .. cjson.encode(ARGV[3]) .. ',"score":' .. ARGV[4] .. '}')
+end
+` + refreshUserGroupLua + writeResultLua)
+
+// segment 값이 바뀌면 이전 segment board에서 제거하고, 저장된 모든 segment board의 점수를 갱신합니다.
+// attr, value 쌍은 ARGV[segmentArgs]부터
+const updateSegmentsLua = `
+for i = segmentArgs, #ARGV, 2 do
+	local old = redis.call('HGET', KEYS[2], ARGV[i])
+	if old and old ~= ARGV[i + 1] then
+		redis.call('ZREM', ARGV[2] .. ARGV[i] .. ':' .. old, ARGV[3])
+	end
+	redis.call('HSET', KEYS[2], ARGV[i], ARGV[i + 1])
+end
+if not ban then
+	local segments = redis.call('HGETALL', KEYS[2])
+	for i = 1, #segments, 2 do
+		redis.call('ZADD', ARGV[2] .. segments[i] .. ':' .. segments[i + 1], ARGV[4], ARGV[3])
+	end
+end
+`
+
+const publishScoreLua = `
+if not ban then
+	redis.call('PUBLISH', ARGV[1], '{"name":' .. cjson.encode(ARGV[3]) .. ',"score":' .. ARGV[4] .. '}')
+end
+`
+
+// {1, score, rank, segments}. 없으면 {0}, 차단된 user면 {-1}
+var updateScript = redis.NewScript(groupScoreLua + banLua + rejectBannedLua + `
+if not redis.call('ZSCORE', board, ARGV[3]) then
+	return {0}
+end
+redis.call('ZADD', board, ARGV[4], ARGV[3])
+local groupArgs = 5
+local segmentArgs = 8
+` + updateSegmentsLua + publishScoreLua + refreshUserGroupLua + writeResultLua)
+
+// user의 segment hash가 expected(JSON object)와 같은지 확인합니다.
+const sameSegmentsLua = `
+local function sameSegments(key, expected)
+	local segments = cjson.decode(expected)
+	local stored = redis.call('HGETALL', key)
+	local count = 0
+	for i = 1, #stored, 2 do
+		if segments[stored[i]] ~= stored[i + 1] then
+			return false
+		end
+		count = count + 1
+	end
+	for _ in pairs(segments) do
+		count = count - 1
+	end
+	return count == 0
+end
+`
+
+// updateScript와 같지만 ARGV[8], ARGV[9]: 기존 score, segment(JSON)가 다르면 {-2}. attr, value 쌍은 ARGV[10...]
+var compareAndUpdateScript = redis.NewScript(groupScoreLua + sameSegmentsLua + banLua + rejectBannedLua + `
+local current = redis.call('ZSCORE', board, ARGV[3])
+if not current then
+	return {0}
+end
+if tonumber(current) ~= tonumber(ARGV[8]) or not sameSegments(KEYS[2], ARGV[9]) then
+	return {-2}
+end
+redis.call('ZADD', board, ARGV[4], ARGV[3])
+local groupArgs = 5
+local segmentArgs = 10
+` + updateSegmentsLua + publishScoreLua + refreshUserGroupLua + writeResultLua)
+
+// 없으면 추가하고 있으면 수정합니다. 추가했으면 1을 반환합니다.
+// 차단된 user는 shadow ban이어도 기록하지 않고 -1. 변경 알림은 SetUsers가 모아서 publish
+var setScript = redis.NewScript(groupScoreLua + banLua + `
+if ban then
+	return -1
+end
+local added = redis.call('ZADD', board, ARGV[4], ARGV[3])
+local groupArgs = 5
+local segmentArgs = 8
+` + updateSegmentsLua + refreshUserGroupLua + `
+return added
+`)
+
+// ARGV[1]: 변경 알림 channel, ARGV[2]: segment board prefix, ARGV[3]: name,
+// ARGV[4]: group member set prefix, ARGV[5]: group 점수 mode, ARGV[6]: top n
+// 차단된 user는 banned board에서 지웁니다. 차단 목록은 유지하고 group에서는 빠짐
+var deleteScript = redis.NewScript(groupScoreLua + `
+local groupArgs = 4
+` + deleteLua)
+
+// deleteScript와 같지만 ARGV[7], ARGV[8]: 기존 score, segment(JSON)가 다르면 -2
+var compareAndDeleteScript = redis.NewScript(groupScoreLua + sameSegmentsLua + `
+local groupArgs = 4
+local current = redis.call('ZSCORE', KEYS[1], ARGV[3]) or redis.call('ZSCORE', KEYS[4], ARGV[3])
+if not current then
+	return 0
+end
+if tonumber(current) ~= tonumber(ARGV[7]) or not sameSegments(KEYS[2], ARGV[8]) then
+	return -2
+end
+` + deleteLua)
+
+const deleteLua = `
+local removed = redis.call('ZREM', KEYS[1], ARGV[3])
+local removedBanned = redis.call('ZREM', KEYS[4], ARGV[3])
+local segments = redis.call('HGETALL', KEYS[2])
+for i = 1, #segments, 2 do
+	redis.call('ZREM', ARGV[2] .. segments[i] .. ':' .. segments[i + 1], ARGV[3])
+end
+redis.call('DEL', KEYS[2])
+if removed == 1 then
+	redis.call('PUBLISH', ARGV[1], '{"name":' .. cjson.encode(ARGV[3]) .. ',"deleted":true}')
+end
+` + leaveUserGroupLua + `
+return removed + removedBanned
+`
+
+// 삭제한 user를 group에서 빼고 group 점수를 다시 계산합니다.
+const leaveUserGroupLua = `
+local group = redis.call('HGET', KEYS[5], ARGV[3])
+if group then
+	redis.call('HDEL', KEYS[5], ARGV[3])
+	redis.call('SREM', ARGV[groupArgs] .. group, ARGV[3])
+	refreshGroup(KEYS[1], KEYS[6], ARGV[groupArgs], group, ARGV[groupArgs + 1], tonumber(ARGV[groupArgs + 2]))
+end
+`
+
+// user의 점수를 쓰는 script의 KEYS
+func (r *RedisStorage) userKeys(name string) []string {
+	return []string{r.zsetKey, r.userSegmentsKey(name), r.bansKey(), r.bannedKey(), r.userGroupsKey(), r.groupScoresKey()}
+}
+
+// user가 속한 group의 점수를 다시 계산하기 위한 script의 ARGV
+func (r *RedisStorage) groupArgs(groupMode string, groupTopN int) []interface{} {
+	return []interface{}{r.groupMembersPrefix(), groupMode, groupTopN}
+}
+
+func (r *RedisStorage) segmentPrefix() string {
+	return r.zsetKey + ":segment:"
+}
+
+func (r *RedisStorage) userSegmentsKey(name string) string {
+	if r.usersKey != "" {
+		return r.usersKey + ":user-segments:" + name
+	}
+	return r.zsetKey + ":user-segments:" + name
+}
+
+// sameSegmentsLua에 넘길 JSON object. segment가 없으면 {}
+func segmentsJSON(segments map[string]string) (string, error) {
+	if segments == nil {
+		segments = map[string]string{}
+	}
+	data, err := json.Marshal(segments)
+	return string(data), errors.Wrap(err, "json.Marshal")
+}
+
+// segment board를 읽기 위한 RedisStorage를 반환합니다.
+func (r *RedisStorage) Segment(attr string, value string) *RedisStorage {
+	return &RedisStorage{
+		zsetKey:  r.segmentPrefix() + attr + ":" + value,
+		usersKey: r.zsetKey,
+		client:   r.client,
+	}
+}
+
+func (r *RedisStorage) writeArgs(name string, score float64, segments map[string]string, groupMode string, groupTopN int) []interface{} {
+	attrs := make([]string, 0, len(segments))
+	for attr := range segments {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+
+	args := make([]interface{}, 0, 7+len(segments)*2)
+	args = append(args, r.ChangesChannel(), r.segmentPrefix(), name, score)
+	args = append(args, r.groupArgs(groupMode, groupTopN)...)
+	for _, attr := range attrs {
+		args = append(args, attr, segments[attr])
+	}
+	return args
+}
@@ -0,0 +1,179 @@
+package redisstorage
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// 전체 board와 banned board를 snapshot key로 복사하고 index(id, 생성 시각)에 기록합니다.
+// 오래된 것부터 지워서 ARGV[3]개만 남기고, 복사한 전체 board의 user 수를 반환합니다. 같은 id가 있으면 -1
+// KEYS[1]: 전체 board, KEYS[2]: snapshot, KEYS[3]: snapshot index, KEYS[4]: banned board, KEYS[5]: banned board의 snapshot
+// ARGV[1]: id, ARGV[2]: 생성 시각, ARGV[3]: retention, ARGV[4]: snapshot prefix, ARGV[5]: banned board snapshot suffix
+var snapshotScript = redis.NewScript(`
+if redis.call('ZSCORE', KEYS[3], ARGV[1]) then
+	return -1
+end
+redis.call('DEL', KEYS[2], KEYS[5])
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('ZUNIONSTORE', KEYS[2], 1, KEYS[1])
+end
+if redis.call('EXISTS', KEYS[4]) == 1 then
+	redis.call('ZUNIONSTORE', KEYS[5], 1, KEYS[4])
+end
+redis.call('ZADD', KEYS[3], ARGV[2], ARGV[1])
+local retention = tonumber(ARGV[3])
+if retention > 0 then
+	for _, id in ipairs(redis.call('ZRANGE', KEYS[3], 0, -retention - 1)) do
+		redis.call('DEL', ARGV[4] .. id, ARGV[4] .. id .. ARGV[5])
+		redis.call('ZREM', KEYS[3], id)
+	end
+end
+return redis.call('ZCARD', KEYS[2])
+`)
+
+// snapshot의 전체 board와 banned board로 두 board를 바꾸고, segment board와 모든 group 점수를 다시 맞춘 뒤 변경 알림을 publish 합니다.
+// 한 script에서 모두 바꾸므로 중간에 실패하거나 다른 기록이 끼어들어 board끼리 어긋나지 않음
+// 점수는 지금의 차단 목록에 따라 나눠서, snapshot 이후 차단된 user는 banned board로, 차단이 풀린 user는 전체 board로 돌아감
+// KEYS[1]: 전체 board, KEYS[2]: user의 group hash, KEYS[3]: group 점수 board, KEYS[4]: snapshot index, KEYS[5]: snapshot,
+// KEYS[6]: 차단 목록, KEYS[7]: banned board, KEYS[8]: banned board의 snapshot, KEYS[9]: segment board를 다시 만들 때 쓰는 임시 key
+// ARGV[1]: group member set prefix, ARGV[2]: id, ARGV[3]: group 점수 mode, ARGV[4]: top n,
+// ARGV[5]: segment board prefix, ARGV[6]: 변경 알림 channel
+var restoreScript = redis.NewScript(groupScoreLua + `
+if not redis.call('ZSCORE', KEYS[4], ARGV[2]) then
+	return 0
+end
+redis.call('DEL', KEYS[1], KEYS[7])
+local sources = {}
+for _, key in ipairs({KEYS[5], KEYS[8]}) do
+	if redis.call('EXISTS', key) == 1 then
+		table.insert(sources, key)
+	end
+end
+if #sources > 0 then
+	redis.call('ZUNIONSTORE', KEYS[1], #sources, unpack(sources))
+end
+for _, name in ipairs(redis.call('HKEYS', KEYS[6])) do
+	local score = redis.call('ZSCORE', KEYS[1], name)
+	if score then
+		redis.call('ZREM', KEYS[1], name)
+		redis.call('ZADD', KEYS[7], score, name)
+	end
+end
+-- segment board는 restore한 전체 board의 점수로 바꾸고, 전체 board에 없는 user는 뺌
+local cursor = '0'
+repeat
+	local scanned = redis.call('SCAN', cursor, 'MATCH', ARGV[5] .. '*', 'COUNT', 1000)
+	cursor = scanned[1]
+	for _, key in ipairs(scanned[2]) do
+		if redis.call('ZINTERSTORE', KEYS[9], 2, key, KEYS[1], 'WEIGHTS', 0, 1) > 0 then
+			redis.call('RENAME', KEYS[9], key)
+		else
+			redis.call('DEL', key)
+		end
+	end
+until cursor == '0'
+for _, group in ipairs(redis.call('ZRANGE', KEYS[3], 0, -1)) do
+	refreshGroup(KEYS[1], KEYS[3], ARGV[1], group, ARGV[3], tonumber(ARGV[4]))
+end
+redis.call('PUBLISH', ARGV[6], '{"deleted":true}')
+return 1
+`)
+
+// snapshot의 banned board key는 snapshot key 뒤에 붙임
+const snapshotBannedSuffix = ":banned"
+
+func (r *RedisStorage) snapshotsKey() string {
+	return r.zsetKey + ":snapshots"
+}
+
+func (r *RedisStorage) snapshotPrefix() string {
+	return r.zsetKey + ":snapshot:"
+}
+
+// 차단된 user의 점수(banned board)를 복사한 snapshot
+func (r *RedisStorage) snapshotBannedKey(id string) string {
+	return r.snapshotPrefix() + id + snapshotBannedSuffix
+}
+
+// 전체 board의 snapshot을 만듭니다. retention이 0보다 크면 최근 retention개만 남깁니다.
+// 복사한 user 수를 반환하며, 같은 id가 있으면 false를 반환합니다.
+func (r *RedisStorage) Snapshot(ctx context.Context, id string, createdAt int64, retention int) (bool, int64, error) {
+	keys := []string{r.zsetKey, r.snapshotPrefix() + id, r.snapshotsKey(), r.bannedKey(), r.snapshotBannedKey(id)}
+	count, err := snapshotScript.Run(ctx, r.client, keys, id, createdAt, retention, r.snapshotPrefix(), snapshotBannedSuffix).Int64()
+	if err != nil {
+		return false, 0, errors.Wrap(err, "snapshotScript.Run")
+	}
+	return count >= 0, count, nil
+}
+
+// 오래된 순서대로 snapshot id, 생성 시각(unix), user 수를 반환합니다.
+func (r *RedisStorage) Snapshots(ctx context.Context) ([]string, []int64, []int64, error) {
+	index, err := r.client.ZRangeWithScores(ctx, r.snapshotsKey(), 0, -1).Result()
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "r.client.ZRangeWithScores")
+	}
+	pipe := r.client.Pipeline()
+	countCmds := make([]*redis.IntCmd, 0, len(index))
+	for _, z := range index {
+		countCmds = append(countCmds, pipe.ZCard(ctx, r.snapshotPrefix()+z.Member.(string)))
+	}
+	if len(index) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "pipe.Exec")
+		}
+	}
+	ids := make([]string, 0, len(index))
+	createdAts := make([]int64, 0, len(index))
+	counts := make([]int64, 0, len(index))
+	for i, z := range index {
+		ids = append(ids, z.Member.(string))
+		createdAts = append(createdAts, int64(z.Score))
+		counts = append(counts, countCmds[i].Val())
+	}
+	return ids, createdAts, counts, nil
+}
+
+// snapshot의 점수를 전체 board와 banned board에 덮어쓰고 group 점수와 segment board를 다시 맞춥니다.
+// group 소속, segment 속성, 차단 목록은 현재 상태를 그대로 사용합니다. 없는 snapshot이면 false
+func (r *RedisStorage) Restore(ctx context.Context, id string, groupMode string, groupTopN int) (bool, error) {
+	keys := append(r.groupKeys(), r.snapshotsKey(), r.snapshotPrefix()+id, r.bansKey(), r.bannedKey(), r.snapshotBannedKey(id), r.zsetKey+":restoring")
+	restored, err := restoreScript.Run(ctx, r.client, keys, r.groupMembersPrefix(), id, groupMode, groupTopN, r.segmentPrefix(), r.ChangesChannel()).Int()
+	if err != nil {
+		return false, errors.Wrap(err, "restoreScript.Run")
+	}
+	return restored == 1, nil
+}
+
+func (r *RedisStorage) DeleteSnapshot(ctx context.Context, id string) (bool, error) {
+	pipe := r.client.TxPipeline()
+	removedCmd := pipe.ZRem(ctx, r.snapshotsKey(), id)
+	pipe.Del(ctx, r.snapshotPrefix()+id, r.snapshotBannedKey(id))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, errors.Wrap(err, "pipe.Exec")
+	}
+	return removedCmd.Val() == 1, nil
+}
+
+// snapshot board를 읽기 위한 RedisStorage를 반환합니다.
+func (r *RedisStorage) SnapshotBoard(id string) *RedisStorage {
+	return &RedisStorage{
+		zsetKey: r.snapshotPrefix() + id,
+		client:  r.client,
+	}
+}
+
+// snapshot의 생성 시각(unix)과 user 수를 반환합니다. 없는 snapshot이면 false
+func (r *RedisStorage) SnapshotInfo(ctx context.Context, id string) (bool, int64, int64, error) {
+	pipe := r.client.Pipeline()
+	createdAtCmd := pipe.ZScore(ctx, r.snapshotsKey(), id)
+	countCmd := pipe.ZCard(ctx, r.snapshotPrefix()+id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, 0, 0, nil
+		}
+		return false, 0, 0, errors.Wrap(err, "pipe.Exec")
+	}
+	return true, int64(createdAtCmd.Val()), countCmd.Val(), nil
+}
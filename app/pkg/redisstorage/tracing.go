@@ -0,0 +1,54 @@
+package redisstorage
+
+import (
+	"context"
+	"strings"
+
+	"github.com/JeongMinSik/go-leaderboard/pkg/tracing"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var pipelineCommandsKey = attribute.Key("db.redis.num_cmd")
+
+// 명령마다 "redis <명령>" span을 만듭니다. 인자에는 user 정보가 있으므로 명령 이름만 기록
+// pipeline(MULTI 포함)은 "redis pipeline" span 하나로 기록
+type tracingHook struct{}
+
+func (tracingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = tracing.Start(ctx, "redis "+cmd.Name(), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationKey.String(cmd.Name())))
+	return ctx, nil
+}
+
+func (tracingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	endSpan(trace.SpanFromContext(ctx), []redis.Cmder{cmd})
+	return nil
+}
+
+func (tracingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name())
+	}
+	ctx, _ = tracing.Start(ctx, "redis pipeline", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationKey.String(strings.Join(names, " ")), pipelineCommandsKey.Int(len(cmds))))
+	return ctx, nil
+}
+
+func (tracingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	endSpan(trace.SpanFromContext(ctx), cmds)
+	return nil
+}
+
+func endSpan(span trace.Span, cmds []redis.Cmder) {
+	for _, cmd := range cmds {
+		if failed(cmd) {
+			tracing.End(span, cmd.Err(), true)
+			return
+		}
+	}
+	span.End()
+}
@@ -0,0 +1,138 @@
+package redisstorage
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// script가 publish하는 변경 알림과 같은 형식
+type changeData struct {
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+type Record struct {
+	Name     string
+	Score    float64
+	Segments map[string]string
+}
+
+// names 순서대로 저장된 segment를 반환합니다.
+func (r *RedisStorage) UserSegments(ctx context.Context, names []string) ([]map[string]string, error) {
+	pipe := r.client.Pipeline()
+	segmentCmds := make([]*redis.StringStringMapCmd, 0, len(names))
+	for _, name := range names {
+		segmentCmds = append(segmentCmds, pipe.HGetAll(ctx, r.userSegmentsKey(name)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, errors.Wrap(err, "pipe.Exec")
+	}
+	result := make([]map[string]string, 0, len(names))
+	for _, cmd := range segmentCmds {
+		result = append(result, cmd.Val())
+	}
+	return result, nil
+}
+
+// import할 user의 현재 상태
+type RecordState struct {
+	Exists bool
+	Score  float64
+	// 차단 목록에 있으면 true. 차단된 user의 점수는 전체 board에 없으므로 Exists는 false
+	Banned bool
+}
+
+// names 순서대로 {전체 board의 score, 차단 여부}. 없는 user의 score는 빈 문자열
+// KEYS[1]: 전체 board, KEYS[2]: 차단 목록
+var recordStatesScript = redis.NewScript(`
+local result = {}
+for i, name in ipairs(ARGV) do
+	result[i] = {redis.call('ZSCORE', KEYS[1], name) or '', redis.call('HEXISTS', KEYS[2], name)}
+end
+return result
+`)
+
+// names 순서대로 이미 있는 user인지와 점수, 차단 여부를 반환합니다.
+func (r *RedisStorage) RecordStates(ctx context.Context, names []string) ([]RecordState, error) {
+	args := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		args = append(args, name)
+	}
+	result, err := recordStatesScript.Run(ctx, r.client, []string{r.zsetKey, r.bansKey()}, args...).Slice()
+	if err != nil {
+		return nil, errors.Wrap(err, "recordStatesScript.Run")
+	}
+	states := make([]RecordState, 0, len(names))
+	for _, value := range result {
+		fields, _ := value.([]interface{})
+		if len(fields) != 2 {
+			return nil, errors.New("invalid record state")
+		}
+		banned, _ := fields[1].(int64)
+		state := RecordState{Banned: banned == 1}
+		if score, _ := fields[0].(string); score != "" {
+			if state.Score, err = strconv.ParseFloat(score, 64); err != nil {
+				return nil, errors.Wrap(err, "strconv.ParseFloat")
+			}
+			state.Exists = true
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// records를 한 번의 pipeline으로 추가 또는 수정하고 속한 group 점수를 갱신합니다.
+// records 순서대로 1: 추가, 0: 수정, -1: 차단된 user라서 기록하지 않음
+// 기록한 user가 있으면 record마다 알리지 않고 마지막으로 기록한 user로 변경 알림을 한 번 publish 합니다.
+func (r *RedisStorage) SetUsers(ctx context.Context, records []Record, groupMode string, groupTopN int) ([]int64, error) {
+	results, err := r.setUsers(ctx, records, groupMode, groupTopN)
+	if err != nil && strings.HasPrefix(errors.Cause(err).Error(), "NOSCRIPT") {
+		// pipeline에서는 EVALSHA가 실패해도 script를 다시 보내지 않으므로 직접 load 후 재시도
+		if err := setScript.Load(ctx, r.client).Err(); err != nil {
+			return nil, errors.Wrap(err, "setScript.Load")
+		}
+		results, err = r.setUsers(ctx, records, groupMode, groupTopN)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		if results[i] >= 0 {
+			change, err := json.Marshal(changeData{Name: records[i].Name, Score: records[i].Score})
+			if err != nil {
+				return nil, errors.Wrap(err, "json.Marshal")
+			}
+			if err := r.client.Publish(ctx, r.ChangesChannel(), change).Err(); err != nil {
+				return nil, errors.Wrap(err, "r.client.Publish")
+			}
+			break
+		}
+	}
+	return results, nil
+}
+
+func (r *RedisStorage) setUsers(ctx context.Context, records []Record, groupMode string, groupTopN int) ([]int64, error) {
+	pipe := r.client.Pipeline()
+	setCmds := make([]*redis.Cmd, 0, len(records))
+	for _, record := range records {
+		args := r.writeArgs(record.Name, record.Score, record.Segments, groupMode, groupTopN)
+		setCmds = append(setCmds, setScript.EvalSha(ctx, pipe, r.userKeys(record.Name), args...))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, errors.Wrap(err, "pipe.Exec")
+	}
+	results := make([]int64, 0, len(records))
+	for _, cmd := range setCmds {
+		result, err := cmd.Int64()
+		if err != nil {
+			return nil, errors.Wrap(err, "cmd.Int64")
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// span을 보낼 곳
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+const (
+	ServiceName         = "go-leaderboard"
+	instrumentationName = "github.com/JeongMinSik/go-leaderboard"
+)
+
+// span에 붙이는 attribute
+var (
+	BoardKey = attribute.Key("leaderboard.board")
+	UserKey  = attribute.Key("leaderboard.user")
+)
+
+// exporter로 span을 보내는 TracerProvider를 전역으로 설정하고, 종료할 때 남은 span을 보내는 함수를 반환합니다.
+// exporter가 비어 있거나 none이면 span을 만들지 않음
+// OTLP는 gRPC로 OTEL_EXPORTER_OTLP_ENDPOINT(기본 localhost:4317)에 보내고, service 이름은 OTEL_SERVICE_NAME(기본 go-leaderboard)
+func Setup(ctx context.Context, exporter string) (func(context.Context) error, error) {
+	// 들어온 요청의 W3C traceparent, baggage header를 이어받음
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		spanExporter, err = otlptracegrpc.New(ctx)
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New()
+	default:
+		return nil, errors.New("invalid trace exporter: " + exporter)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "spanExporter")
+	}
+
+	res, err := resource.Merge(
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(ServiceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "resource.Merge")
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// 전역 TracerProvider로 span을 시작합니다. Setup 전에는 아무것도 기록하지 않음
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// err를 기록하고 span을 끝냅니다. failed가 true일 때만 span을 실패로 표시
+func End(span trace.Span, err error, failed bool) {
+	if err != nil {
+		span.RecordError(err)
+		if failed {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+// 요청의 span을 이어받지만, 요청이 끝나거나 취소되어도 ctx는 취소되지 않습니다.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
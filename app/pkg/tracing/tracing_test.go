@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+	for _, exporter := range []string{"", ExporterNone, ExporterStdout} {
+		shutdown, err := Setup(context.Background(), exporter)
+		if assert.NoError(t, err, exporter) {
+			assert.NoError(t, shutdown(context.Background()), exporter)
+		}
+	}
+	_, err := Setup(context.Background(), "jaeger")
+	assert.Error(t, err)
+}
+
+func TestStartEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, span := Start(ctx, "not found")
+	End(span, errors.New("user not found"), false)
+	_, span = Start(ctx, "failed")
+	End(span, errors.New("redis down"), true)
+	parent.End()
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 3) {
+		// 실패가 아닌 error는 기록만 함
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+		assert.Len(t, spans[0].Events(), 1)
+		assert.Equal(t, codes.Error, spans[1].Status().Code)
+		assert.Equal(t, spans[2].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	}
+}
+
+func TestDetach(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, span := Start(ctx, "request")
+	defer span.End()
+	detached := Detach(ctx)
+	cancel()
+	assert.NoError(t, detached.Err())
+	assert.Equal(t, trace.SpanFromContext(ctx), trace.SpanFromContext(detached))
+}